@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/adjustment"
+)
+
+var (
+	adjustChain    string
+	adjustAmount   float64
+	adjustReason   string
+	adjustOperator string
+	adjustLimit    int
+)
+
+// adjustCmd 手动积分调整命令
+var adjustCmd = &cobra.Command{
+	Use:   "adjust",
+	Short: "手动调整用户积分",
+	Long:  "发放或扣回用户积分（任务奖励、补偿、作弊扣回），每次调整都会记录操作人和原因，并可撤销",
+}
+
+// adjustAddCmd 发放或扣回积分
+var adjustAddCmd = &cobra.Command{
+	Use:   "add <address>",
+	Short: "发放（正数）或扣回（负数）积分",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runAdjustAdd(args[0])
+	},
+}
+
+// adjustRevertCmd 撤销调整
+var adjustRevertCmd = &cobra.Command{
+	Use:   "revert <id>",
+	Short: "撤销一次积分调整",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无效的调整 ID: %s\n", args[0])
+			os.Exit(1)
+		}
+		runAdjustRevert(id)
+	},
+}
+
+// adjustListCmd 查询调整记录
+var adjustListCmd = &cobra.Command{
+	Use:   "list [address]",
+	Short: "查询积分调整记录",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		address := ""
+		if len(args) > 0 {
+			address = args[0]
+		}
+		runAdjustList(address)
+	},
+}
+
+func init() {
+	adjustCmd.PersistentFlags().StringVar(&adjustChain, "chain", "", "链名称")
+
+	adjustAddCmd.Flags().Float64Var(&adjustAmount, "amount", 0, "调整积分（负数表示扣回）")
+	adjustAddCmd.MarkFlagRequired("chain")
+	adjustAddCmd.MarkFlagRequired("amount")
+
+	for _, cmd := range []*cobra.Command{adjustAddCmd, adjustRevertCmd} {
+		cmd.Flags().StringVar(&adjustReason, "reason", "", "原因")
+		cmd.Flags().StringVar(&adjustOperator, "operator", os.Getenv("USER"), "操作人")
+		cmd.MarkFlagRequired("reason")
+	}
+	adjustListCmd.Flags().IntVar(&adjustLimit, "limit", 50, "最多显示条数")
+
+	adjustCmd.AddCommand(adjustAddCmd, adjustRevertCmd, adjustListCmd)
+	rootCmd.AddCommand(adjustCmd)
+}
+
+// newAdjustmentCommandService 创建命令行使用的积分调整服务
+func newAdjustmentCommandService() (*adjustment.AdjustmentService, func()) {
+	cfg, log, db := initCommand()
+	service := adjustment.NewAdjustmentService(repository.NewAdjustmentRepository(db), chainNames(cfg), log)
+	return service, func() { db.Close() }
+}
+
+func runAdjustAdd(address string) {
+	service, closeDB := newAdjustmentCommandService()
+	defer closeDB()
+
+	adjusted, err := service.Adjust(context.Background(), adjustChain, address, adjustAmount, adjustReason, adjustOperator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "调整积分失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ 已为 %s 调整 %+.4f 积分（调整 ID: %d）\n", adjusted.UserAddress, adjusted.Amount, adjusted.ID)
+}
+
+func runAdjustRevert(id int64) {
+	service, closeDB := newAdjustmentCommandService()
+	defer closeDB()
+
+	reverted, err := service.Revert(context.Background(), id, adjustReason, adjustOperator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "撤销调整 #%d 失败: %v\n", id, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ 已撤销调整 #%d（%s 积分 %+.4f）\n", id, reverted.UserAddress, -reverted.Amount)
+}
+
+func runAdjustList(address string) {
+	service, closeDB := newAdjustmentCommandService()
+	defer closeDB()
+
+	adjustments, err := service.ListAdjustments(context.Background(), adjustChain, address, 0, adjustLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "查询调整记录失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tCHAIN\tADDRESS\tAMOUNT\tSTATUS\tOPERATOR\tREASON")
+	for _, a := range adjustments {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%+.4f\t%s\t%s\t%s\n",
+			a.ID, a.CreatedAt.Format("2006-01-02 15:04:05"), a.ChainName, a.UserAddress,
+			a.Amount, a.Status, a.Operator, a.Reason)
+	}
+	w.Flush()
+}
@@ -13,11 +13,20 @@ import (
 	"my-token-points/config"
 	"my-token-points/internal/api"
 	"my-token-points/internal/pkg/database"
+	"my-token-points/internal/pkg/leader"
 	"my-token-points/internal/pkg/logger"
 	"my-token-points/internal/repository"
+	"my-token-points/internal/service/adjustment"
+	"my-token-points/internal/service/anomaly"
 	"my-token-points/internal/service/balance"
+	"my-token-points/internal/service/deadletter"
+	"my-token-points/internal/service/exclusion"
+	"my-token-points/internal/service/job"
 	"my-token-points/internal/service/points"
+	"my-token-points/internal/service/redemption"
+	"my-token-points/internal/service/referral"
 	"my-token-points/internal/service/scheduler"
+	"my-token-points/internal/service/syncstatus"
 )
 
 // apiCmd API服务命令
@@ -57,30 +66,29 @@ func runAPI() {
 	log.Info("✅ 数据库连接成功")
 
 	// 4. 创建 Repository 实例
+	syncRepo := repository.NewSyncRepository(db)
+	failedEventRepo := repository.NewFailedEventRepository(db)
 	balanceRepo := repository.NewBalanceRepository(db)
+	anomalyRepo := repository.NewAnomalyRepository(db)
 	pointsRepo := repository.NewPointsRepository(db)
+	exclusionRepo := repository.NewExclusionRepository(db)
+	referralRepo := repository.NewReferralRepository(db)
 
 	// 5. 创建服务实例
-	balanceService := balance.NewBalanceService(balanceRepo, log)
+	balanceService := balance.NewBalanceService(balanceRepo, anomalyRepo, cfg.Listener.NegativeBalancePolicy, log)
 
-	pointsConfig := &points.PointsConfig{
-		HourlyRate:   cfg.Points.HourlyRate,
-		CalcInterval: cfg.Points.CalcInterval,
-	}
-	pointsService := points.NewPointsService(pointsRepo, balanceRepo, log, pointsConfig)
+	pointsConfig := pointsServiceConfig(cfg)
+	exclusionService := exclusion.NewExclusionService(exclusionRepo, cfg.Points.ExcludedAddresses, log)
+	sybilService := newSybilService(cfg, db, exclusionService, log)
+	pointsService := points.NewPointsService(pointsRepo, balanceRepo, exclusionService, referralRepo, repository.NewRecalculationRepository(db), syncRepo, sybilService, log, pointsConfig)
 
 	// 6. 创建调度器（用于手动触发计算）
 	schedulerConfig := &scheduler.SchedulerConfig{
 		EnableCalculation: false, // API服务中不启动自动调度
-		Chains:            []scheduler.ChainConfig{},
-	}
-	for _, chain := range cfg.Chains {
-		schedulerConfig.Chains = append(schedulerConfig.Chains, scheduler.ChainConfig{
-			Name:    chain.Name,
-			Enabled: true,
-		})
+		Chains:            schedulerChains(cfg),
 	}
-	schedulerService := scheduler.NewScheduler(pointsService, schedulerConfig, log)
+	schedulerService := scheduler.NewScheduler(pointsService, sybilService, schedulerConfig, log)
+	jobService := job.NewJobService(repository.NewJobRepository(db), pointsService, chainNames(cfg), log)
 
 	// 7. 创建API服务器
 	serverConfig := &api.ServerConfig{
@@ -88,15 +96,37 @@ func runAPI() {
 		Port: cfg.API.Port,
 		Mode: cfg.API.Mode,
 	}
-	apiServer := api.NewServer(serverConfig, balanceService, pointsService, schedulerService, log)
+	services := &api.Services{
+		Balance:    balanceService,
+		Points:     pointsService,
+		Scheduler:  schedulerService,
+		SyncStatus: syncstatus.NewSyncStatusService(syncRepo, log),
+		DeadLetter: deadletter.NewDeadLetterService(failedEventRepo, log),
+		Anomaly:    anomaly.NewAnomalyService(anomalyRepo, log),
+		Exclusion:  exclusionService,
+		Referral:   referral.NewReferralService(referralRepo, log),
+		Adjustment: adjustment.NewAdjustmentService(repository.NewAdjustmentRepository(db), chainNames(cfg), log),
+		Redemption: redemption.NewRedemptionService(repository.NewRedemptionRepository(db), chainNames(cfg), log),
+		Sybil:      sybilService,
+		Jobs:       jobService,
+		Leader:     leader.NewElector(db, schedulerElection, log),
+	}
+	apiServer := api.NewServer(serverConfig, services, log)
 
-	// 8. 启动API服务器
+	// 8. 启动API服务器和回溯/重算任务执行进程
 	go func() {
 		if err := apiServer.Start(); err != nil {
 			log.Fatalf("API服务器启动失败: %v", err)
 		}
 	}()
 
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	jobsDone := make(chan struct{})
+	go func() {
+		defer close(jobsDone)
+		jobService.Run(jobCtx)
+	}()
+
 	log.Infof("✅ API服务启动完成 (http://%s:%d)", cfg.API.Host, cfg.API.Port)
 	log.Infof("API文档: http://%s:%d/api/v1", cfg.API.Host, cfg.API.Port)
 
@@ -115,5 +145,9 @@ func runAPI() {
 		log.Errorf("停止API服务器失败: %v", err)
 	}
 
+	// 停止任务执行进程，执行中的任务在当前周期完成后释放，重启后续跑
+	stopJobs()
+	<-jobsDone
+
 	log.Info("✅ API服务已停止")
 }
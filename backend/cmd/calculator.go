@@ -11,8 +11,10 @@ import (
 
 	"my-token-points/config"
 	"my-token-points/internal/pkg/database"
+	"my-token-points/internal/pkg/leader"
 	"my-token-points/internal/pkg/logger"
 	"my-token-points/internal/repository"
+	"my-token-points/internal/service/exclusion"
 	"my-token-points/internal/service/points"
 	"my-token-points/internal/service/scheduler"
 )
@@ -54,42 +56,47 @@ func runCalculator() {
 	log.Info("✅ 数据库连接成功")
 
 	// 4. 创建 Repository 实例
+	syncRepo := repository.NewSyncRepository(db)
 	balanceRepo := repository.NewBalanceRepository(db)
 	pointsRepo := repository.NewPointsRepository(db)
+	exclusionRepo := repository.NewExclusionRepository(db)
+	referralRepo := repository.NewReferralRepository(db)
 
 	// 5. 创建积分服务
-	pointsConfig := &points.PointsConfig{
-		HourlyRate:     cfg.Points.HourlyRate,
-		CalcInterval:   cfg.Points.CalcInterval,
-		EnableBackfill: cfg.Points.EnableBackfill,
-	}
-	pointsService := points.NewPointsService(pointsRepo, balanceRepo, log, pointsConfig)
+	pointsConfig := pointsServiceConfig(cfg)
+	exclusionService := exclusion.NewExclusionService(exclusionRepo, cfg.Points.ExcludedAddresses, log)
+	sybilService := newSybilService(cfg, db, exclusionService, log)
+	pointsService := points.NewPointsService(pointsRepo, balanceRepo, exclusionService, referralRepo, repository.NewRecalculationRepository(db), syncRepo, sybilService, log, pointsConfig)
 
 	// 6. 创建调度器配置
 	schedulerConfig := &scheduler.SchedulerConfig{
 		EnableCalculation: true,
 		CronExpression:    cfg.Points.CronExpression,
-		Chains:            []scheduler.ChainConfig{},
+		EnableBackfill:    cfg.Points.EnableBackfill,
+		BackfillOnStartup: cfg.Points.EnableBackfill && cfg.Points.BackfillOnStartup,
+		BackfillMaxDays:   cfg.Points.BackfillMaxDays,
+		Chains:            schedulerChains(cfg),
 	}
-
-	// 添加链配置
-	for _, chain := range cfg.Chains {
-		schedulerConfig.Chains = append(schedulerConfig.Chains, scheduler.ChainConfig{
-			Name:    chain.Name,
-			Enabled: true,
-		})
+	if cfg.Points.ExpiryDays > 0 {
+		schedulerConfig.ExpiryCronExpression = cfg.Points.ExpiryCron
+	}
+	if cfg.Points.Sybil.Enabled {
+		schedulerConfig.SybilCronExpression = cfg.Points.Sybil.AnalysisCron
 	}
 
+
 	// 7. 创建调度器
-	schedulerService := scheduler.NewScheduler(pointsService, schedulerConfig, log)
+	schedulerService := scheduler.NewScheduler(pointsService, sybilService, schedulerConfig, log)
 
-	// 8. 启动调度器
+	// 8. 竞选领导者并启动调度器（多实例部署时其他实例待命，领导者退出后自动接管）
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	if err := schedulerService.Start(ctx); err != nil {
-		log.Fatalf("启动调度器失败: %v", err)
-	}
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		runSchedulerAsLeader(ctx, leader.NewElector(db, schedulerElection, log), schedulerService, log)
+	}()
 
 	log.Info("✅ 积分计算服务启动完成")
 
@@ -99,9 +106,8 @@ func runCalculator() {
 	<-sigChan
 
 	log.Info("收到关闭信号，正在停止...")
-	if err := schedulerService.Stop(); err != nil {
-		log.Errorf("停止调度器失败: %v", err)
-	}
+	cancel()
+	<-schedulerDone
 	log.Info("✅ 积分计算服务已停止")
 }
 
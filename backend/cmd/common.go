@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/config"
+	"my-token-points/internal/pkg/database"
+	"my-token-points/internal/pkg/leader"
+	"my-token-points/internal/pkg/logger"
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/exclusion"
+	"my-token-points/internal/service/listener"
+	"my-token-points/internal/service/points"
+	"my-token-points/internal/service/scheduler"
+	"my-token-points/internal/service/sybil"
+)
+
+// initCommand 加载配置、初始化日志和数据库，供一次性执行的子命令使用
+func initCommand() (*config.Config, *logrus.Logger, *sqlx.DB) {
+	cfg, err := config.LoadConfig(cfgFile, env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	log := logger.InitLogger(cfg.App.LogLevel)
+
+	db, err := database.InitDB(&cfg.Database)
+	if err != nil {
+		log.Fatalf("数据库连接失败: %v", err)
+	}
+
+	return cfg, log, db
+}
+
+// custodyAddresses 收集各链配置的托管合约地址
+func custodyAddresses(cfg *config.Config) map[string][]string {
+	addresses := make(map[string][]string)
+	for i := range cfg.Chains {
+		addresses[cfg.Chains[i].Name] = cfg.Chains[i].CustodyAddresses()
+	}
+	return addresses
+}
+
+// chainNames 返回配置中的所有链名称
+func chainNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Chains))
+	for _, chain := range cfg.Chains {
+		names = append(names, chain.Name)
+	}
+	return names
+}
+
+// holdingStreakConfig 转换持有时长倍数配置，未启用时返回 nil
+func holdingStreakConfig(cfg *config.Config) *points.StreakConfig {
+	streak := cfg.Points.HoldingStreak
+	if !streak.Enabled {
+		return nil
+	}
+
+	streakConfig := &points.StreakConfig{Mode: streak.Mode}
+	for _, tier := range streak.Tiers {
+		streakConfig.Tiers = append(streakConfig.Tiers, points.StreakTier{
+			MinHolding: time.Duration(tier.MinDays * float64(24*time.Hour)),
+			Multiplier: tier.Multiplier,
+		})
+	}
+	return streakConfig
+}
+
+// newSybilService 创建女巫检测服务，未启用时返回 nil
+func newSybilService(cfg *config.Config, db *sqlx.DB, exclusionService *exclusion.ExclusionService, log *logrus.Logger) *sybil.SybilService {
+	detection := cfg.Points.Sybil
+	if !detection.Enabled {
+		return nil
+	}
+
+	return sybil.NewSybilService(
+		repository.NewRiskRepository(db),
+		exclusionService,
+		custodyAddresses(cfg),
+		chainNames(cfg),
+		&sybil.SybilConfig{
+			Lookback:           time.Duration(detection.LookbackHours) * time.Hour,
+			MaxCycleLength:     detection.MaxCycleLength,
+			RapidWindow:        time.Duration(detection.RapidWindowMinutes) * time.Minute,
+			RapidMinRoundTrips: detection.RapidMinRoundTrips,
+			MinClusterSize:     detection.MinClusterSize,
+			FlagThreshold:      detection.FlagThreshold,
+			ExcludeThreshold:   detection.ExcludeThreshold,
+			FlaggedMultiplier:  detection.FlaggedMultiplier,
+		},
+		log,
+	)
+}
+
+// pointsServiceConfig 转换积分配置，所有命令共用，保证与定时计算使用相同的规则
+func pointsServiceConfig(cfg *config.Config) *points.PointsConfig {
+	return &points.PointsConfig{
+		HourlyRate:       cfg.Points.HourlyRate,
+		CalcInterval:     cfg.Points.CalcInterval,
+		ChainIntervals:   chainCalcIntervals(cfg),
+		EnableBackfill:   cfg.Points.EnableBackfill,
+		StakedMultiplier: cfg.Points.StakedMultiplier,
+		CustodyAddresses: custodyAddresses(cfg),
+		ReferralRate:     cfg.Points.ReferralRate,
+
+		ExpiryPeriod:       time.Duration(cfg.Points.ExpiryDays) * 24 * time.Hour,
+		ExpiringSoonWindow: time.Duration(cfg.Points.ExpiringSoonDays) * 24 * time.Hour,
+		HoldingStreak:      holdingStreakConfig(cfg),
+		ReportingLocation:  reportingLocation(cfg),
+	}
+}
+
+// reportingLocation 按日/周/月汇总积分使用的时区（配置加载时已验证）
+func reportingLocation(cfg *config.Config) *time.Location {
+	location, err := time.LoadLocation(cfg.Points.ReportingTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// chainCalcIntervals 各链的计算周期长度
+func chainCalcIntervals(cfg *config.Config) map[string]time.Duration {
+	intervals := make(map[string]time.Duration)
+	for _, chain := range cfg.Chains {
+		if chain.Points.CalcInterval > 0 {
+			intervals[chain.Name] = chain.Points.CalcInterval
+		}
+	}
+	return intervals
+}
+
+// schedulerChains 转换各链的定时计算配置
+func schedulerChains(cfg *config.Config) []scheduler.ChainConfig {
+	chains := make([]scheduler.ChainConfig, 0, len(cfg.Chains))
+	for _, chain := range cfg.Chains {
+		chains = append(chains, scheduler.ChainConfig{
+			Name:           chain.Name,
+			Enabled:        chain.Points.CalculationEnabled(),
+			CronExpression: chain.Points.CronExpression,
+		})
+	}
+	return chains
+}
+
+// schedulerElection 调度器的领导者选举名称，多实例部署时只有领导者执行定时计算和回溯
+const schedulerElection = "scheduler"
+
+// runSchedulerAsLeader 竞选调度器领导者，当选期间运行调度器，阻塞直到 ctx 被取消
+func runSchedulerAsLeader(ctx context.Context, elector *leader.Elector, schedulerService *scheduler.Scheduler, log *logrus.Logger) {
+	elector.Run(ctx, func(leaderCtx context.Context) {
+		log.Info("已当选调度器领导者，启动积分计算调度器...")
+		if err := schedulerService.Start(leaderCtx); err != nil {
+			log.Errorf("启动调度器失败: %v", err)
+			return
+		}
+
+		<-leaderCtx.Done()
+		if err := schedulerService.Stop(); err != nil {
+			log.Errorf("停止调度器失败: %v", err)
+		}
+		log.Info("积分计算调度器已停止")
+	})
+}
+
+// listenerElection 链上事件监听的领导者选举名称，同一条链只有领导者扫描区块并更新余额
+func listenerElection(chainName string) string {
+	return "listener:" + chainName
+}
+
+// runListenerAsLeader 竞选链的监听领导者，当选期间运行事件监听器，阻塞直到 ctx 被取消
+// 监听器停止后不能再次启动，每次当选都重新创建
+func runListenerAsLeader(ctx context.Context, elector *leader.Elector, chainName string, newListener func() (*listener.EventListener, error), log *logrus.Logger) {
+	elector.Run(ctx, func(leaderCtx context.Context) {
+		log.Infof("已当选 %s 链监听领导者，启动事件监听...", chainName)
+		eventListener, err := newListener()
+		if err != nil {
+			log.Errorf("创建 %s 监听器失败: %v", chainName, err)
+			return
+		}
+
+		if err := eventListener.Start(leaderCtx); err != nil {
+			log.Errorf("启动 %s 监听器失败: %v", chainName, err)
+			eventListener.Stop()
+			return
+		}
+
+		<-leaderCtx.Done()
+		eventListener.Stop()
+		log.Infof("%s 监听器已停止", chainName)
+	})
+}
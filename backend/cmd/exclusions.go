@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/exclusion"
+)
+
+var (
+	exclusionsChain    string
+	exclusionsReason   string
+	exclusionsOperator string
+	exclusionsLimit    int
+)
+
+// exclusionsCmd 积分排除地址管理命令
+var exclusionsCmd = &cobra.Command{
+	Use:   "exclusions",
+	Short: "管理积分排除地址",
+	Long:  "查询、添加或移除不参与积分计算和排行榜的地址（国库、多签、DEX 池、跨链桥等）",
+}
+
+// exclusionsListCmd 查询排除地址
+var exclusionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "查询生效的排除地址",
+	Run: func(cmd *cobra.Command, args []string) {
+		runExclusionsList()
+	},
+}
+
+// exclusionsAddCmd 添加排除地址
+var exclusionsAddCmd = &cobra.Command{
+	Use:   "add <address>",
+	Short: "添加排除地址（不指定 --chain 表示所有链）",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runExclusionsUpdate(args[0], true)
+	},
+}
+
+// exclusionsRemoveCmd 移除排除地址
+var exclusionsRemoveCmd = &cobra.Command{
+	Use:   "remove <address>",
+	Short: "移除排除地址",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runExclusionsUpdate(args[0], false)
+	},
+}
+
+// exclusionsAuditCmd 查询审计记录
+var exclusionsAuditCmd = &cobra.Command{
+	Use:   "audit [address]",
+	Short: "查询排除地址的操作记录",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		address := ""
+		if len(args) > 0 {
+			address = args[0]
+		}
+		runExclusionsAudit(address)
+	},
+}
+
+func init() {
+	exclusionsCmd.PersistentFlags().StringVar(&exclusionsChain, "chain", "", "链名称（为空表示所有链）")
+
+	for _, cmd := range []*cobra.Command{exclusionsAddCmd, exclusionsRemoveCmd} {
+		cmd.Flags().StringVar(&exclusionsReason, "reason", "", "原因")
+		cmd.Flags().StringVar(&exclusionsOperator, "operator", os.Getenv("USER"), "操作人")
+	}
+	exclusionsAddCmd.MarkFlagRequired("reason")
+	exclusionsAuditCmd.Flags().IntVar(&exclusionsLimit, "limit", 50, "最多显示条数")
+
+	exclusionsCmd.AddCommand(exclusionsListCmd, exclusionsAddCmd, exclusionsRemoveCmd, exclusionsAuditCmd)
+	rootCmd.AddCommand(exclusionsCmd)
+}
+
+// newExclusionCommandService 创建命令行使用的排除地址服务
+func newExclusionCommandService() (*exclusion.ExclusionService, func()) {
+	cfg, log, db := initCommand()
+	service := exclusion.NewExclusionService(repository.NewExclusionRepository(db), cfg.Points.ExcludedAddresses, log)
+	return service, func() { db.Close() }
+}
+
+func runExclusionsList() {
+	service, closeDB := newExclusionCommandService()
+	defer closeDB()
+
+	addresses, err := service.ListExcludedAddresses(context.Background(), exclusionsChain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "查询排除地址失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAIN\tADDRESS\tSOURCE\tCREATED_BY\tREASON")
+	for _, a := range addresses {
+		chain := a.ChainName
+		if chain == "" {
+			chain = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", chain, a.Address, a.Source, a.CreatedBy, a.Reason)
+	}
+	w.Flush()
+}
+
+func runExclusionsUpdate(address string, add bool) {
+	service, closeDB := newExclusionCommandService()
+	defer closeDB()
+
+	var err error
+	if add {
+		_, err = service.AddExcludedAddress(context.Background(), exclusionsChain, address, exclusionsReason, exclusionsOperator)
+	} else {
+		err = service.RemoveExcludedAddress(context.Background(), exclusionsChain, address, exclusionsReason, exclusionsOperator)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "操作排除地址 %s 失败: %v\n", address, err)
+		os.Exit(1)
+	}
+
+	if add {
+		fmt.Printf("✅ 已将 %s 加入排除列表\n", address)
+	} else {
+		fmt.Printf("✅ 已将 %s 移出排除列表\n", address)
+	}
+}
+
+func runExclusionsAudit(address string) {
+	service, closeDB := newExclusionCommandService()
+	defer closeDB()
+
+	audits, err := service.ListAudit(context.Background(), exclusionsChain, address, 0, exclusionsLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "查询审计记录失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tCHAIN\tADDRESS\tACTION\tOPERATOR\tREASON")
+	for _, a := range audits {
+		chain := a.ChainName
+		if chain == "" {
+			chain = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			a.CreatedAt.Format("2006-01-02 15:04:05"), chain, a.Address, a.Action, a.Operator, a.Reason)
+	}
+	w.Flush()
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/exclusion"
+	"my-token-points/internal/service/points"
+)
+
+// exportPageSize 导出时每页读取的用户数
+const exportPageSize = 1000
+
+var (
+	exportChain  string
+	exportOutput string
+)
+
+// exportCmd 导出积分快照
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "导出用户积分快照 (CSV)",
+	Long:  "按积分降序导出指定链的用户积分，排除地址不会出现在导出结果中",
+	Run: func(cmd *cobra.Command, args []string) {
+		runExport()
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportChain, "chain", "", "链名称")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "输出文件路径（默认输出到标准输出）")
+	exportCmd.MarkFlagRequired("chain")
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport() {
+	cfg, log, db := initCommand()
+	defer db.Close()
+
+	exclusionService := exclusion.NewExclusionService(repository.NewExclusionRepository(db), cfg.Points.ExcludedAddresses, log)
+	pointsService := points.NewPointsService(
+		repository.NewPointsRepository(db),
+		repository.NewBalanceRepository(db),
+		exclusionService,
+		repository.NewReferralRepository(db),
+		nil,
+		nil,
+		nil,
+		log,
+		pointsServiceConfig(cfg),
+	)
+
+	out := os.Stdout
+	if exportOutput != "" {
+		file, err := os.Create(exportOutput)
+		if err != nil {
+			log.Fatalf("创建输出文件失败: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w := csv.NewWriter(out)
+	w.Write([]string{"rank", "chain", "address", "total_points", "last_calc_at"})
+
+	ctx := context.Background()
+	rank := 0
+	for offset := 0; ; offset += exportPageSize {
+		page, err := pointsService.ListUserPoints(ctx, exportChain, offset, exportPageSize)
+		if err != nil {
+			log.Fatalf("查询用户积分失败: %v", err)
+		}
+
+		for _, p := range page {
+			rank++
+			lastCalcAt := ""
+			if p.LastCalcAt != nil {
+				lastCalcAt = p.LastCalcAt.UTC().Format("2006-01-02T15:04:05Z")
+			}
+			w.Write([]string{
+				strconv.Itoa(rank), p.ChainName, p.UserAddress,
+				strconv.FormatFloat(p.TotalPoints, 'f', 10, 64), lastCalcAt,
+			})
+		}
+
+		if len(page) < exportPageSize {
+			break
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Fatalf("写入导出文件失败: %v", err)
+	}
+
+	if exportOutput != "" {
+		fmt.Fprintf(os.Stderr, "✅ 已导出 %d 个用户到 %s\n", rank, exportOutput)
+	}
+}
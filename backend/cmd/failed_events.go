@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/deadletter"
+)
+
+var (
+	failedEventsChain  string
+	failedEventsStatus string
+	failedEventsLimit  int
+)
+
+// failedEventsCmd 死信管理命令
+var failedEventsCmd = &cobra.Command{
+	Use:   "failed-events",
+	Short: "管理处理失败的事件（死信）",
+	Long:  "查询、重试或丢弃处理失败的事件日志",
+}
+
+// failedEventsListCmd 查询死信
+var failedEventsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "查询死信列表",
+	Run: func(cmd *cobra.Command, args []string) {
+		runFailedEventsList()
+	},
+}
+
+// failedEventsRetryCmd 重试死信
+var failedEventsRetryCmd = &cobra.Command{
+	Use:   "retry <id>",
+	Short: "将死信放回重试队列（由运行中的监听器处理）",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runFailedEventsUpdate(args[0], true)
+	},
+}
+
+// failedEventsDiscardCmd 丢弃死信
+var failedEventsDiscardCmd = &cobra.Command{
+	Use:   "discard <id>",
+	Short: "丢弃死信",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runFailedEventsUpdate(args[0], false)
+	},
+}
+
+func init() {
+	failedEventsListCmd.Flags().StringVar(&failedEventsChain, "chain", "", "按链过滤")
+	failedEventsListCmd.Flags().StringVar(&failedEventsStatus, "status", "", "按状态过滤 (pending/exhausted/resolved/discarded)")
+	failedEventsListCmd.Flags().IntVar(&failedEventsLimit, "limit", 50, "最多显示条数")
+
+	failedEventsCmd.AddCommand(failedEventsListCmd, failedEventsRetryCmd, failedEventsDiscardCmd)
+	rootCmd.AddCommand(failedEventsCmd)
+}
+
+func runFailedEventsList() {
+	_, log, db := initCommand()
+	defer db.Close()
+
+	service := deadletter.NewDeadLetterService(repository.NewFailedEventRepository(db), log)
+
+	events, err := service.ListFailedEvents(context.Background(), failedEventsChain, failedEventsStatus, 0, failedEventsLimit)
+	if err != nil {
+		log.Fatalf("查询死信失败: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCHAIN\tBLOCK\tTX\tLOG\tSTATUS\tATTEMPTS\tERROR")
+	for _, e := range events {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%d\t%s\t%d\t%s\n",
+			e.ID, e.ChainName, e.BlockNumber, e.TxHash, e.LogIndex, e.Status, e.Attempts, e.ErrorMessage)
+	}
+	w.Flush()
+}
+
+func runFailedEventsUpdate(idArg string, retry bool) {
+	id, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "无效的死信ID: %s\n", idArg)
+		os.Exit(1)
+	}
+
+	_, log, db := initCommand()
+	defer db.Close()
+
+	service := deadletter.NewDeadLetterService(repository.NewFailedEventRepository(db), log)
+
+	if retry {
+		err = service.RetryFailedEvent(context.Background(), id)
+	} else {
+		err = service.DiscardFailedEvent(context.Background(), id)
+	}
+	if err != nil {
+		log.Fatalf("操作死信 %d 失败: %v", id, err)
+	}
+
+	if retry {
+		fmt.Printf("✅ 死信 %d 已放回重试队列\n", id)
+	} else {
+		fmt.Printf("✅ 死信 %d 已丢弃\n", id)
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"my-token-points/config"
 	"my-token-points/internal/pkg/database"
+	"my-token-points/internal/pkg/leader"
 	"my-token-points/internal/pkg/logger"
 	"my-token-points/internal/repository"
 	"my-token-points/internal/service/balance"
@@ -61,10 +62,13 @@ func runListener() {
 
 	// 4. 创建 Repository 实例
 	syncRepo := repository.NewSyncRepository(db)
+	failedEventRepo := repository.NewFailedEventRepository(db)
+	rawEventRepo := repository.NewRawEventRepository(db)
 	balanceRepo := repository.NewBalanceRepository(db)
+	anomalyRepo := repository.NewAnomalyRepository(db)
 
 	// 5. 创建 Service 实例
-	balanceService := balance.NewBalanceService(balanceRepo, log)
+	balanceService := balance.NewBalanceService(balanceRepo, anomalyRepo, cfg.Listener.NegativeBalancePolicy, log)
 
 	// 6. 创建上下文
 	ctx, cancel := context.WithCancel(context.Background())
@@ -92,31 +96,23 @@ func runListener() {
 		wg.Add(1)
 		go func(chain config.ChainConfig) {
 			defer wg.Done()
-			log.Infof("启动 %s 链的事件监听...", chain.Name)
-
-			// 创建事件监听器
-			eventListener, err := listener.NewEventListener(
-				chain.Name,
-				&chain,
-				int(cfg.Confirmation.Blocks),
-				syncRepo,
-				balanceService,
-				log,
-			)
-			if err != nil {
-				log.Errorf("创建 %s 监听器失败: %v", chain.Name, err)
-				return
-			}
-
-			// 启动监听
-			if err := eventListener.Start(ctx); err != nil {
-				log.Errorf("启动 %s 监听器失败: %v", chain.Name, err)
-				return
-			}
-
-			<-ctx.Done()
-			eventListener.Stop()
-			log.Infof("%s 监听器已停止", chain.Name)
+			log.Infof("竞选 %s 链的事件监听领导者...", chain.Name)
+
+			// 同一条链只有持有锁的实例扫描区块，其余实例待命，领导者退出后接管
+			elector := leader.NewElector(db, listenerElection(chain.Name), log)
+			runListenerAsLeader(ctx, elector, chain.Name, func() (*listener.EventListener, error) {
+				return listener.NewEventListener(
+					chain.Name,
+					&chain,
+					int(cfg.Confirmation.Blocks),
+					&cfg.Listener,
+					syncRepo,
+					failedEventRepo,
+					rawEventRepo,
+					balanceService,
+					log,
+				)
+			}, log)
 		}(chainCfg)
 	}
 
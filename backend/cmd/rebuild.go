@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"my-token-points/config"
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/balance"
+	"my-token-points/internal/service/listener"
+)
+
+var (
+	rebuildChain string
+)
+
+// rebuildCmd 从原始事件归档重建余额
+var rebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "从原始事件归档重建余额",
+	Long: `清空指定链的 balance_changes 和 user_balances，然后按链上顺序重放 raw_events 中的归档事件，
+整个过程不访问 RPC 节点。执行前请先停止该链的事件监听服务。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runRebuild()
+	},
+}
+
+func init() {
+	rebuildCmd.Flags().StringVar(&rebuildChain, "chain", "", "要重建的链名称")
+	rebuildCmd.MarkFlagRequired("chain")
+	rootCmd.AddCommand(rebuildCmd)
+}
+
+func runRebuild() {
+	cfg, log, db := initCommand()
+	defer db.Close()
+
+	chainCfg := findChainConfig(cfg, rebuildChain)
+	if chainCfg == nil {
+		fmt.Fprintf(os.Stderr, "未找到链配置: %s\n", rebuildChain)
+		os.Exit(1)
+	}
+
+	balanceService := balance.NewBalanceService(repository.NewBalanceRepository(db), repository.NewAnomalyRepository(db), cfg.Listener.NegativeBalancePolicy, log)
+
+	replayer, err := listener.NewArchiveReplayer(
+		chainCfg.Name,
+		chainCfg,
+		&cfg.Listener,
+		repository.NewFailedEventRepository(db),
+		repository.NewRawEventRepository(db),
+		balanceService,
+		log,
+	)
+	if err != nil {
+		log.Fatalf("创建重放器失败: %v", err)
+	}
+
+	log.Infof("开始从归档重建 %s 的余额...", chainCfg.Name)
+	start := time.Now()
+
+	processed, err := replayer.Rebuild(context.Background())
+	if err != nil {
+		log.Fatalf("重建失败（已处理 %d 个事件）: %v", processed, err)
+	}
+
+	log.Infof("✅ 重建完成: 处理 %d 个事件，耗时 %s", processed, time.Since(start).Round(time.Millisecond))
+}
+
+// findChainConfig 按名称查找链配置
+func findChainConfig(cfg *config.Config, name string) *config.ChainConfig {
+	for i := range cfg.Chains {
+		if cfg.Chains[i].Name == name {
+			return &cfg.Chains[i]
+		}
+	}
+	return nil
+}
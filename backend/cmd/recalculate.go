@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/exclusion"
+	"my-token-points/internal/service/job"
+	"my-token-points/internal/service/points"
+)
+
+var (
+	recalculateChain    string
+	recalculateFrom     string
+	recalculateTo       string
+	recalculateReason   string
+	recalculateOperator string
+	recalculateLimit    int
+)
+
+// recalculateCmd 积分全量重算命令
+var recalculateCmd = &cobra.Command{
+	Use:   "recalculate",
+	Short: "全量重算积分",
+	Long: `按小时用 balance_changes 重新计算时间段内的积分，取代原有的积分历史（旧记录保留并标记为已取代），
+然后按积分历史重建用户总积分，并记录每个用户重算前后的差异。用于修复 bug 或链重组导致的错误积分。`,
+}
+
+// recalculateRunCmd 执行重算
+var recalculateRunCmd = &cobra.Command{
+	Use:     "run",
+	Short:   "重算指定时间段的积分",
+	Example: `  my-token-points recalculate run --chain sepolia --from 2024-01-01T00:00:00Z --to 2024-01-08T00:00:00Z --reason "reorg at block 123"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runRecalculate()
+	},
+}
+
+// recalculateListCmd 查询重算记录
+var recalculateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "查询重算记录",
+	Run: func(cmd *cobra.Command, args []string) {
+		runRecalculateList()
+	},
+}
+
+// recalculateDiffCmd 查询重算差异
+var recalculateDiffCmd = &cobra.Command{
+	Use:   "diff <id>",
+	Short: "查询重算前后的用户积分差异",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无效的重算 ID: %s\n", args[0])
+			os.Exit(1)
+		}
+		runRecalculateDiff(id)
+	},
+}
+
+func init() {
+	recalculateRunCmd.Flags().StringVar(&recalculateChain, "chain", "", "链名称")
+	recalculateRunCmd.Flags().StringVar(&recalculateFrom, "from", "", "开始时间 (RFC3339，按小时对齐)")
+	recalculateRunCmd.Flags().StringVar(&recalculateTo, "to", "", "结束时间 (RFC3339，按小时对齐)")
+	recalculateRunCmd.Flags().StringVar(&recalculateReason, "reason", "", "原因")
+	recalculateRunCmd.Flags().StringVar(&recalculateOperator, "operator", os.Getenv("USER"), "操作人")
+	recalculateRunCmd.MarkFlagRequired("chain")
+	recalculateRunCmd.MarkFlagRequired("from")
+	recalculateRunCmd.MarkFlagRequired("to")
+	recalculateRunCmd.MarkFlagRequired("reason")
+
+	recalculateListCmd.Flags().StringVar(&recalculateChain, "chain", "", "链名称（为空表示全部）")
+	for _, cmd := range []*cobra.Command{recalculateRunCmd, recalculateListCmd, recalculateDiffCmd} {
+		cmd.Flags().IntVar(&recalculateLimit, "limit", 50, "最多显示条数")
+	}
+
+	recalculateCmd.AddCommand(recalculateRunCmd, recalculateListCmd, recalculateDiffCmd)
+	rootCmd.AddCommand(recalculateCmd)
+}
+
+// newRecalculationCommandService 创建命令行使用的积分服务和任务服务
+func newRecalculationCommandService() (*points.PointsService, *job.JobService, func()) {
+	cfg, log, db := initCommand()
+	exclusionService := exclusion.NewExclusionService(repository.NewExclusionRepository(db), cfg.Points.ExcludedAddresses, log)
+	service := points.NewPointsService(
+		repository.NewPointsRepository(db),
+		repository.NewBalanceRepository(db),
+		exclusionService,
+		repository.NewReferralRepository(db),
+		repository.NewRecalculationRepository(db),
+		repository.NewSyncRepository(db),
+		newSybilService(cfg, db, exclusionService, log),
+		log,
+		pointsServiceConfig(cfg),
+	)
+	jobService := job.NewJobService(repository.NewJobRepository(db), service, chainNames(cfg), log)
+	return service, jobService, func() { db.Close() }
+}
+
+func runRecalculate() {
+	from, err := time.Parse(time.RFC3339, recalculateFrom)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "--from 格式错误，请使用 RFC3339")
+		os.Exit(1)
+	}
+	to, err := time.Parse(time.RFC3339, recalculateTo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "--to 格式错误，请使用 RFC3339")
+		os.Exit(1)
+	}
+
+	service, jobService, closeDB := newRecalculationCommandService()
+	defer closeDB()
+
+	recalculationJob, err := jobService.NewRecalculationJob(recalculateChain, from, to, recalculateReason, recalculateOperator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "发起重算失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 中断时任务回到待执行状态，由 API 服务续跑
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := jobService.Execute(ctx, recalculationJob); err != nil {
+		fmt.Fprintf(os.Stderr, "执行重算任务失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch recalculationJob.Status {
+	case model.JobStatusCompleted:
+	case model.JobStatusPending:
+		fmt.Printf("重算任务 #%d 已中断（已完成 %d/%d 个小时），将由 API 服务续跑\n",
+			recalculationJob.ID, recalculationJob.ProgressDone, recalculationJob.ProgressTotal)
+		return
+	default:
+		message := ""
+		if recalculationJob.ErrorMessage != nil {
+			message = *recalculationJob.ErrorMessage
+		}
+		fmt.Fprintf(os.Stderr, "重算任务 #%d %s（已完成 %d/%d 个小时）: %s\n",
+			recalculationJob.ID, recalculationJob.Status, recalculationJob.ProgressDone, recalculationJob.ProgressTotal, message)
+		os.Exit(1)
+	}
+
+	ctx = context.Background()
+	recalculation, err := service.GetRecalculation(ctx, *recalculationJob.Params.RecalculationID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "查询重算记录失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ 重算 #%d 完成：%d 个小时，%d 个用户总积分变化，时间段积分 %.4f -> %.4f\n",
+		recalculation.ID, recalculation.PeriodsDone, recalculation.UsersChanged,
+		recalculation.PointsBefore, recalculation.PointsAfter)
+	printRecalculationDiffs(ctx, service, recalculation.ID)
+}
+
+func runRecalculateList() {
+	service, _, closeDB := newRecalculationCommandService()
+	defer closeDB()
+
+	recalculations, err := service.ListRecalculations(context.Background(), recalculateChain, 0, recalculateLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "查询重算记录失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tCHAIN\tFROM\tTO\tSTATUS\tPERIODS\tCHANGED\tBEFORE\tAFTER\tOPERATOR\tREASON")
+	for _, r := range recalculations {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%.4f\t%.4f\t%s\t%s\n",
+			r.ID, r.CreatedAt.Format("2006-01-02 15:04:05"), r.ChainName,
+			r.PeriodStart.Format(time.RFC3339), r.PeriodEnd.Format(time.RFC3339), r.Status,
+			r.PeriodsDone, r.UsersChanged, r.PointsBefore, r.PointsAfter, r.Operator, r.Reason)
+	}
+	w.Flush()
+}
+
+func runRecalculateDiff(id int64) {
+	service, _, closeDB := newRecalculationCommandService()
+	defer closeDB()
+
+	printRecalculationDiffs(context.Background(), service, id)
+}
+
+// printRecalculationDiffs 输出总积分变化最大的用户
+func printRecalculationDiffs(ctx context.Context, service *points.PointsService, id int64) {
+	diffs, err := service.ListRecalculationDiffs(ctx, id, 0, recalculateLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "查询重算差异失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tPERIOD_BEFORE\tPERIOD_AFTER\tTOTAL_BEFORE\tTOTAL_AFTER\tDELTA")
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s\t%.4f\t%.4f\t%.4f\t%.4f\t%+.4f\n",
+			d.UserAddress, d.PeriodPointsBefore, d.PeriodPointsAfter,
+			d.TotalBefore, d.TotalAfter, d.TotalAfter-d.TotalBefore)
+	}
+	w.Flush()
+}
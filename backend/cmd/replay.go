@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/balance"
+	"my-token-points/internal/service/listener"
+)
+
+var (
+	replayChain      string
+	replayLogsFile   string
+	replayBlocksFile string
+	replayReset      bool
+	replayUpdateSync bool
+)
+
+// replayCmd 从导出的日志文件离线重放事件
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "从导出的日志文件离线重放事件",
+	Long: `读取 eth_getLogs 格式的 JSON-lines 日志文件和区块时间文件，按实时监听相同的流程写入数据库，
+不需要访问 RPC 节点。可用于重建数据库或确定性地复现问题。
+
+日志文件每行为一条日志（按 blockNumber、logIndex 升序），例如:
+  {"address":"0x..","topics":["0x.."],"data":"0x..","blockNumber":"0x10","transactionHash":"0x..","transactionIndex":"0x0","blockHash":"0x..","logIndex":"0x0","removed":false}
+
+区块时间文件每行为一个区块，例如:
+  {"number":"0x10","timestamp":"0x6553f100"}`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runReplay()
+	},
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&replayChain, "chain", "", "链名称")
+	replayCmd.Flags().StringVar(&replayLogsFile, "logs", "", "日志文件路径 (JSON-lines)")
+	replayCmd.Flags().StringVar(&replayBlocksFile, "blocks", "", "区块时间文件路径 (JSON-lines)")
+	replayCmd.Flags().BoolVar(&replayReset, "reset", false, "重放前清空该链的余额数据")
+	replayCmd.Flags().BoolVar(&replayUpdateSync, "update-sync", false, "重放后将同步游标推进到最后一个区块")
+	replayCmd.MarkFlagRequired("chain")
+	replayCmd.MarkFlagRequired("logs")
+	replayCmd.MarkFlagRequired("blocks")
+	rootCmd.AddCommand(replayCmd)
+}
+
+func runReplay() {
+	cfg, log, db := initCommand()
+	defer db.Close()
+
+	chainCfg := findChainConfig(cfg, replayChain)
+	if chainCfg == nil {
+		fmt.Fprintf(os.Stderr, "未找到链配置: %s\n", replayChain)
+		os.Exit(1)
+	}
+
+	balanceService := balance.NewBalanceService(repository.NewBalanceRepository(db), repository.NewAnomalyRepository(db), cfg.Listener.NegativeBalancePolicy, log)
+
+	replayer, err := listener.NewFileReplayer(
+		chainCfg.Name,
+		chainCfg,
+		&cfg.Listener,
+		repository.NewSyncRepository(db),
+		repository.NewFailedEventRepository(db),
+		repository.NewRawEventRepository(db),
+		balanceService,
+		log,
+	)
+	if err != nil {
+		log.Fatalf("创建重放器失败: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// 1. 加载区块时间
+	blocksFile, err := os.Open(replayBlocksFile)
+	if err != nil {
+		log.Fatalf("打开区块时间文件失败: %v", err)
+	}
+	blockCount, err := replayer.LoadBlockTimes(blocksFile)
+	blocksFile.Close()
+	if err != nil {
+		log.Fatalf("读取区块时间失败: %v", err)
+	}
+	log.Infof("已加载 %d 个区块时间", blockCount)
+
+	// 2. 可选：清空已有数据
+	if replayReset {
+		if err := replayer.Reset(ctx); err != nil {
+			log.Fatalf("清空 %s 余额数据失败: %v", chainCfg.Name, err)
+		}
+	}
+
+	// 3. 重放日志
+	logsFile, err := os.Open(replayLogsFile)
+	if err != nil {
+		log.Fatalf("打开日志文件失败: %v", err)
+	}
+	defer logsFile.Close()
+
+	start := time.Now()
+	result, err := replayer.Replay(ctx, logsFile)
+	if err != nil {
+		log.Fatalf("重放失败（已读取 %d 条日志，成功处理 %d 条）: %v", result.Logs, result.Processed, err)
+	}
+
+	// 4. 可选：推进同步游标
+	if replayUpdateSync && result.Logs > 0 {
+		if err := replayer.UpdateSyncState(ctx, result); err != nil {
+			log.Fatalf("更新同步状态失败: %v", err)
+		}
+		log.Infof("同步游标已推进到区块 %d", result.LastBlock)
+	}
+
+	log.Infof("✅ 重放完成: 读取 %d 条日志，成功处理 %d 条，最后区块 %d，耗时 %s",
+		result.Logs, result.Processed, result.LastBlock, time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/exclusion"
+	"my-token-points/internal/service/sybil"
+)
+
+var (
+	riskChain    string
+	riskMinScore float64
+	riskLimit    int
+)
+
+// riskCmd 女巫/刷量检测命令
+var riskCmd = &cobra.Command{
+	Use:   "risk",
+	Short: "女巫/刷量检测",
+	Long:  "检测循环转账、快进快出和同源资金集群，查看地址风险分（需要在配置中启用 points.sybil）",
+}
+
+// riskAnalyzeCmd 立即执行一次检测
+var riskAnalyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "立即执行一次女巫检测",
+	Run: func(cmd *cobra.Command, args []string) {
+		runRiskAnalyze()
+	},
+}
+
+// riskListCmd 查询风险地址
+var riskListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "查询风险地址",
+	Run: func(cmd *cobra.Command, args []string) {
+		runRiskList()
+	},
+}
+
+func init() {
+	riskCmd.PersistentFlags().StringVar(&riskChain, "chain", "", "链名称")
+	riskCmd.MarkPersistentFlagRequired("chain")
+
+	riskListCmd.Flags().Float64Var(&riskMinScore, "min-score", -1, "最低风险分（默认使用降权阈值）")
+	riskListCmd.Flags().IntVar(&riskLimit, "limit", 50, "最多显示条数")
+
+	riskCmd.AddCommand(riskAnalyzeCmd, riskListCmd)
+	rootCmd.AddCommand(riskCmd)
+}
+
+// newSybilCommandService 创建命令行使用的女巫检测服务
+func newSybilCommandService() (*sybil.SybilService, func()) {
+	cfg, log, db := initCommand()
+	if !cfg.Points.Sybil.Enabled {
+		db.Close()
+		fmt.Fprintln(os.Stderr, "未启用女巫检测（points.sybil.enabled）")
+		os.Exit(1)
+	}
+
+	exclusionService := exclusion.NewExclusionService(repository.NewExclusionRepository(db), cfg.Points.ExcludedAddresses, log)
+	return newSybilService(cfg, db, exclusionService, log), func() { db.Close() }
+}
+
+func runRiskAnalyze() {
+	service, closeDB := newSybilCommandService()
+	defer closeDB()
+
+	result, err := service.Analyze(context.Background(), riskChain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "女巫检测失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ %s 检测完成：%d 笔转账，%d 个地址命中信号，%d 个降权，%d 个排除\n",
+		result.ChainName, result.Transfers, result.Scored, result.Flagged, result.Excluded)
+}
+
+func runRiskList() {
+	service, closeDB := newSybilCommandService()
+	defer closeDB()
+
+	minScore := riskMinScore
+	if minScore < 0 {
+		minScore = service.FlagThreshold()
+	}
+
+	risks, err := service.ListRisks(context.Background(), riskChain, minScore, 0, riskLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "查询风险地址失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tSCORE\tACTION\tMULTIPLIER\tOVERRIDE\tSIGNALS")
+	for _, r := range risks {
+		signals := ""
+		for i, signal := range r.Signals {
+			if i > 0 {
+				signals += ","
+			}
+			signals += fmt.Sprintf("%s(%.2f)", signal.Type, signal.Score)
+		}
+		fmt.Fprintf(w, "%s\t%.4f\t%s\t%.2f\t%s\t%s\n",
+			r.Address, r.Score, r.Action, r.Multiplier, r.Override, signals)
+	}
+	w.Flush()
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"my-token-points/config"
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/exclusion"
+	"my-token-points/internal/service/points"
+)
+
+var (
+	simulateChain            string
+	simulateFrom             string
+	simulateTo               string
+	simulateAltEnv           string
+	simulateHourlyRate       float64
+	simulateStakedMultiplier float64
+	simulateReferralRate     float64
+	simulateNoHoldingStreak  bool
+	simulateTop              int
+	simulateJSON             bool
+)
+
+// simulateCmd 模拟积分计算
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "用替代配置模拟积分计算（不写入数据）",
+	Long: `在历史时间段上用替代的积分配置重新计算积分，输出每个用户的积分变化、分布统计和前 N 名对比。
+模拟不会写入 user_points / points_history，可以在调整积分规则前评估影响。`,
+	Example: `  my-token-points simulate --chain sepolia --from 2024-01-01T00:00:00Z --to 2024-01-08T00:00:00Z --hourly-rate 0.08
+  my-token-points simulate --chain sepolia --from 2024-01-01T00:00:00Z --to 2024-01-08T00:00:00Z --alt-env proposal`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runSimulate(cmd)
+	},
+}
+
+func init() {
+	simulateCmd.Flags().StringVar(&simulateChain, "chain", "", "链名称")
+	simulateCmd.Flags().StringVar(&simulateFrom, "from", "", "开始时间 (RFC3339)")
+	simulateCmd.Flags().StringVar(&simulateTo, "to", "", "结束时间 (RFC3339)")
+	simulateCmd.Flags().StringVar(&simulateAltEnv, "alt-env", "", "替代配置的环境名（使用其中的 points 配置）")
+	simulateCmd.Flags().Float64Var(&simulateHourlyRate, "hourly-rate", 0, "替代的每小时积分比例")
+	simulateCmd.Flags().Float64Var(&simulateStakedMultiplier, "staked-multiplier", 0, "替代的质押积分倍数")
+	simulateCmd.Flags().Float64Var(&simulateReferralRate, "referral-rate", 0, "替代的推荐奖励比例")
+	simulateCmd.Flags().BoolVar(&simulateNoHoldingStreak, "no-holding-streak", false, "关闭持有时长倍数")
+	simulateCmd.Flags().IntVar(&simulateTop, "top", 20, "对比的前 N 名人数")
+	simulateCmd.Flags().BoolVar(&simulateJSON, "json", false, "以 JSON 格式输出完整结果")
+	simulateCmd.MarkFlagRequired("chain")
+	simulateCmd.MarkFlagRequired("from")
+	simulateCmd.MarkFlagRequired("to")
+	rootCmd.AddCommand(simulateCmd)
+}
+
+func runSimulate(cmd *cobra.Command) {
+	from, err := time.Parse(time.RFC3339, simulateFrom)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "--from 格式错误，请使用 RFC3339")
+		os.Exit(1)
+	}
+	to, err := time.Parse(time.RFC3339, simulateTo)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "--to 格式错误，请使用 RFC3339")
+		os.Exit(1)
+	}
+
+	cfg, log, db := initCommand()
+	defer db.Close()
+
+	overrides := &points.SimulationOverrides{}
+	if simulateAltEnv != "" {
+		altCfg, err := config.LoadConfig(cfgFile, simulateAltEnv)
+		if err != nil {
+			log.Fatalf("加载替代配置失败: %v", err)
+		}
+		overrides.HourlyRate = &altCfg.Points.HourlyRate
+		overrides.StakedMultiplier = &altCfg.Points.StakedMultiplier
+		overrides.ReferralRate = &altCfg.Points.ReferralRate
+		overrides.HoldingStreak = holdingStreakConfig(altCfg)
+		overrides.DisableHoldingStreak = overrides.HoldingStreak == nil
+	}
+	if cmd.Flags().Changed("hourly-rate") {
+		overrides.HourlyRate = &simulateHourlyRate
+	}
+	if cmd.Flags().Changed("staked-multiplier") {
+		overrides.StakedMultiplier = &simulateStakedMultiplier
+	}
+	if cmd.Flags().Changed("referral-rate") {
+		overrides.ReferralRate = &simulateReferralRate
+	}
+	if simulateNoHoldingStreak {
+		overrides.DisableHoldingStreak = true
+	}
+
+	exclusionService := exclusion.NewExclusionService(repository.NewExclusionRepository(db), cfg.Points.ExcludedAddresses, log)
+	pointsService := points.NewPointsService(
+		repository.NewPointsRepository(db),
+		repository.NewBalanceRepository(db),
+		exclusionService,
+		repository.NewReferralRepository(db),
+		nil,
+		nil,
+		newSybilService(cfg, db, exclusionService, log),
+		log,
+		pointsServiceConfig(cfg),
+	)
+
+	report, err := pointsService.Simulate(context.Background(), simulateChain, from, to, overrides, simulateTop)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "模拟失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	if simulateJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("输出结果失败: %v", err)
+		}
+		return
+	}
+
+	printSimulationReport(report)
+}
+
+// printSimulationReport 以表格形式输出模拟结果
+func printSimulationReport(report *points.SimulationReport) {
+	fmt.Printf("%s: %s ~ %s (%d 个周期)\n\n", report.ChainName,
+		report.PeriodStart.Format(time.RFC3339), report.PeriodEnd.Format(time.RFC3339), report.Periods)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\tUSERS\tTOTAL\tMEAN\tMEDIAN\tP90\tP99\tMAX\tGINI")
+	for _, row := range []struct {
+		name string
+		d    points.PointsDistribution
+	}{{"live", report.Live}, {"simulated", report.Simulated}} {
+		fmt.Fprintf(w, "%s\t%d\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\t%.4f\n", row.name,
+			row.d.Users, row.d.Total, row.d.Mean, row.d.Median, row.d.P90, row.d.P99, row.d.Max, row.d.Gini)
+	}
+	w.Flush()
+
+	fmt.Printf("\n前 %d 名（模拟），%d 人同时在实际前 %d 名中\n", report.TopN, report.TopOverlap, report.TopN)
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tADDRESS\tSIMULATED\tLIVE\tLIVE_RANK\tDELTA")
+	for _, u := range report.TopSimulated {
+		liveRank := "-"
+		if u.LiveRank > 0 {
+			liveRank = fmt.Sprintf("%d", u.LiveRank)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%.4f\t%.4f\t%s\t%+.4f\n",
+			u.SimulatedRank, u.UserAddress, u.SimulatedPoints, u.LivePoints, liveRank, u.Delta)
+	}
+	w.Flush()
+}
@@ -14,12 +14,21 @@ import (
 	"my-token-points/config"
 	"my-token-points/internal/api"
 	"my-token-points/internal/pkg/database"
+	"my-token-points/internal/pkg/leader"
 	"my-token-points/internal/pkg/logger"
 	"my-token-points/internal/repository"
+	"my-token-points/internal/service/adjustment"
+	"my-token-points/internal/service/anomaly"
 	"my-token-points/internal/service/balance"
+	"my-token-points/internal/service/deadletter"
+	"my-token-points/internal/service/exclusion"
+	"my-token-points/internal/service/job"
 	"my-token-points/internal/service/listener"
 	"my-token-points/internal/service/points"
+	"my-token-points/internal/service/redemption"
+	"my-token-points/internal/service/referral"
 	"my-token-points/internal/service/scheduler"
+	"my-token-points/internal/service/syncstatus"
 )
 
 // startCmd 启动所有服务
@@ -60,32 +69,38 @@ func runStart() {
 
 	// 4. 创建 Repository 实例
 	syncRepo := repository.NewSyncRepository(db)
+	failedEventRepo := repository.NewFailedEventRepository(db)
+	rawEventRepo := repository.NewRawEventRepository(db)
 	balanceRepo := repository.NewBalanceRepository(db)
+	anomalyRepo := repository.NewAnomalyRepository(db)
 	pointsRepo := repository.NewPointsRepository(db)
+	exclusionRepo := repository.NewExclusionRepository(db)
+	referralRepo := repository.NewReferralRepository(db)
 
 	// 5. 创建 Service 实例
-	balanceService := balance.NewBalanceService(balanceRepo, log)
+	balanceService := balance.NewBalanceService(balanceRepo, anomalyRepo, cfg.Listener.NegativeBalancePolicy, log)
 
-	pointsConfig := &points.PointsConfig{
-		HourlyRate:     cfg.Points.HourlyRate,
-		CalcInterval:   cfg.Points.CalcInterval,
-		EnableBackfill: cfg.Points.EnableBackfill,
-	}
-	pointsService := points.NewPointsService(pointsRepo, balanceRepo, log, pointsConfig)
+	pointsConfig := pointsServiceConfig(cfg)
+	exclusionService := exclusion.NewExclusionService(exclusionRepo, cfg.Points.ExcludedAddresses, log)
+	sybilService := newSybilService(cfg, db, exclusionService, log)
+	pointsService := points.NewPointsService(pointsRepo, balanceRepo, exclusionService, referralRepo, repository.NewRecalculationRepository(db), syncRepo, sybilService, log, pointsConfig)
 
 	// 6. 创建调度器
 	schedulerConfig := &scheduler.SchedulerConfig{
 		EnableCalculation: cfg.Points.Enabled,
 		CronExpression:    cfg.Points.CronExpression,
-		Chains:            []scheduler.ChainConfig{},
+		EnableBackfill:    cfg.Points.EnableBackfill,
+		BackfillOnStartup: cfg.Points.EnableBackfill && cfg.Points.BackfillOnStartup,
+		BackfillMaxDays:   cfg.Points.BackfillMaxDays,
+		Chains:            schedulerChains(cfg),
+	}
+	if cfg.Points.ExpiryDays > 0 {
+		schedulerConfig.ExpiryCronExpression = cfg.Points.ExpiryCron
 	}
-	for _, chain := range cfg.Chains {
-		schedulerConfig.Chains = append(schedulerConfig.Chains, scheduler.ChainConfig{
-			Name:    chain.Name,
-			Enabled: true,
-		})
+	if cfg.Points.Sybil.Enabled {
+		schedulerConfig.SybilCronExpression = cfg.Points.Sybil.AnalysisCron
 	}
-	schedulerService := scheduler.NewScheduler(pointsService, schedulerConfig, log)
+	schedulerService := scheduler.NewScheduler(pointsService, sybilService, schedulerConfig, log)
 
 	// 7. 创建上下文
 	ctx, cancel := context.WithCancel(context.Background())
@@ -99,51 +114,34 @@ func runStart() {
 		wg.Add(1)
 		go func(chain config.ChainConfig) {
 			defer wg.Done()
-			log.Infof("启动 %s 链的事件监听...", chain.Name)
-
-			// 创建事件监听器
-			eventListener, err := listener.NewEventListener(
-				chain.Name,
-				&chain,
-				int(cfg.Confirmation.Blocks),
-				syncRepo,
-				balanceService,
-				log,
-			)
-			if err != nil {
-				log.Errorf("创建 %s 监听器失败: %v", chain.Name, err)
-				return
-			}
-
-			// 启动监听
-			if err := eventListener.Start(ctx); err != nil {
-				log.Errorf("启动 %s 监听器失败: %v", chain.Name, err)
-				return
-			}
-
-			<-ctx.Done()
-			eventListener.Stop()
-			log.Infof("%s 监听器已停止", chain.Name)
+			log.Infof("竞选 %s 链的事件监听领导者...", chain.Name)
+
+			// 同一条链只有持有锁的实例扫描区块，其余实例待命，领导者退出后接管
+			elector := leader.NewElector(db, listenerElection(chain.Name), log)
+			runListenerAsLeader(ctx, elector, chain.Name, func() (*listener.EventListener, error) {
+				return listener.NewEventListener(
+					chain.Name,
+					&chain,
+					int(cfg.Confirmation.Blocks),
+					&cfg.Listener,
+					syncRepo,
+					failedEventRepo,
+					rawEventRepo,
+					balanceService,
+					log,
+				)
+			}, log)
 		}(chainCfg)
 	}
 
-	// 9. 启动积分计算服务
+	// 9. 启动积分计算服务（多实例部署时只有领导者运行调度器）
+	schedulerElector := leader.NewElector(db, schedulerElection, log)
 	if cfg.Points.Enabled {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			log.Info("启动积分计算调度器...")
-
-			if err := schedulerService.Start(ctx); err != nil {
-				log.Errorf("启动调度器失败: %v", err)
-				return
-			}
-
-			<-ctx.Done()
-			if err := schedulerService.Stop(); err != nil {
-				log.Errorf("停止调度器失败: %v", err)
-			}
-			log.Info("积分计算调度器已停止")
+			log.Info("竞选积分计算调度器领导者...")
+			runSchedulerAsLeader(ctx, schedulerElector, schedulerService, log)
 		}()
 	}
 
@@ -151,12 +149,35 @@ func runStart() {
 	var apiServer *api.Server
 	if cfg.API.Enabled {
 		// 创建API服务器
+		jobService := job.NewJobService(repository.NewJobRepository(db), pointsService, chainNames(cfg), log)
 		serverConfig := &api.ServerConfig{
 			Host: cfg.API.Host,
 			Port: cfg.API.Port,
 			Mode: cfg.API.Mode,
 		}
-		apiServer = api.NewServer(serverConfig, balanceService, pointsService, schedulerService, log)
+		services := &api.Services{
+			Balance:    balanceService,
+			Points:     pointsService,
+			Scheduler:  schedulerService,
+			SyncStatus: syncstatus.NewSyncStatusService(syncRepo, log),
+			DeadLetter: deadletter.NewDeadLetterService(failedEventRepo, log),
+			Anomaly:    anomaly.NewAnomalyService(anomalyRepo, log),
+			Exclusion:  exclusionService,
+			Referral:   referral.NewReferralService(referralRepo, log),
+			Adjustment: adjustment.NewAdjustmentService(repository.NewAdjustmentRepository(db), chainNames(cfg), log),
+			Redemption: redemption.NewRedemptionService(repository.NewRedemptionRepository(db), chainNames(cfg), log),
+			Sybil:      sybilService,
+			Jobs:       jobService,
+			Leader:     schedulerElector,
+		}
+		apiServer = api.NewServer(serverConfig, services, log)
+
+		// 启动回溯/重算任务执行进程（任务通过管理接口提交）
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jobService.Run(ctx)
+		}()
 
 		// 在单独的 goroutine 中启动服务器
 		wg.Add(1)
@@ -197,7 +218,7 @@ func runStart() {
 		log.Infof("📚 健康检查: http://%s:%d/health", cfg.API.Host, cfg.API.Port)
 	}
 	if cfg.Points.Enabled {
-		log.Info("⏰ 积分计算调度器已启动")
+		log.Info("⏰ 积分计算调度器已启动（当选领导者后执行定时计算）")
 	}
 
 	// 11. 等待中断信号
@@ -15,6 +15,7 @@ type Config struct {
 	API          APIConfig          `mapstructure:"api"`
 	Chains       []ChainConfig      `mapstructure:"chains"`
 	Confirmation ConfirmationConfig `mapstructure:"confirmation"`
+	Listener     ListenerConfig     `mapstructure:"listener"`
 	Points       PointsConfig       `mapstructure:"points"`
 }
 
@@ -64,13 +65,86 @@ type ChainConfig struct {
 	BatchSize       uint64 `mapstructure:"batch_size"`
 	ExplorerURL     string `mapstructure:"explorer_url"`      // 区块浏览器 URL
 	ExplorerAPIURL  string `mapstructure:"explorer_api_url"`  // 区块浏览器 API URL
+
+	// 需要索引的合约，未配置时使用 contract_address 和内置的 MyToken 事件映射
+	Contracts []ContractConfig `mapstructure:"contracts"`
+
+	// 该链的积分计算配置，未配置的项使用 points 中的全局配置
+	Points ChainPointsConfig `mapstructure:"points"`
+}
+
+// ChainPointsConfig 链的积分计算配置
+type ChainPointsConfig struct {
+	Enabled        *bool         `mapstructure:"enabled"`         // 是否计算该链的积分（默认 true）
+	CronExpression string        `mapstructure:"cron_expression"` // 定时计算的 Cron 表达式
+	CalcInterval   time.Duration `mapstructure:"calc_interval"`   // 计算周期长度（如 10m、24h），需能整除一天
+}
+
+// CalculationEnabled 是否计算该链的积分
+func (c ChainPointsConfig) CalculationEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// ContractConfig 被索引的合约配置
+type ContractConfig struct {
+	Name    string               `mapstructure:"name"`
+	Address string               `mapstructure:"address"`
+	ABIPath string               `mapstructure:"abi_path"` // ABI JSON 文件路径，为空时使用内置的 MyToken ABI
+	Custody bool                 `mapstructure:"custody"`  // 托管合约（质押/金库），合约自身的钱包余额不计积分
+	Events  []EventMappingConfig `mapstructure:"events"`
+}
+
+// EventMappingConfig 事件到余额变动的映射
+type EventMappingConfig struct {
+	Event             string                `mapstructure:"event"`               // ABI 中的事件名称
+	IgnoreZeroAddress []string              `mapstructure:"ignore_zero_address"` // 这些地址参数为零地址时忽略整个事件
+	Effects           []BalanceEffectConfig `mapstructure:"effects"`
 }
 
+// BalanceEffectConfig 单个余额变动
+type BalanceEffectConfig struct {
+	Direction string `mapstructure:"direction"`  // credit(增加) 或 debit(减少)
+	Account   string `mapstructure:"account"`    // 用户地址所在的事件参数名
+	Amount    string `mapstructure:"amount"`     // 金额所在的事件参数名
+	EventType string `mapstructure:"event_type"` // 记录到 balance_changes 的事件类型
+	Balance   string `mapstructure:"balance"`    // wallet(默认) 或 staked
+}
+
+// 余额变动方向
+const (
+	DirectionCredit = "credit"
+	DirectionDebit  = "debit"
+)
+
+// 余额类型
+const (
+	BalanceWallet = "wallet"
+	BalanceStaked = "staked"
+)
+
 // ConfirmationConfig 确认机制配置
 type ConfirmationConfig struct {
 	Blocks uint64 `mapstructure:"blocks"`
 }
 
+// ListenerConfig 事件监听配置
+type ListenerConfig struct {
+	ErrorThreshold int `mapstructure:"error_threshold"` // 连续失败多少次后标记为 error
+	MaxBackoff     int `mapstructure:"max_backoff"`     // 失败重试的最大退避时间（秒）
+
+	RetryInterval    int `mapstructure:"retry_interval"`     // 死信重试间隔（秒）
+	MaxRetryAttempts int `mapstructure:"max_retry_attempts"` // 死信最多尝试次数
+
+	NegativeBalancePolicy string `mapstructure:"negative_balance_policy"` // 事件使余额为负时的处理策略
+}
+
+// 余额为负时的处理策略（每次发生都记录到余额异常表）
+const (
+	NegativeBalanceClamp     = "clamp"     // 记为 0（默认）
+	NegativeBalanceReject    = "reject"    // 拒绝该事件，写入死信，修复后重试
+	NegativeBalanceReconcile = "reconcile" // 按链上 balanceOf 校正余额
+)
+
 // PointsConfig 积分计算配置
 type PointsConfig struct {
 	Enabled           bool          `mapstructure:"enabled"`
@@ -80,6 +154,55 @@ type PointsConfig struct {
 	EnableBackfill    bool          `mapstructure:"enable_backfill"`    // 启用回溯计算
 	BackfillOnStartup bool          `mapstructure:"backfill_on_startup"` // 启动时自动回溯
 	BackfillMaxDays   int           `mapstructure:"backfill_max_days"`   // 最多回溯天数
+	StakedMultiplier  float64       `mapstructure:"staked_multiplier"`   // 质押余额的积分倍数
+	ReferralRate      float64       `mapstructure:"referral_rate"`       // 推荐人获得被推荐人积分的比例（0 表示关闭）
+	ExpiryDays        int           `mapstructure:"expiry_days"`         // 积分有效天数（0 表示不过期）
+	ExpiringSoonDays  int           `mapstructure:"expiring_soon_days"`  // 即将过期积分的统计天数
+	ExpiryCron        string        `mapstructure:"expiry_cron"`         // 过期任务的 Cron 表达式
+	ReportingTimezone string        `mapstructure:"reporting_timezone"`  // 按日/周/月汇总积分使用的时区（计算周期始终按 UTC 对齐）
+
+	// 不参与积分计算和排行榜的地址（也可以通过管理接口维护）
+	ExcludedAddresses []ExcludedAddressConfig `mapstructure:"excluded_addresses"`
+
+	// 持有时长倍数（长期持有者获得更高积分）
+	HoldingStreak HoldingStreakConfig `mapstructure:"holding_streak"`
+
+	// 女巫/刷量检测（被标记的地址降权或排除）
+	Sybil SybilConfig `mapstructure:"sybil"`
+}
+
+// ExcludedAddressConfig 积分排除地址配置
+type ExcludedAddressConfig struct {
+	Chain   string `mapstructure:"chain"` // 为空表示所有链
+	Address string `mapstructure:"address"`
+	Reason  string `mapstructure:"reason"`
+}
+
+// HoldingStreakConfig 持有时长倍数配置
+type HoldingStreakConfig struct {
+	Enabled bool               `mapstructure:"enabled"`
+	Mode    string             `mapstructure:"mode"` // reset: 转出后重新计时; prorate: 按剩余比例折算持有时长
+	Tiers   []StreakTierConfig `mapstructure:"tiers"`
+}
+
+// StreakTierConfig 持有时长倍数档位
+type StreakTierConfig struct {
+	MinDays    float64 `mapstructure:"min_days"`   // 连续持有天数
+	Multiplier float64 `mapstructure:"multiplier"` // 积分倍数
+}
+
+// SybilConfig 女巫/刷量检测配置
+type SybilConfig struct {
+	Enabled            bool    `mapstructure:"enabled"`
+	AnalysisCron       string  `mapstructure:"analysis_cron"`         // 检测任务的 Cron 表达式
+	LookbackHours      int     `mapstructure:"lookback_hours"`        // 检测窗口（小时）
+	MaxCycleLength     int     `mapstructure:"max_cycle_length"`      // 循环转账的最大地址数
+	RapidWindowMinutes int     `mapstructure:"rapid_window_minutes"`  // 快进快出的时间窗口（分钟）
+	RapidMinRoundTrips int     `mapstructure:"rapid_min_round_trips"` // 快进快出达到多少次才计入
+	MinClusterSize     int     `mapstructure:"min_cluster_size"`      // 同源资金集群的最少地址数
+	FlagThreshold      float64 `mapstructure:"flag_threshold"`        // 风险分达到该值时降权
+	ExcludeThreshold   float64 `mapstructure:"exclude_threshold"`     // 风险分达到该值时排除
+	FlaggedMultiplier  float64 `mapstructure:"flagged_multiplier"`    // 降权地址的积分倍数
 }
 
 // LoadConfig 加载配置文件
@@ -142,8 +265,11 @@ func validateConfig(config *Config) error {
 		if chain.RPCURL == "" {
 			return fmt.Errorf("rpc_url is required for chain %s", chain.Name)
 		}
-		if chain.ContractAddress == "" {
-			return fmt.Errorf("contract_address is required for chain %s", chain.Name)
+		if chain.ContractAddress == "" && len(chain.Contracts) == 0 {
+			return fmt.Errorf("contract_address or contracts is required for chain %s", chain.Name)
+		}
+		if err := validateContracts(chain.Name, chain.Contracts); err != nil {
+			return err
 		}
 		if chain.ChainID == 0 {
 			return fmt.Errorf("chain_id is required for chain %s", chain.Name)
@@ -155,6 +281,27 @@ func validateConfig(config *Config) error {
 		config.Confirmation.Blocks = 6 // 默认值
 	}
 
+	// 验证监听配置
+	if config.Listener.ErrorThreshold <= 0 {
+		config.Listener.ErrorThreshold = 5 // 默认连续失败5次
+	}
+	if config.Listener.MaxBackoff <= 0 {
+		config.Listener.MaxBackoff = 300 // 默认最多退避5分钟
+	}
+	if config.Listener.RetryInterval <= 0 {
+		config.Listener.RetryInterval = 60 // 默认每分钟重试一次死信
+	}
+	if config.Listener.MaxRetryAttempts <= 0 {
+		config.Listener.MaxRetryAttempts = 10 // 默认最多尝试10次
+	}
+	switch config.Listener.NegativeBalancePolicy {
+	case "":
+		config.Listener.NegativeBalancePolicy = NegativeBalanceClamp
+	case NegativeBalanceClamp, NegativeBalanceReject, NegativeBalanceReconcile:
+	default:
+		return fmt.Errorf("invalid negative_balance_policy %q, must be clamp, reject or reconcile", config.Listener.NegativeBalancePolicy)
+	}
+
 	// 验证积分配置
 	if config.Points.Enabled {
 		if config.Points.CronExpression == "" {
@@ -166,6 +313,61 @@ func validateConfig(config *Config) error {
 		if config.Points.CalcInterval == 0 {
 			config.Points.CalcInterval = time.Hour // 默认1小时
 		}
+		if err := validateCalcInterval("points", config.Points.CalcInterval); err != nil {
+			return err
+		}
+		if config.Points.StakedMultiplier == 0 {
+			config.Points.StakedMultiplier = 1 // 默认质押余额与钱包余额等同
+		}
+	}
+
+	if config.Points.ExpiryDays < 0 || config.Points.ExpiringSoonDays < 0 {
+		return fmt.Errorf("expiry_days and expiring_soon_days must not be negative")
+	}
+	if config.Points.ExpiringSoonDays == 0 {
+		config.Points.ExpiringSoonDays = 7
+	}
+	if config.Points.ExpiryCron == "" {
+		config.Points.ExpiryCron = "0 30 0 * * *" // 默认每天 00:30
+	}
+
+	if config.Points.ReportingTimezone == "" {
+		config.Points.ReportingTimezone = "UTC"
+	}
+	if _, err := time.LoadLocation(config.Points.ReportingTimezone); err != nil {
+		return fmt.Errorf("invalid reporting_timezone %q: %w", config.Points.ReportingTimezone, err)
+	}
+
+	if config.Points.ReferralRate < 0 || config.Points.ReferralRate > 1 {
+		return fmt.Errorf("referral_rate must be between 0 and 1, got %v", config.Points.ReferralRate)
+	}
+
+	// 链未单独配置的积分计算项使用全局配置
+	for i := range config.Chains {
+		chainPoints := &config.Chains[i].Points
+		if chainPoints.CronExpression == "" {
+			chainPoints.CronExpression = config.Points.CronExpression
+		}
+		if chainPoints.CalcInterval == 0 {
+			chainPoints.CalcInterval = config.Points.CalcInterval
+		}
+		if err := validateCalcInterval("chain "+config.Chains[i].Name, chainPoints.CalcInterval); err != nil {
+			return err
+		}
+	}
+
+	if err := validateHoldingStreak(&config.Points.HoldingStreak); err != nil {
+		return err
+	}
+
+	if err := validateSybil(&config.Points.Sybil); err != nil {
+		return err
+	}
+
+	for _, excluded := range config.Points.ExcludedAddresses {
+		if excluded.Address == "" {
+			return fmt.Errorf("address is required for excluded address (reason: %s)", excluded.Reason)
+		}
 	}
 
 	// 设置API默认模式
@@ -180,9 +382,140 @@ func validateConfig(config *Config) error {
 	return nil
 }
 
-// GetDSN 获取数据库连接字符串
+// validateCalcInterval 验证计算周期：周期按整点对齐，因此需要在 1 分钟到 1 天之间并能整除一天
+func validateCalcInterval(scope string, interval time.Duration) error {
+	if interval == 0 {
+		return nil
+	}
+	if interval < time.Minute || interval > 24*time.Hour || (24*time.Hour)%interval != 0 {
+		return fmt.Errorf("invalid calc_interval %s for %s, must divide 24h evenly and be at least 1m", interval, scope)
+	}
+	return nil
+}
+
+// validateHoldingStreak 验证持有时长倍数配置（档位需按天数严格递增）
+func validateHoldingStreak(streak *HoldingStreakConfig) error {
+	if !streak.Enabled {
+		return nil
+	}
+	if streak.Mode == "" {
+		streak.Mode = "reset"
+	}
+	if streak.Mode != "reset" && streak.Mode != "prorate" {
+		return fmt.Errorf("invalid holding_streak mode %q (expected reset or prorate)", streak.Mode)
+	}
+	if len(streak.Tiers) == 0 {
+		return fmt.Errorf("holding_streak requires at least one tier")
+	}
+
+	for i, tier := range streak.Tiers {
+		if tier.MinDays <= 0 || tier.Multiplier <= 0 {
+			return fmt.Errorf("holding_streak tier %d: min_days and multiplier must be positive", i)
+		}
+		if i > 0 && tier.MinDays <= streak.Tiers[i-1].MinDays {
+			return fmt.Errorf("holding_streak tiers must be sorted by min_days ascending")
+		}
+	}
+	return nil
+}
+
+// validateSybil 验证女巫检测配置并设置默认值
+func validateSybil(sybil *SybilConfig) error {
+	if !sybil.Enabled {
+		return nil
+	}
+	if sybil.AnalysisCron == "" {
+		sybil.AnalysisCron = "0 50 * * * *" // 默认每小时第 50 分钟，早于整点的积分计算
+	}
+	if sybil.LookbackHours == 0 {
+		sybil.LookbackHours = 7 * 24
+	}
+	if sybil.MaxCycleLength == 0 {
+		sybil.MaxCycleLength = 4
+	}
+	if sybil.RapidWindowMinutes == 0 {
+		sybil.RapidWindowMinutes = 60
+	}
+	if sybil.RapidMinRoundTrips == 0 {
+		sybil.RapidMinRoundTrips = 3
+	}
+	if sybil.MinClusterSize == 0 {
+		sybil.MinClusterSize = 5
+	}
+	if sybil.FlagThreshold == 0 {
+		sybil.FlagThreshold = 0.5
+	}
+	if sybil.ExcludeThreshold == 0 {
+		sybil.ExcludeThreshold = 0.9
+	}
+	if sybil.FlaggedMultiplier == 0 {
+		sybil.FlaggedMultiplier = 0.5
+	}
+
+	if sybil.LookbackHours < 0 || sybil.RapidWindowMinutes < 0 || sybil.RapidMinRoundTrips < 0 || sybil.MinClusterSize < 0 {
+		return fmt.Errorf("sybil lookback, window, round trips and cluster size must be positive")
+	}
+	if sybil.MaxCycleLength < 2 {
+		return fmt.Errorf("sybil max_cycle_length must be at least 2, got %d", sybil.MaxCycleLength)
+	}
+	if sybil.FlagThreshold < 0 || sybil.FlagThreshold > sybil.ExcludeThreshold || sybil.ExcludeThreshold > 1 {
+		return fmt.Errorf("sybil thresholds must satisfy 0 <= flag_threshold <= exclude_threshold <= 1")
+	}
+	if sybil.FlaggedMultiplier < 0 || sybil.FlaggedMultiplier > 1 {
+		return fmt.Errorf("sybil flagged_multiplier must be between 0 and 1, got %v", sybil.FlaggedMultiplier)
+	}
+	return nil
+}
+
+// validateContracts 验证合约事件映射配置（ABI 中是否存在对应事件和参数在加载 ABI 时检查）
+func validateContracts(chainName string, contracts []ContractConfig) error {
+	for _, contract := range contracts {
+		if contract.Address == "" {
+			return fmt.Errorf("address is required for contract %s on chain %s", contract.Name, chainName)
+		}
+		if len(contract.Events) == 0 {
+			return fmt.Errorf("at least one event mapping is required for contract %s on chain %s", contract.Name, chainName)
+		}
+
+		for _, event := range contract.Events {
+			if event.Event == "" {
+				return fmt.Errorf("event name is required for contract %s on chain %s", contract.Name, chainName)
+			}
+			if len(event.Effects) == 0 {
+				return fmt.Errorf("at least one effect is required for event %s of contract %s", event.Event, contract.Name)
+			}
+
+			for _, effect := range event.Effects {
+				if effect.Direction != DirectionCredit && effect.Direction != DirectionDebit {
+					return fmt.Errorf("invalid direction %q for event %s of contract %s", effect.Direction, event.Event, contract.Name)
+				}
+				if effect.Account == "" || effect.Amount == "" || effect.EventType == "" {
+					return fmt.Errorf("account, amount and event_type are required for event %s of contract %s", event.Event, contract.Name)
+				}
+				if effect.Balance != "" && effect.Balance != BalanceWallet && effect.Balance != BalanceStaked {
+					return fmt.Errorf("invalid balance %q for event %s of contract %s", effect.Balance, event.Event, contract.Name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// CustodyAddresses 返回链上所有托管合约的地址
+func (c *ChainConfig) CustodyAddresses() []string {
+	var addresses []string
+	for _, contract := range c.Contracts {
+		if contract.Custody {
+			addresses = append(addresses, contract.Address)
+		}
+	}
+	return addresses
+}
+
+// GetDSN 获取数据库连接字符串，会话时区固定为 UTC
 func (c *DatabaseConfig) GetDSN() string {
-	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s timezone=UTC",
 		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode)
 }
 
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"my-token-points/internal/service/adjustment"
+)
+
+// AdjustPointsRequest 手动调整积分请求
+type AdjustPointsRequest struct {
+	Chain   string  `json:"chain" binding:"required"`
+	Address string  `json:"address" binding:"required"`
+	Amount  float64 `json:"amount" binding:"required"` // 正数为发放，负数为扣回
+	Reason  string  `json:"reason" binding:"required"`
+}
+
+// RevertAdjustmentRequest 撤销调整请求
+type RevertAdjustmentRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// AdjustPointsHandler 手动发放或扣回积分
+// POST /api/v1/admin/points/adjust  (Header: X-Operator)
+func (h *Handlers) AdjustPointsHandler(c *gin.Context) {
+	var req AdjustPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	adjusted, err := h.adjustmentService.Adjust(
+		c.Request.Context(), req.Chain, req.Address, req.Amount, req.Reason, getOperator(c),
+	)
+	if err != nil {
+		respondAdjustmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Data:    adjusted,
+	})
+}
+
+// ListAdjustmentsHandler 查询积分调整记录
+// GET /api/v1/admin/points/adjustments?chain=sepolia&address=0x...&offset=0&limit=100
+func (h *Handlers) ListAdjustmentsHandler(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	adjustments, err := h.adjustmentService.ListAdjustments(
+		c.Request.Context(), c.Query("chain"), c.Query("address"), offset, limit,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    adjustments,
+	})
+}
+
+// GetAdjustmentHandler 查询单条积分调整
+// GET /api/v1/admin/points/adjustments/:id
+func (h *Handlers) GetAdjustmentHandler(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	adjusted, err := h.adjustmentService.GetAdjustment(c.Request.Context(), id)
+	if err != nil {
+		respondAdjustmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    adjusted,
+	})
+}
+
+// RevertAdjustmentHandler 撤销积分调整
+// POST /api/v1/admin/points/adjustments/:id/revert  (Header: X-Operator)
+func (h *Handlers) RevertAdjustmentHandler(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req RevertAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	reverted, err := h.adjustmentService.Revert(c.Request.Context(), id, req.Reason, getOperator(c))
+	if err != nil {
+		respondAdjustmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    reverted,
+	})
+}
+
+// respondAdjustmentError 根据错误类型返回对应的状态码
+func respondAdjustmentError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, adjustment.ErrInvalidAddress), errors.Is(err, adjustment.ErrUnknownChain),
+		errors.Is(err, adjustment.ErrInvalidAmount), errors.Is(err, adjustment.ErrReasonRequired),
+		errors.Is(err, adjustment.ErrOperatorRequired):
+		status = http.StatusBadRequest
+	case errors.Is(err, adjustment.ErrAdjustmentNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, adjustment.ErrAlreadyReverted):
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, Response{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
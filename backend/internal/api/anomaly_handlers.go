@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"my-token-points/internal/service/anomaly"
+)
+
+// ResolveAnomalyRequest 核查余额异常请求
+type ResolveAnomalyRequest struct {
+	Note string `json:"note" binding:"required"` // 核查结论，例如已补处理漏掉的事件
+}
+
+// ListAnomaliesHandler 查询余额异常（余额变为负数的事件）
+// GET /api/v1/admin/anomalies?chain=sepolia&status=open&offset=0&limit=100
+func (h *Handlers) ListAnomaliesHandler(c *gin.Context) {
+	offset, limit := parsePagination(c)
+	anomalies, err := h.anomalyService.ListAnomalies(c.Request.Context(), c.Query("chain"), c.Query("status"), offset, limit)
+	if err != nil {
+		respondAnomalyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    anomalies,
+	})
+}
+
+// GetAnomalyHandler 查询单个余额异常
+// GET /api/v1/admin/anomalies/:id
+func (h *Handlers) GetAnomalyHandler(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.anomalyService.GetAnomaly(c.Request.Context(), id)
+	if err != nil {
+		respondAnomalyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// ResolveAnomalyHandler 标记余额异常为已核查
+// POST /api/v1/admin/anomalies/:id/resolve  (Header: X-Operator)
+func (h *Handlers) ResolveAnomalyHandler(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ResolveAnomalyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := h.anomalyService.ResolveAnomaly(c.Request.Context(), id, req.Note, getOperator(c))
+	if err != nil {
+		respondAnomalyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// respondAnomalyError 根据错误类型返回对应的状态码
+func respondAnomalyError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, anomaly.ErrOperatorRequired):
+		status = http.StatusBadRequest
+	case errors.Is(err, anomaly.ErrAnomalyNotFound):
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, Response{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"my-token-points/internal/service/deadletter"
+)
+
+// ListFailedEventsHandler 查询死信列表
+// GET /api/v1/admin/failed-events?chain=sepolia&status=pending&offset=0&limit=100
+func (h *Handlers) ListFailedEventsHandler(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	events, err := h.deadLetterService.ListFailedEvents(
+		c.Request.Context(), c.Query("chain"), c.Query("status"), offset, limit,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    events,
+	})
+}
+
+// GetFailedEventHandler 查询单个死信
+// GET /api/v1/admin/failed-events/:id
+func (h *Handlers) GetFailedEventHandler(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	event, err := h.deadLetterService.GetFailedEvent(c.Request.Context(), id)
+	if err != nil {
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    event,
+	})
+}
+
+// RetryFailedEventHandler 将死信放回重试队列
+// POST /api/v1/admin/failed-events/:id/retry
+func (h *Handlers) RetryFailedEventHandler(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deadLetterService.RetryFailedEvent(c.Request.Context(), id); err != nil {
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    gin.H{"message": "failed event requeued for retry"},
+	})
+}
+
+// DiscardFailedEventHandler 丢弃死信
+// POST /api/v1/admin/failed-events/:id/discard
+func (h *Handlers) DiscardFailedEventHandler(c *gin.Context) {
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deadLetterService.DiscardFailedEvent(c.Request.Context(), id); err != nil {
+		respondDeadLetterError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    gin.H{"message": "failed event discarded"},
+	})
+}
+
+// respondDeadLetterError 输出死信操作错误
+func respondDeadLetterError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, deadletter.ErrFailedEventNotFound) {
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, Response{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
+
+// parseIDParam 解析路径中的 :id 参数，失败时直接返回 400
+func parseIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "invalid id",
+		})
+		return 0, false
+	}
+	return id, true
+}
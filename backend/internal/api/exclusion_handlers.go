@@ -0,0 +1,126 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"my-token-points/internal/service/exclusion"
+)
+
+// ExclusionRequest 添加排除地址请求
+type ExclusionRequest struct {
+	Chain   string `json:"chain"` // 为空表示所有链
+	Address string `json:"address" binding:"required"`
+	Reason  string `json:"reason" binding:"required"`
+}
+
+// ListExclusionsHandler 查询生效的排除地址
+// GET /api/v1/admin/exclusions?chain=sepolia
+func (h *Handlers) ListExclusionsHandler(c *gin.Context) {
+	addresses, err := h.exclusionService.ListExcludedAddresses(c.Request.Context(), c.Query("chain"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    addresses,
+	})
+}
+
+// AddExclusionHandler 添加排除地址
+// POST /api/v1/admin/exclusions  (Header: X-Operator)
+func (h *Handlers) AddExclusionHandler(c *gin.Context) {
+	var req ExclusionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	excluded, err := h.exclusionService.AddExcludedAddress(
+		c.Request.Context(), req.Chain, req.Address, req.Reason, getOperator(c),
+	)
+	if err != nil {
+		respondExclusionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Data:    excluded,
+	})
+}
+
+// RemoveExclusionHandler 移除排除地址
+// DELETE /api/v1/admin/exclusions/:address?chain=sepolia&reason=xxx  (Header: X-Operator)
+func (h *Handlers) RemoveExclusionHandler(c *gin.Context) {
+	err := h.exclusionService.RemoveExcludedAddress(
+		c.Request.Context(), c.Query("chain"), c.Param("address"), c.Query("reason"), getOperator(c),
+	)
+	if err != nil {
+		respondExclusionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    gin.H{"message": "address removed from exclusion list"},
+	})
+}
+
+// ListExclusionAuditHandler 查询排除地址审计记录
+// GET /api/v1/admin/exclusions/audit?chain=sepolia&address=0x...&offset=0&limit=100
+func (h *Handlers) ListExclusionAuditHandler(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	audits, err := h.exclusionService.ListAudit(
+		c.Request.Context(), c.Query("chain"), c.Query("address"), offset, limit,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    audits,
+	})
+}
+
+// respondExclusionError 根据错误类型返回对应的状态码
+func respondExclusionError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, exclusion.ErrInvalidAddress), errors.Is(err, exclusion.ErrOperatorRequired):
+		status = http.StatusBadRequest
+	case errors.Is(err, exclusion.ErrAlreadyExcluded), errors.Is(err, exclusion.ErrStaticExclusion):
+		status = http.StatusConflict
+	case errors.Is(err, exclusion.ErrNotExcluded):
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, Response{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
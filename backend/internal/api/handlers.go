@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -8,28 +9,54 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"my-token-points/internal/pkg/leader"
+	"my-token-points/internal/service/adjustment"
+	"my-token-points/internal/service/anomaly"
 	"my-token-points/internal/service/balance"
+	"my-token-points/internal/service/deadletter"
+	"my-token-points/internal/service/exclusion"
+	"my-token-points/internal/service/job"
 	"my-token-points/internal/service/points"
+	"my-token-points/internal/service/redemption"
+	"my-token-points/internal/service/referral"
 	"my-token-points/internal/service/scheduler"
+	"my-token-points/internal/service/sybil"
+	"my-token-points/internal/service/syncstatus"
 )
 
 // Handlers API处理器
 type Handlers struct {
-	balanceService *balance.BalanceService
-	pointsService  *points.PointsService
-	scheduler      *scheduler.Scheduler
+	balanceService    *balance.BalanceService
+	pointsService     *points.PointsService
+	scheduler         *scheduler.Scheduler
+	syncStatusService *syncstatus.SyncStatusService
+	deadLetterService *deadletter.DeadLetterService
+	anomalyService    *anomaly.AnomalyService
+	exclusionService  *exclusion.ExclusionService
+	referralService   *referral.ReferralService
+	adjustmentService *adjustment.AdjustmentService
+	redemptionService *redemption.RedemptionService
+	sybilService      *sybil.SybilService
+	jobService        *job.JobService
+	leaderElector     *leader.Elector
 }
 
 // NewHandlers 创建API处理器
-func NewHandlers(
-	balanceService *balance.BalanceService,
-	pointsService *points.PointsService,
-	scheduler *scheduler.Scheduler,
-) *Handlers {
+func NewHandlers(services *Services) *Handlers {
 	return &Handlers{
-		balanceService: balanceService,
-		pointsService:  pointsService,
-		scheduler:      scheduler,
+		balanceService:    services.Balance,
+		pointsService:     services.Points,
+		scheduler:         services.Scheduler,
+		syncStatusService: services.SyncStatus,
+		deadLetterService: services.DeadLetter,
+		anomalyService:    services.Anomaly,
+		exclusionService:  services.Exclusion,
+		referralService:   services.Referral,
+		adjustmentService: services.Adjustment,
+		redemptionService: services.Redemption,
+		sybilService:      services.Sybil,
+		jobService:        services.Jobs,
+		leaderElector:     services.Leader,
 	}
 }
 
@@ -38,17 +65,118 @@ type Response struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
+	// 余额/积分数据所反映的区块高度
+	AsOfBlock *int64 `json:"as_of_block,omitempty"`
 }
 
 // HealthCheckHandler 健康检查
 func (h *Handlers) HealthCheckHandler(c *gin.Context) {
+	states, err := h.syncStatusService.ListChainStatuses(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	status := "healthy"
+	if !h.syncStatusService.IsHealthy(states) {
+		status = "degraded"
+	}
+
+	chains := make(gin.H, len(states))
+	for _, state := range states {
+		chains[state.ChainName] = gin.H{
+			"status":               state.Status,
+			"last_synced_block":    state.LastSyncedBlock,
+			"head_lag_blocks":      state.HeadLagBlocks,
+			"lag_seconds":          state.LagSeconds,
+			"consecutive_failures": state.ConsecutiveFailures,
+			"last_success_at":      state.LastSuccessAt,
+			"error_message":        state.ErrorMessage,
+		}
+	}
+
+	data := gin.H{
+		"status":    status,
+		"timestamp": time.Now().Unix(),
+		"scheduler": h.scheduler.IsRunning(),
+		"chains":    chains,
+	}
+
+	// 调度器领导者（多实例部署时只有领导者执行定时计算）
+	if h.leaderElector != nil {
+		lease, err := h.leaderElector.Leader(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, Response{
+				Success: false,
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		leaderInfo := gin.H{
+			"instance":  h.leaderElector.Identity(),
+			"is_leader": h.leaderElector.IsLeader(),
+		}
+		if lease != nil {
+			leaderInfo["holder"] = lease.Holder
+			leaderInfo["acquired_at"] = lease.AcquiredAt
+			leaderInfo["renewed_at"] = lease.RenewedAt
+		}
+		data["leader"] = leaderInfo
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// ListSyncStatusHandler 查询所有链的同步状态
+// GET /api/v1/sync
+func (h *Handlers) ListSyncStatusHandler(c *gin.Context) {
+	statuses, err := h.syncStatusService.ListChainStatuses(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, Response{
 		Success: true,
-		Data: gin.H{
-			"status":    "healthy",
-			"timestamp": time.Now().Unix(),
-			"scheduler": h.scheduler.IsRunning(),
-		},
+		Data:    statuses,
+	})
+}
+
+// GetSyncStatusHandler 查询链的同步状态
+// GET /api/v1/sync/:chain
+func (h *Handlers) GetSyncStatusHandler(c *gin.Context) {
+	chainName := c.Param("chain")
+
+	state, err := h.syncStatusService.GetChainStatus(c.Request.Context(), chainName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if state == nil {
+		c.JSON(http.StatusNotFound, Response{
+			Success: false,
+			Error:   "sync state not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    state,
 	})
 }
 
@@ -83,9 +211,19 @@ func (h *Handlers) GetBalanceHandler(c *gin.Context) {
 		return
 	}
 
+	asOfBlock, err := h.syncStatusService.GetAsOfBlock(c.Request.Context(), chainName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, Response{
-		Success: true,
-		Data:    balance,
+		Success:   true,
+		Data:      balance,
+		AsOfBlock: asOfBlock,
 	})
 }
 
@@ -99,7 +237,7 @@ func (h *Handlers) GetBalanceChangesHandler(c *gin.Context) {
 	endTimeStr := c.Query("end_time")
 
 	// 默认查询最近24小时
-	endTime := time.Now()
+	endTime := time.Now().UTC()
 	startTime := endTime.Add(-24 * time.Hour)
 
 	if startTimeStr != "" {
@@ -123,9 +261,19 @@ func (h *Handlers) GetBalanceChangesHandler(c *gin.Context) {
 		return
 	}
 
+	asOfBlock, err := h.syncStatusService.GetAsOfBlock(c.Request.Context(), chainName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, Response{
-		Success: true,
-		Data:    changes,
+		Success:   true,
+		Data:      changes,
+		AsOfBlock: asOfBlock,
 	})
 }
 
@@ -160,9 +308,19 @@ func (h *Handlers) GetPointsHandler(c *gin.Context) {
 		return
 	}
 
+	asOfBlock, err := h.syncStatusService.GetAsOfBlock(c.Request.Context(), chainName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, Response{
-		Success: true,
-		Data:    points,
+		Success:   true,
+		Data:      points,
+		AsOfBlock: asOfBlock,
 	})
 }
 
@@ -176,7 +334,7 @@ func (h *Handlers) GetPointsHistoryHandler(c *gin.Context) {
 	endTimeStr := c.Query("end_time")
 
 	// 默认查询最近7天
-	endTime := time.Now()
+	endTime := time.Now().UTC()
 	startTime := endTime.Add(-7 * 24 * time.Hour)
 
 	if startTimeStr != "" {
@@ -200,9 +358,80 @@ func (h *Handlers) GetPointsHistoryHandler(c *gin.Context) {
 		return
 	}
 
+	asOfBlock, err := h.syncStatusService.GetAsOfBlock(c.Request.Context(), chainName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, Response{
-		Success: true,
-		Data:    history,
+		Success:   true,
+		Data:      history,
+		AsOfBlock: asOfBlock,
+	})
+}
+
+// GetPointsRollupHandler 按报表时区汇总用户每日/每周/每月获得的积分
+// GET /api/v1/points/:chain/:address/rollup?granularity=day&start_time=xxx&end_time=xxx
+func (h *Handlers) GetPointsRollupHandler(c *gin.Context) {
+	chainName := c.Param("chain")
+	userAddress := c.Param("address")
+
+	granularity := c.DefaultQuery("granularity", "day")
+
+	var startTime, endTime time.Time
+	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
+		t, err := time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, Response{
+				Success: false,
+				Error:   "invalid start_time format, use RFC3339",
+			})
+			return
+		}
+		startTime = t
+	}
+	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
+		t, err := time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, Response{
+				Success: false,
+				Error:   "invalid end_time format, use RFC3339",
+			})
+			return
+		}
+		endTime = t
+	}
+
+	rollup, err := h.pointsService.GetUserPointsRollup(c.Request.Context(), chainName, userAddress, granularity, startTime, endTime)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, points.ErrInvalidRollup) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	asOfBlock, err := h.syncStatusService.GetAsOfBlock(c.Request.Context(), chainName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success:   true,
+		Data:      rollup,
+		AsOfBlock: asOfBlock,
 	})
 }
 
@@ -226,9 +455,19 @@ func (h *Handlers) GetLeaderboardHandler(c *gin.Context) {
 		return
 	}
 
+	asOfBlock, err := h.syncStatusService.GetAsOfBlock(c.Request.Context(), chainName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, Response{
-		Success: true,
-		Data:    topUsers,
+		Success:   true,
+		Data:      topUsers,
+		AsOfBlock: asOfBlock,
 	})
 }
 
@@ -247,7 +486,11 @@ func (h *Handlers) TriggerCalculationHandler(c *gin.Context) {
 
 	err := h.scheduler.TriggerCalculation(c.Request.Context(), chainName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, Response{
+		status := http.StatusInternalServerError
+		if errors.Is(err, points.ErrPeriodNotIndexed) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, Response{
 			Success: false,
 			Error:   err.Error(),
 		})
@@ -260,8 +503,8 @@ func (h *Handlers) TriggerCalculationHandler(c *gin.Context) {
 	})
 }
 
-// BackfillPointsHandler 执行积分回溯
-// POST /api/v1/admin/backfill/:chain
+// BackfillPointsHandler 提交积分回溯任务（异步执行，通过任务接口查询进度）
+// POST /api/v1/admin/backfill/:chain  (Header: X-Operator)
 // Body: {"start_time": "2024-01-01T00:00:00Z", "end_time": "2024-01-02T00:00:00Z"}
 func (h *Handlers) BackfillPointsHandler(c *gin.Context) {
 	chainName := c.Param("chain")
@@ -297,18 +540,19 @@ func (h *Handlers) BackfillPointsHandler(c *gin.Context) {
 		return
 	}
 
-	// 异步执行回溯（可能耗时较长）
-	go func() {
-		ctx := c.Request.Context()
-		if err := h.scheduler.RunBackfill(ctx, chainName, startTime, endTime); err != nil {
-			// 记录错误日志（这里简化处理）
-			_ = err
-		}
-	}()
+	backfillJob, err := h.jobService.NewBackfillJob(chainName, startTime, endTime, getOperator(c))
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+	if err := h.jobService.Submit(c.Request.Context(), backfillJob); err != nil {
+		respondJobError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusAccepted, Response{
 		Success: true,
-		Data:    gin.H{"message": "backfill started"},
+		Data:    backfillJob,
 	})
 }
 
@@ -317,7 +561,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -375,19 +619,84 @@ func SetupRoutes(router *gin.Engine, handlers *Handlers) {
 		// 积分相关
 		v1.GET("/points/:chain/:address", handlers.GetPointsHandler)
 		v1.GET("/points/:chain/:address/history", handlers.GetPointsHistoryHandler)
+		v1.GET("/points/:chain/:address/rollup", handlers.GetPointsRollupHandler)
+		v1.GET("/points/:chain/:address/transactions", handlers.GetPointsTransactionsHandler)
+
+		// 积分兑换（用户签名 + Idempotency-Key）
+		v1.GET("/points/:chain/:address/redeem/message", handlers.GetRedemptionMessageHandler)
+		v1.POST("/points/:chain/:address/redeem", handlers.RedeemPointsHandler)
+		v1.GET("/points/:chain/:address/redemptions", handlers.ListRedemptionsHandler)
 
 		// 排行榜
 		v1.GET("/leaderboard/:chain", handlers.GetLeaderboardHandler)
 
+		// 推荐
+		v1.POST("/referral/code", handlers.CreateReferralCodeHandler)
+		v1.GET("/referral/message", handlers.GetReferralMessageHandler)
+		v1.POST("/referral/bind", handlers.BindReferralHandler)
+		v1.GET("/referral/:address", handlers.GetReferralHandler)
+
+		// 同步状态
+		v1.GET("/sync", handlers.ListSyncStatusHandler)
+		v1.GET("/sync/:chain", handlers.GetSyncStatusHandler)
+
 		// 管理接口（生产环境应添加认证）
 		admin := v1.Group("/admin")
 		{
 			admin.POST("/calculate/:chain", handlers.TriggerCalculationHandler)
 			admin.POST("/backfill/:chain", handlers.BackfillPointsHandler)
+			admin.POST("/simulate/:chain", handlers.SimulatePointsHandler)
+			admin.POST("/recalculate/:chain", handlers.RecalculatePointsHandler)
+			admin.GET("/recalculations", handlers.ListRecalculationsHandler)
+			admin.GET("/recalculations/:id", handlers.GetRecalculationHandler)
+			admin.GET("/recalculations/:id/diffs", handlers.ListRecalculationDiffsHandler)
+
+			// 回溯/重算任务
+			admin.GET("/jobs", handlers.ListJobsHandler)
+			admin.GET("/jobs/:id", handlers.GetJobHandler)
+			admin.POST("/jobs/:id/cancel", handlers.CancelJobHandler)
+
+			// 死信管理
+			admin.GET("/failed-events", handlers.ListFailedEventsHandler)
+			admin.GET("/failed-events/:id", handlers.GetFailedEventHandler)
+			admin.POST("/failed-events/:id/retry", handlers.RetryFailedEventHandler)
+			admin.POST("/failed-events/:id/discard", handlers.DiscardFailedEventHandler)
+
+			// 余额异常（事件使余额为负）
+			admin.GET("/anomalies", handlers.ListAnomaliesHandler)
+			admin.GET("/anomalies/:id", handlers.GetAnomalyHandler)
+			admin.POST("/anomalies/:id/resolve", handlers.ResolveAnomalyHandler)
+
+			// 积分排除地址
+			admin.GET("/exclusions", handlers.ListExclusionsHandler)
+			admin.POST("/exclusions", handlers.AddExclusionHandler)
+			admin.DELETE("/exclusions/:address", handlers.RemoveExclusionHandler)
+			admin.GET("/exclusions/audit", handlers.ListExclusionAuditHandler)
+
+			// 手动积分调整
+			admin.POST("/points/adjust", handlers.AdjustPointsHandler)
+			admin.GET("/points/adjustments", handlers.ListAdjustmentsHandler)
+			admin.GET("/points/adjustments/:id", handlers.GetAdjustmentHandler)
+			admin.POST("/points/adjustments/:id/revert", handlers.RevertAdjustmentHandler)
+
+			// 女巫/刷量检测
+			admin.GET("/risk/:chain", handlers.ListRisksHandler)
+			admin.GET("/risk/:chain/audit", handlers.ListRiskAuditHandler)
+			admin.POST("/risk/:chain/analyze", handlers.AnalyzeRiskHandler)
+			admin.GET("/risk/:chain/:address", handlers.GetRiskHandler)
+			admin.PUT("/risk/:chain/:address/override", handlers.SetRiskOverrideHandler)
 		}
 	}
 }
 
+// OperatorHeader 管理接口中标识操作人的请求头
+const OperatorHeader = "X-Operator"
+
+// getOperator 获取管理接口的操作人，用于审计
+func getOperator(c *gin.Context) string {
+	return strings.TrimSpace(c.GetHeader(OperatorHeader))
+}
+
 // NormalizeAddress 标准化地址（转小写）
 func NormalizeAddress(address string) string {
 	return strings.ToLower(strings.TrimSpace(address))
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"my-token-points/internal/service/job"
+	"my-token-points/internal/service/points"
+)
+
+// ListJobsHandler 查询回溯/重算任务
+// GET /api/v1/admin/jobs?chain=sepolia&status=running&offset=0&limit=100
+func (h *Handlers) ListJobsHandler(c *gin.Context) {
+	offset, limit := parsePagination(c)
+	jobs, err := h.jobService.List(c.Request.Context(), c.Query("chain"), c.Query("status"), offset, limit)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    jobs,
+	})
+}
+
+// GetJobHandler 查询任务的状态、进度和错误信息
+// GET /api/v1/admin/jobs/:id
+func (h *Handlers) GetJobHandler(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.jobService.Get(c.Request.Context(), id)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// CancelJobHandler 取消任务（执行中的任务在当前周期完成后停止）
+// POST /api/v1/admin/jobs/:id/cancel
+func (h *Handlers) CancelJobHandler(c *gin.Context) {
+	id, ok := parseJobID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.jobService.Cancel(c.Request.Context(), id)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// parseJobID 解析路径中的任务 ID，失败时返回 400
+func parseJobID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "invalid job id",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondJobError 根据错误类型返回对应的状态码
+func respondJobError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, job.ErrInvalidJob), errors.Is(err, job.ErrUnknownChain), errors.Is(err, job.ErrOperatorRequired):
+		status = http.StatusBadRequest
+	case errors.Is(err, job.ErrJobNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, job.ErrChainBusy), errors.Is(err, job.ErrJobFinished):
+		status = http.StatusConflict
+	case errors.Is(err, points.ErrRecalculationUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, Response{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"my-token-points/internal/service/points"
+)
+
+// RecalculatePointsRequest 积分重算请求
+type RecalculatePointsRequest struct {
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+	Reason    string `json:"reason" binding:"required"`
+}
+
+// RecalculatePointsHandler 提交积分重算任务（异步执行，通过任务接口查询进度，重算记录查询差异）
+// POST /api/v1/admin/recalculate/:chain  (Header: X-Operator)
+// Body: {"start_time": "2024-01-01T00:00:00Z", "end_time": "2024-01-08T00:00:00Z", "reason": "reorg at block 123"}
+func (h *Handlers) RecalculatePointsHandler(c *gin.Context) {
+	var req RecalculatePointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "invalid start_time format, use RFC3339",
+		})
+		return
+	}
+	endTime, err := time.Parse(time.RFC3339, req.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "invalid end_time format, use RFC3339",
+		})
+		return
+	}
+
+	recalculationJob, err := h.jobService.NewRecalculationJob(
+		c.Param("chain"), startTime, endTime, req.Reason, getOperator(c),
+	)
+	if err != nil {
+		respondJobError(c, err)
+		return
+	}
+	if err := h.jobService.Submit(c.Request.Context(), recalculationJob); err != nil {
+		respondJobError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Success: true,
+		Data:    recalculationJob,
+	})
+}
+
+// ListRecalculationsHandler 查询重算记录
+// GET /api/v1/admin/recalculations?chain=sepolia&offset=0&limit=100
+func (h *Handlers) ListRecalculationsHandler(c *gin.Context) {
+	offset, limit := parsePagination(c)
+	recalculations, err := h.pointsService.ListRecalculations(c.Request.Context(), c.Query("chain"), offset, limit)
+	if err != nil {
+		respondRecalculationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    recalculations,
+	})
+}
+
+// GetRecalculationHandler 查询重算进度和汇总结果
+// GET /api/v1/admin/recalculations/:id
+func (h *Handlers) GetRecalculationHandler(c *gin.Context) {
+	id, ok := parseRecalculationID(c)
+	if !ok {
+		return
+	}
+
+	recalculation, err := h.pointsService.GetRecalculation(c.Request.Context(), id)
+	if err != nil {
+		respondRecalculationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    recalculation,
+	})
+}
+
+// ListRecalculationDiffsHandler 查询重算前后的用户积分差异（按总积分变化绝对值降序）
+// GET /api/v1/admin/recalculations/:id/diffs?offset=0&limit=100
+func (h *Handlers) ListRecalculationDiffsHandler(c *gin.Context) {
+	id, ok := parseRecalculationID(c)
+	if !ok {
+		return
+	}
+
+	offset, limit := parsePagination(c)
+	diffs, err := h.pointsService.ListRecalculationDiffs(c.Request.Context(), id, offset, limit)
+	if err != nil {
+		respondRecalculationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    diffs,
+	})
+}
+
+// parseRecalculationID 解析路径中的重算 ID，失败时返回 400
+func parseRecalculationID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "invalid recalculation id",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// respondRecalculationError 根据错误类型返回对应的状态码
+func respondRecalculationError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, points.ErrInvalidRecalculation):
+		status = http.StatusBadRequest
+	case errors.Is(err, points.ErrRecalculationNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, points.ErrRecalculationUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, Response{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
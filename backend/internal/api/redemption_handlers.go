@@ -0,0 +1,166 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"my-token-points/internal/service/redemption"
+)
+
+// IdempotencyKeyHeader 兑换接口的幂等键请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RedeemPointsRequest 兑换积分请求
+// Signature 为用户对 /redeem/message 返回的原文的 personal_sign 签名
+type RedeemPointsRequest struct {
+	Amount    float64 `json:"amount" binding:"required"`
+	Perk      string  `json:"perk" binding:"required"`
+	Signature string  `json:"signature" binding:"required"`
+}
+
+// GetRedemptionMessageHandler 获取兑换需要签名的原文
+// GET /api/v1/points/:chain/:address/redeem/message?amount=100&perk=xxx  (Header: Idempotency-Key)
+func (h *Handlers) GetRedemptionMessageHandler(c *gin.Context) {
+	amount, err := strconv.ParseFloat(c.Query("amount"), 64)
+	if err != nil || amount <= 0 {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "amount must be a positive number",
+		})
+		return
+	}
+	perk := strings.TrimSpace(c.Query("perk"))
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if perk == "" || key == "" {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "perk and Idempotency-Key header are required",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data: gin.H{
+			"message": redemption.RedemptionMessage(c.Param("chain"), c.Param("address"), amount, perk, key),
+		},
+	})
+}
+
+// RedeemPointsHandler 兑换积分
+// POST /api/v1/points/:chain/:address/redeem  (Header: Idempotency-Key)
+func (h *Handlers) RedeemPointsHandler(c *gin.Context) {
+	var req RedeemPointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	redeemed, replayed, err := h.redemptionService.Redeem(c.Request.Context(), &redemption.RedeemRequest{
+		ChainName:      c.Param("chain"),
+		UserAddress:    c.Param("address"),
+		Amount:         req.Amount,
+		Perk:           req.Perk,
+		IdempotencyKey: c.GetHeader(IdempotencyKeyHeader),
+		Signature:      req.Signature,
+	})
+	if err != nil {
+		respondRedemptionError(c, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if replayed {
+		status = http.StatusOK
+	}
+	c.JSON(status, Response{
+		Success: true,
+		Data:    redeemed,
+	})
+}
+
+// ListRedemptionsHandler 查询用户兑换记录
+// GET /api/v1/points/:chain/:address/redemptions?offset=0&limit=100
+func (h *Handlers) ListRedemptionsHandler(c *gin.Context) {
+	offset, limit := parsePagination(c)
+
+	redemptions, err := h.redemptionService.ListRedemptions(
+		c.Request.Context(), c.Param("chain"), c.Param("address"), offset, limit,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    redemptions,
+	})
+}
+
+// GetPointsTransactionsHandler 查询用户积分流水
+// GET /api/v1/points/:chain/:address/transactions?type=spend&offset=0&limit=100
+func (h *Handlers) GetPointsTransactionsHandler(c *gin.Context) {
+	offset, limit := parsePagination(c)
+
+	transactions, err := h.pointsService.GetUserTransactions(
+		c.Request.Context(), c.Param("chain"), c.Param("address"), c.Query("type"), offset, limit,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    transactions,
+	})
+}
+
+// parsePagination 解析 offset/limit 查询参数（limit 默认 100，最大 1000）
+func parsePagination(c *gin.Context) (int, int) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	return offset, limit
+}
+
+// respondRedemptionError 根据错误类型返回对应的状态码
+func respondRedemptionError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, redemption.ErrInvalidAddress), errors.Is(err, redemption.ErrUnknownChain),
+		errors.Is(err, redemption.ErrInvalidAmount), errors.Is(err, redemption.ErrPerkRequired),
+		errors.Is(err, redemption.ErrInvalidIdempotencyKey):
+		status = http.StatusBadRequest
+	case errors.Is(err, redemption.ErrInvalidSignature):
+		status = http.StatusUnauthorized
+	case errors.Is(err, redemption.ErrInsufficientPoints):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, redemption.ErrIdempotencyConflict):
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, Response{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"my-token-points/internal/service/referral"
+)
+
+// ReferralCodeRequest 创建推荐码请求
+type ReferralCodeRequest struct {
+	Address string `json:"address" binding:"required"`
+}
+
+// BindReferralRequest 绑定推荐码请求
+// Signature 为被推荐人对 /referral/message 返回的原文的 personal_sign 签名
+type BindReferralRequest struct {
+	Code      string `json:"code" binding:"required"`
+	Address   string `json:"address" binding:"required"`
+	Signature string `json:"signature" binding:"required"`
+}
+
+// CreateReferralCodeHandler 查询或生成地址的推荐码
+// POST /api/v1/referral/code
+func (h *Handlers) CreateReferralCodeHandler(c *gin.Context) {
+	var req ReferralCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	code, err := h.referralService.GetOrCreateCode(c.Request.Context(), req.Address)
+	if err != nil {
+		respondReferralError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    code,
+	})
+}
+
+// GetReferralMessageHandler 获取绑定推荐码需要签名的原文
+// GET /api/v1/referral/message?code=ABCD2345&address=0x...
+func (h *Handlers) GetReferralMessageHandler(c *gin.Context) {
+	code := c.Query("code")
+	address := c.Query("address")
+	if code == "" || address == "" {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "code and address are required",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    gin.H{"message": referral.BindingMessage(code, address)},
+	})
+}
+
+// BindReferralHandler 绑定推荐码
+// POST /api/v1/referral/bind
+func (h *Handlers) BindReferralHandler(c *gin.Context) {
+	var req BindReferralRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	bound, err := h.referralService.BindReferral(c.Request.Context(), req.Code, req.Address, req.Signature)
+	if err != nil {
+		respondReferralError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Data:    bound,
+	})
+}
+
+// GetReferralHandler 查询地址的推荐码、推荐人和邀请的地址
+// GET /api/v1/referral/:address?offset=0&limit=100
+func (h *Handlers) GetReferralHandler(c *gin.Context) {
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	info, err := h.referralService.GetReferralInfo(c.Request.Context(), c.Param("address"), offset, limit)
+	if err != nil {
+		respondReferralError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    info,
+	})
+}
+
+// respondReferralError 根据错误类型返回对应的状态码
+func respondReferralError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, referral.ErrInvalidAddress), errors.Is(err, referral.ErrSelfReferral):
+		status = http.StatusBadRequest
+	case errors.Is(err, referral.ErrInvalidSignature):
+		status = http.StatusUnauthorized
+	case errors.Is(err, referral.ErrCodeNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, referral.ErrAlreadyBound), errors.Is(err, referral.ErrReferralCycle):
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, Response{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"my-token-points/internal/service/sybil"
+)
+
+// RiskOverrideRequest 人工覆盖风险处理结果请求
+type RiskOverrideRequest struct {
+	Override string `json:"override" binding:"required"` // none, clear, flag, exclude
+	Reason   string `json:"reason" binding:"required"`
+}
+
+// ListRisksHandler 查询风险地址（默认返回达到降权阈值或有人工覆盖的地址）
+// GET /api/v1/admin/risk/:chain?min_score=0.5&offset=0&limit=100
+func (h *Handlers) ListRisksHandler(c *gin.Context) {
+	if !h.requireSybil(c) {
+		return
+	}
+
+	minScore := h.sybilService.FlagThreshold()
+	if value := c.Query("min_score"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			c.JSON(http.StatusBadRequest, Response{
+				Success: false,
+				Error:   "min_score must be between 0 and 1",
+			})
+			return
+		}
+		minScore = parsed
+	}
+	offset, limit := parsePagination(c)
+
+	risks, err := h.sybilService.ListRisks(c.Request.Context(), c.Param("chain"), minScore, offset, limit)
+	if err != nil {
+		respondRiskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    risks,
+	})
+}
+
+// GetRiskHandler 查询地址的风险分和命中的信号
+// GET /api/v1/admin/risk/:chain/:address
+func (h *Handlers) GetRiskHandler(c *gin.Context) {
+	if !h.requireSybil(c) {
+		return
+	}
+
+	risk, err := h.sybilService.GetRisk(c.Request.Context(), c.Param("chain"), c.Param("address"))
+	if err != nil {
+		respondRiskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    risk,
+	})
+}
+
+// SetRiskOverrideHandler 人工覆盖地址的处理结果
+// PUT /api/v1/admin/risk/:chain/:address/override  (Header: X-Operator)
+func (h *Handlers) SetRiskOverrideHandler(c *gin.Context) {
+	if !h.requireSybil(c) {
+		return
+	}
+
+	var req RiskOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	risk, err := h.sybilService.SetOverride(
+		c.Request.Context(), c.Param("chain"), c.Param("address"), req.Override, req.Reason, getOperator(c),
+	)
+	if err != nil {
+		respondRiskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    risk,
+	})
+}
+
+// AnalyzeRiskHandler 立即执行一次女巫检测
+// POST /api/v1/admin/risk/:chain/analyze
+func (h *Handlers) AnalyzeRiskHandler(c *gin.Context) {
+	if !h.requireSybil(c) {
+		return
+	}
+
+	result, err := h.sybilService.Analyze(c.Request.Context(), c.Param("chain"))
+	if err != nil {
+		respondRiskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// ListRiskAuditHandler 查询风险覆盖审计记录
+// GET /api/v1/admin/risk/:chain/audit?address=0x...&offset=0&limit=100
+func (h *Handlers) ListRiskAuditHandler(c *gin.Context) {
+	if !h.requireSybil(c) {
+		return
+	}
+
+	offset, limit := parsePagination(c)
+	audits, err := h.sybilService.ListAudit(c.Request.Context(), c.Param("chain"), c.Query("address"), offset, limit)
+	if err != nil {
+		respondRiskError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    audits,
+	})
+}
+
+// requireSybil 未启用女巫检测时返回 503
+func (h *Handlers) requireSybil(c *gin.Context) bool {
+	if h.sybilService != nil {
+		return true
+	}
+
+	c.JSON(http.StatusServiceUnavailable, Response{
+		Success: false,
+		Error:   "sybil detection is disabled",
+	})
+	return false
+}
+
+// respondRiskError 根据错误类型返回对应的状态码
+func respondRiskError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, sybil.ErrInvalidAddress), errors.Is(err, sybil.ErrUnknownChain),
+		errors.Is(err, sybil.ErrInvalidOverride), errors.Is(err, sybil.ErrReasonRequired),
+		errors.Is(err, sybil.ErrOperatorRequired):
+		status = http.StatusBadRequest
+	case errors.Is(err, sybil.ErrRiskNotFound):
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, Response{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
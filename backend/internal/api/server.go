@@ -9,9 +9,19 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
+	"my-token-points/internal/pkg/leader"
+	"my-token-points/internal/service/adjustment"
+	"my-token-points/internal/service/anomaly"
 	"my-token-points/internal/service/balance"
+	"my-token-points/internal/service/deadletter"
+	"my-token-points/internal/service/exclusion"
+	"my-token-points/internal/service/job"
 	"my-token-points/internal/service/points"
+	"my-token-points/internal/service/redemption"
+	"my-token-points/internal/service/referral"
 	"my-token-points/internal/service/scheduler"
+	"my-token-points/internal/service/sybil"
+	"my-token-points/internal/service/syncstatus"
 )
 
 // ServerConfig API服务器配置
@@ -21,23 +31,36 @@ type ServerConfig struct {
 	Mode string // debug, release, test
 }
 
+// Services API 依赖的服务集合
+type Services struct {
+	Balance    *balance.BalanceService
+	Points     *points.PointsService
+	Scheduler  *scheduler.Scheduler
+	SyncStatus *syncstatus.SyncStatusService
+	DeadLetter *deadletter.DeadLetterService
+	Anomaly    *anomaly.AnomalyService
+	Exclusion  *exclusion.ExclusionService
+	Referral   *referral.ReferralService
+	Adjustment *adjustment.AdjustmentService
+	Redemption *redemption.RedemptionService
+	Sybil      *sybil.SybilService // 未启用女巫检测时为 nil
+	Jobs       *job.JobService
+	Leader     *leader.Elector // 调度器领导者选举，用于健康检查展示
+}
+
 // Server API服务器
 type Server struct {
-	config         *ServerConfig
-	router         *gin.Engine
-	server         *http.Server
-	logger         *logrus.Logger
-	balanceService *balance.BalanceService
-	pointsService  *points.PointsService
-	scheduler      *scheduler.Scheduler
+	config   *ServerConfig
+	router   *gin.Engine
+	server   *http.Server
+	logger   *logrus.Logger
+	services *Services
 }
 
 // NewServer 创建API服务器
 func NewServer(
 	config *ServerConfig,
-	balanceService *balance.BalanceService,
-	pointsService *points.PointsService,
-	scheduler *scheduler.Scheduler,
+	services *Services,
 	logger *logrus.Logger,
 ) *Server {
 	// 设置Gin模式
@@ -51,18 +74,16 @@ func NewServer(
 	router := gin.New()
 
 	// 创建处理器
-	handlers := NewHandlers(balanceService, pointsService, scheduler)
+	handlers := NewHandlers(services)
 
 	// 设置路由
 	SetupRoutes(router, handlers)
 
 	return &Server{
-		config:         config,
-		router:         router,
-		logger:         logger,
-		balanceService: balanceService,
-		pointsService:  pointsService,
-		scheduler:      scheduler,
+		config:   config,
+		router:   router,
+		logger:   logger,
+		services: services,
 	}
 }
 
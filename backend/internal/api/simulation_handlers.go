@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"my-token-points/internal/service/points"
+)
+
+// SimulatePointsRequest 积分模拟请求，未填写的规则沿用当前配置
+type SimulatePointsRequest struct {
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+	TopN      int    `json:"top_n"`
+
+	HourlyRate       *float64               `json:"hourly_rate"`
+	StakedMultiplier *float64               `json:"staked_multiplier"`
+	ReferralRate     *float64               `json:"referral_rate"`
+	HoldingStreak    *SimulateStreakRequest `json:"holding_streak"`
+}
+
+// SimulateStreakRequest 模拟使用的持有时长倍数（enabled 为 false 表示关闭）
+type SimulateStreakRequest struct {
+	Enabled bool   `json:"enabled"`
+	Mode    string `json:"mode"`
+	Tiers   []struct {
+		MinDays    float64 `json:"min_days"`
+		Multiplier float64 `json:"multiplier"`
+	} `json:"tiers"`
+}
+
+// SimulatePointsHandler 用替代配置模拟计算积分，并与实际结果对比（不写入任何积分数据）
+// POST /api/v1/admin/simulate/:chain
+// Body: {"start_time": "2024-01-01T00:00:00Z", "end_time": "2024-01-08T00:00:00Z", "hourly_rate": 0.08}
+func (h *Handlers) SimulatePointsHandler(c *gin.Context) {
+	var req SimulatePointsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "invalid start_time format, use RFC3339",
+		})
+		return
+	}
+	endTime, err := time.Parse(time.RFC3339, req.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Success: false,
+			Error:   "invalid end_time format, use RFC3339",
+		})
+		return
+	}
+
+	overrides := &points.SimulationOverrides{
+		HourlyRate:       req.HourlyRate,
+		StakedMultiplier: req.StakedMultiplier,
+		ReferralRate:     req.ReferralRate,
+	}
+	if req.HoldingStreak != nil {
+		if !req.HoldingStreak.Enabled {
+			overrides.DisableHoldingStreak = true
+		} else {
+			streak := &points.StreakConfig{Mode: req.HoldingStreak.Mode}
+			if streak.Mode == "" {
+				streak.Mode = points.StreakModeReset
+			}
+			for _, tier := range req.HoldingStreak.Tiers {
+				streak.Tiers = append(streak.Tiers, points.StreakTier{
+					MinHolding: time.Duration(tier.MinDays * float64(24*time.Hour)),
+					Multiplier: tier.Multiplier,
+				})
+			}
+			overrides.HoldingStreak = streak
+		}
+	}
+
+	report, err := h.pointsService.Simulate(c.Request.Context(), c.Param("chain"), startTime, endTime, overrides, req.TopN)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, points.ErrInvalidSimulation) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, Response{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Success: true,
+		Data:    report,
+	})
+}
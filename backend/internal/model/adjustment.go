@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+// PointsAdjustment 手动积分调整
+type PointsAdjustment struct {
+	ID           int64      `db:"id" json:"id"`
+	ChainName    string     `db:"chain_name" json:"chain_name"`
+	UserAddress  string     `db:"user_address" json:"user_address"`
+	Amount       float64    `db:"amount" json:"amount"` // 正数为发放，负数为扣回
+	Reason       string     `db:"reason" json:"reason"`
+	Operator     string     `db:"operator" json:"operator"`
+	Status       string     `db:"status" json:"status"` // applied, reverted
+	RevertedBy   *string    `db:"reverted_by" json:"reverted_by,omitempty"`
+	RevertReason *string    `db:"revert_reason" json:"revert_reason,omitempty"`
+	RevertedAt   *time.Time `db:"reverted_at" json:"reverted_at,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
+
+// PointsAdjustment 状态常量
+const (
+	AdjustmentStatusApplied  = "applied"
+	AdjustmentStatusReverted = "reverted"
+)
@@ -9,7 +9,8 @@ type UserBalance struct {
 	ID              int64     `db:"id" json:"id"`
 	ChainName       string    `db:"chain_name" json:"chain_name"`
 	UserAddress     string    `db:"user_address" json:"user_address"`
-	Balance         string    `db:"balance" json:"balance"` // 使用string存储大数
+	Balance         string    `db:"balance" json:"balance"`               // 钱包余额，使用string存储大数
+	StakedBalance   string    `db:"staked_balance" json:"staked_balance"` // 托管合约中的质押余额
 	LastUpdateBlock int64     `db:"last_update_block" json:"last_update_block"`
 	LastUpdateTime  time.Time `db:"last_update_time" json:"last_update_time"`
 	CreatedAt       time.Time `db:"created_at" json:"created_at"`
@@ -18,18 +19,21 @@ type UserBalance struct {
 
 // BalanceChange 余额变动模型
 type BalanceChange struct {
-	ID            int64     `db:"id" json:"id"`
-	ChainName     string    `db:"chain_name" json:"chain_name"`
-	UserAddress   string    `db:"user_address" json:"user_address"`
-	TxHash        string    `db:"tx_hash" json:"tx_hash"`
-	BlockNumber   int64     `db:"block_number" json:"block_number"`
-	BlockTime     time.Time `db:"block_time" json:"block_time"`
-	EventType     EventType `db:"event_type" json:"event_type"` // mint, burn, transfer_in, transfer_out
-	AmountDelta   string    `db:"amount_delta" json:"amount_delta"`
-	BalanceBefore string    `db:"balance_before" json:"balance_before"`
-	BalanceAfter  string    `db:"balance_after" json:"balance_after"`
-	Confirmed     bool      `db:"confirmed" json:"confirmed"`
-	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	ID              int64       `db:"id" json:"id"`
+	ChainName       string      `db:"chain_name" json:"chain_name"`
+	UserAddress     string      `db:"user_address" json:"user_address"`
+	TxHash          string      `db:"tx_hash" json:"tx_hash"`
+	LogIndex        int         `db:"log_index" json:"log_index"`
+	ContractAddress string      `db:"contract_address" json:"contract_address"`
+	BlockNumber     int64       `db:"block_number" json:"block_number"`
+	BlockTime       time.Time   `db:"block_time" json:"block_time"`
+	EventType       EventType   `db:"event_type" json:"event_type"`     // mint, burn, transfer_in, transfer_out, stake, unstake
+	BalanceType     BalanceType `db:"balance_type" json:"balance_type"` // wallet, staked
+	AmountDelta     string      `db:"amount_delta" json:"amount_delta"`
+	BalanceBefore   string      `db:"balance_before" json:"balance_before"`
+	BalanceAfter    string      `db:"balance_after" json:"balance_after"`
+	Confirmed       bool        `db:"confirmed" json:"confirmed"`
+	CreatedAt       time.Time   `db:"created_at" json:"created_at"`
 }
 
 // EventType 事件类型
@@ -41,5 +45,15 @@ const (
 	EventTypeBurn        EventType = "burn"
 	EventTypeTransferIn  EventType = "transfer_in"
 	EventTypeTransferOut EventType = "transfer_out"
+	EventTypeStake       EventType = "stake"
+	EventTypeUnstake     EventType = "unstake"
 )
 
+// BalanceType 余额类型
+type BalanceType string
+
+// BalanceType 常量
+const (
+	BalanceTypeWallet BalanceType = "wallet"
+	BalanceTypeStaked BalanceType = "staked"
+)
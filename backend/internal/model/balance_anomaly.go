@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// BalanceAnomaly 余额异常（事件导致余额为负）
+type BalanceAnomaly struct {
+	ID              int64       `db:"id" json:"id"`
+	ChainName       string      `db:"chain_name" json:"chain_name"`
+	UserAddress     string      `db:"user_address" json:"user_address"`
+	TxHash          string      `db:"tx_hash" json:"tx_hash"`
+	LogIndex        int         `db:"log_index" json:"log_index"`
+	BlockNumber     int64       `db:"block_number" json:"block_number"`
+	BalanceType     BalanceType `db:"balance_type" json:"balance_type"`
+	BalanceBefore   string      `db:"balance_before" json:"balance_before"`     // 本地余额
+	AmountDelta     string      `db:"amount_delta" json:"amount_delta"`         // 事件变动
+	ComputedBalance string      `db:"computed_balance" json:"computed_balance"` // 本地余额 + 变动（负数）
+	Action          string      `db:"action" json:"action"`                     // clamped, rejected, reconciled
+	ChainBalance    *string     `db:"chain_balance" json:"chain_balance,omitempty"`
+	BalanceAfter    *string     `db:"balance_after" json:"balance_after,omitempty"`
+	Occurrences     int         `db:"occurrences" json:"occurrences"`
+	Status          string      `db:"status" json:"status"` // open, resolved
+	ResolvedBy      *string     `db:"resolved_by" json:"resolved_by,omitempty"`
+	ResolutionNote  *string     `db:"resolution_note" json:"resolution_note,omitempty"`
+	ResolvedAt      *time.Time  `db:"resolved_at" json:"resolved_at,omitempty"`
+	CreatedAt       time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time   `db:"updated_at" json:"updated_at"`
+}
+
+// BalanceAnomaly 处理方式常量
+const (
+	AnomalyActionClamped    = "clamped"
+	AnomalyActionRejected   = "rejected"
+	AnomalyActionReconciled = "reconciled"
+)
+
+// BalanceAnomaly 状态常量
+const (
+	AnomalyStatusOpen     = "open"
+	AnomalyStatusResolved = "resolved"
+)
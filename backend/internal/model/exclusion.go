@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+)
+
+// ExcludedAddress 积分排除地址
+type ExcludedAddress struct {
+	ID        int64     `db:"id" json:"id,omitempty"`
+	ChainName string    `db:"chain_name" json:"chain_name"` // 为空表示所有链
+	Address   string    `db:"address" json:"address"`
+	Reason    string    `db:"reason" json:"reason"`
+	CreatedBy string    `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at,omitempty"`
+	Source    string    `db:"-" json:"source"` // builtin, config, database
+}
+
+// ExcludedAddressAudit 排除地址操作审计
+type ExcludedAddressAudit struct {
+	ID        int64     `db:"id" json:"id"`
+	ChainName string    `db:"chain_name" json:"chain_name"`
+	Address   string    `db:"address" json:"address"`
+	Action    string    `db:"action" json:"action"` // add, remove
+	Reason    string    `db:"reason" json:"reason"`
+	Operator  string    `db:"operator" json:"operator"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// ExcludedAddress 来源常量
+const (
+	ExclusionSourceBuiltin  = "builtin"
+	ExclusionSourceConfig   = "config"
+	ExclusionSourceDatabase = "database"
+)
+
+// ExcludedAddressAudit 操作常量
+const (
+	ExclusionActionAdd    = "add"
+	ExclusionActionRemove = "remove"
+)
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// HexStrings 十六进制字符串数组 (用于JSONB)
+type HexStrings []string
+
+// Value 实现 driver.Valuer 接口
+func (h HexStrings) Value() (driver.Value, error) {
+	if h == nil {
+		return json.Marshal([]string{})
+	}
+	return json.Marshal([]string(h))
+}
+
+// Scan 实现 sql.Scanner 接口
+func (h *HexStrings) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, h)
+}
+
+// FailedEvent 处理失败的事件日志（死信）
+type FailedEvent struct {
+	ID              int64      `db:"id" json:"id"`
+	ChainName       string     `db:"chain_name" json:"chain_name"`
+	ContractAddress string     `db:"contract_address" json:"contract_address"`
+	TxHash          string     `db:"tx_hash" json:"tx_hash"`
+	TxIndex         int        `db:"tx_index" json:"tx_index"`
+	BlockNumber     int64      `db:"block_number" json:"block_number"`
+	BlockHash       string     `db:"block_hash" json:"block_hash"`
+	LogIndex        int        `db:"log_index" json:"log_index"`
+	Topics          HexStrings `db:"topics" json:"topics"`
+	Data            string     `db:"data" json:"data"`
+	ErrorMessage    string     `db:"error_message" json:"error_message"`
+	Attempts        int        `db:"attempts" json:"attempts"`
+	Status          string     `db:"status" json:"status"` // pending, exhausted, resolved, discarded
+	NextRetryAt     *time.Time `db:"next_retry_at" json:"next_retry_at,omitempty"`
+	ResolvedAt      *time.Time `db:"resolved_at" json:"resolved_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// FailedEvent 状态常量
+const (
+	FailedEventPending   = "pending"
+	FailedEventExhausted = "exhausted"
+	FailedEventResolved  = "resolved"
+	FailedEventDiscarded = "discarded"
+)
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Job 后台任务
+type Job struct {
+	ID              int64      `db:"id" json:"id"`
+	JobType         string     `db:"job_type" json:"job_type"` // backfill, recalculation
+	ChainName       string     `db:"chain_name" json:"chain_name"`
+	Params          JobParams  `db:"params" json:"params"`
+	Status          string     `db:"status" json:"status"` // pending, running, completed, failed, cancelled
+	ProgressDone    int        `db:"progress_done" json:"progress_done"`
+	ProgressTotal   int        `db:"progress_total" json:"progress_total"`
+	Cursor          *time.Time `db:"cursor_time" json:"cursor,omitempty"` // 最后完成的周期结束时间
+	CancelRequested bool       `db:"cancel_requested" json:"cancel_requested"`
+	ErrorMessage    *string    `db:"error_message" json:"error_message,omitempty"`
+	CreatedBy       string     `db:"created_by" json:"created_by"`
+	WorkerID        *string    `db:"worker_id" json:"worker_id,omitempty"`
+	HeartbeatAt     *time.Time `db:"heartbeat_at" json:"heartbeat_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	StartedAt       *time.Time `db:"started_at" json:"started_at,omitempty"`
+	FinishedAt      *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// JobParams 任务参数 (用于JSONB)
+type JobParams struct {
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	Reason          string    `json:"reason,omitempty"`
+	RecalculationID *int64    `json:"recalculation_id,omitempty"` // recalculation 任务对应的重算记录
+}
+
+// Value 实现 driver.Valuer 接口
+func (p JobParams) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (p *JobParams) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+// Job 类型常量
+const (
+	JobTypeBackfill      = "backfill"
+	JobTypeRecalculation = "recalculation"
+)
+
+// Job 状态常量
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
@@ -6,22 +6,31 @@ import (
 	"time"
 )
 
-// UserPoints 用户积分模型
+// UserPoints 用户积分模型（TotalPoints 为累计获得积分）
 type UserPoints struct {
-	ID          int64     `db:"id" json:"id"`
-	ChainName   string    `db:"chain_name" json:"chain_name"`
-	UserAddress string    `db:"user_address" json:"user_address"`
-	TotalPoints float64   `db:"total_points" json:"total_points"`
-	LastCalcAt  *time.Time `db:"last_calc_at" json:"last_calc_at"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	ID               int64      `db:"id" json:"id"`
+	ChainName        string     `db:"chain_name" json:"chain_name"`
+	UserAddress      string     `db:"user_address" json:"user_address"`
+	TotalPoints      float64    `db:"total_points" json:"total_points"`
+	SpentPoints      float64    `db:"spent_points" json:"spent_points"`
+	ExpiredPoints    float64    `db:"expired_points" json:"expired_points"`
+	AvailablePoints  float64    `db:"available_points" json:"available_points"` // total_points - spent_points - expired_points
+	ExpiringSoon     float64    `db:"-" json:"expiring_soon"`                   // 即将过期的积分
+	NextExpiryAt     *time.Time `db:"-" json:"next_expiry_at,omitempty"`        // 最近一批积分的过期时间
+	HoldingSince     *time.Time `db:"-" json:"holding_since,omitempty"`         // 连续持有起点
+	StreakDays       float64    `db:"-" json:"streak_days,omitempty"`           // 连续持有天数
+	StreakMultiplier float64    `db:"-" json:"streak_multiplier,omitempty"`     // 当前持有时长倍数
+	LastCalcAt       *time.Time `db:"last_calc_at" json:"last_calc_at"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 // BalanceSnapshot 余额快照
 type BalanceSnapshot struct {
-	Balance   string    `json:"balance"`
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
+	Balance     string      `json:"balance"`
+	BalanceType BalanceType `json:"balance_type,omitempty"` // 为空表示钱包余额
+	StartTime   time.Time   `json:"start_time"`
+	EndTime     time.Time   `json:"end_time"`
 }
 
 // BalanceSnapshots 余额快照数组 (用于JSONB)
@@ -50,13 +59,37 @@ type PointsHistory struct {
 	CalcPeriodEnd   time.Time        `db:"calc_period_end" json:"calc_period_end"`
 	BalanceSnapshot BalanceSnapshots `db:"balance_snapshot" json:"balance_snapshot"`
 	PointsEarned    float64          `db:"points_earned" json:"points_earned"`
-	CalculationType string           `db:"calculation_type" json:"calculation_type"` // normal, backfill
+	CalculationType string           `db:"calculation_type" json:"calculation_type"`           // normal, backfill, referral, adjustment, recalculation
+	SourceAddress   *string          `db:"source_address" json:"source_address,omitempty"`     // referral 类型为被推荐人
+	AdjustmentID    *int64           `db:"adjustment_id" json:"adjustment_id,omitempty"`       // adjustment 类型关联的调整
+	RemainingPoints float64          `db:"remaining_points" json:"remaining_points"`           // 批次未消费、未过期的剩余积分
+	ExpiresAt       *time.Time       `db:"expires_at" json:"expires_at,omitempty"`             // 批次过期时间，为空表示不过期
+	RiskMultiplier  float64          `db:"risk_multiplier" json:"risk_multiplier"`             // 风险降权倍数，1 表示未降权
+	RecalculationID *int64           `db:"recalculation_id" json:"recalculation_id,omitempty"` // 写入该记录的积分重算
+	BasedOnBlock    *int64           `db:"based_on_block" json:"based_on_block,omitempty"`     // 计算时已同步的区块高度
 	CreatedAt       time.Time        `db:"created_at" json:"created_at"`
 }
 
 // CalculationType 计算类型常量
 const (
-	CalcTypeNormal   = "normal"
-	CalcTypeBackfill = "backfill"
+	CalcTypeNormal        = "normal"
+	CalcTypeBackfill      = "backfill"
+	CalcTypeReferral      = "referral"
+	CalcTypeAdjustment    = "adjustment"
+	CalcTypeRecalculation = "recalculation"
 )
 
+// PointsRollup 按日/周/月汇总的用户积分（周期按报表时区划分）
+type PointsRollup struct {
+	PeriodStart  time.Time `db:"period_start" json:"period_start"`
+	PeriodEnd    time.Time `db:"-" json:"period_end"`
+	PointsEarned float64   `db:"points_earned" json:"points_earned"`
+	Records      int       `db:"records" json:"records"` // 汇总的积分历史记录数
+}
+
+// RollupGranularity 积分汇总粒度常量
+const (
+	RollupDay   = "day"
+	RollupWeek  = "week"
+	RollupMonth = "month"
+)
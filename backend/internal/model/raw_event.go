@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// RawEvent 原始事件日志归档
+type RawEvent struct {
+	ID              int64      `db:"id" json:"id"`
+	ChainName       string     `db:"chain_name" json:"chain_name"`
+	ContractAddress string     `db:"contract_address" json:"contract_address"`
+	EventName       string     `db:"event_name" json:"event_name"`
+	BlockNumber     int64      `db:"block_number" json:"block_number"`
+	BlockHash       string     `db:"block_hash" json:"block_hash"`
+	BlockTime       time.Time  `db:"block_time" json:"block_time"`
+	TxHash          string     `db:"tx_hash" json:"tx_hash"`
+	TxIndex         int        `db:"tx_index" json:"tx_index"`
+	LogIndex        int        `db:"log_index" json:"log_index"`
+	Topics          HexStrings `db:"topics" json:"topics"`
+	Data            string     `db:"data" json:"data"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// PointsRecalculation 积分重算记录
+type PointsRecalculation struct {
+	ID           int64      `db:"id" json:"id"`
+	ChainName    string     `db:"chain_name" json:"chain_name"`
+	PeriodStart  time.Time  `db:"period_start" json:"period_start"`
+	PeriodEnd    time.Time  `db:"period_end" json:"period_end"`
+	Status       string     `db:"status" json:"status"` // running, completed, failed, cancelled
+	Reason       string     `db:"reason" json:"reason"`
+	Operator     string     `db:"operator" json:"operator"`
+	PeriodsDone  int        `db:"periods_done" json:"periods_done"`   // 已重算的小时数
+	UsersChanged int        `db:"users_changed" json:"users_changed"` // 总积分发生变化的用户数
+	PointsBefore float64    `db:"points_before" json:"points_before"` // 重算前时间段内的计算积分合计
+	PointsAfter  float64    `db:"points_after" json:"points_after"`   // 重算后时间段内的计算积分合计
+	ErrorMessage *string    `db:"error_message" json:"error_message,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	CompletedAt  *time.Time `db:"completed_at" json:"completed_at,omitempty"`
+}
+
+// PointsRecalculation 状态常量
+const (
+	RecalculationStatusRunning   = "running"
+	RecalculationStatusCompleted = "completed"
+	RecalculationStatusFailed    = "failed"
+	RecalculationStatusCancelled = "cancelled"
+)
+
+// RecalculationDiff 单个用户重算前后的积分
+type RecalculationDiff struct {
+	ID                 int64      `db:"id" json:"id"`
+	RecalculationID    int64      `db:"recalculation_id" json:"recalculation_id"`
+	UserAddress        string     `db:"user_address" json:"user_address"`
+	PeriodPointsBefore float64    `db:"period_points_before" json:"period_points_before"`
+	PeriodPointsAfter  float64    `db:"period_points_after" json:"period_points_after"`
+	TotalBefore        float64    `db:"total_before" json:"total_before"`
+	TotalAfter         float64    `db:"total_after" json:"total_after"`
+	RebuiltAt          *time.Time `db:"rebuilt_at" json:"rebuilt_at,omitempty"` // 为空表示只记录了重算前的积分
+	CreatedAt          time.Time  `db:"created_at" json:"created_at"`
+}
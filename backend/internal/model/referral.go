@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+// ReferralCode 推荐码
+type ReferralCode struct {
+	ID           int64     `db:"id" json:"id"`
+	Code         string    `db:"code" json:"code"`
+	OwnerAddress string    `db:"owner_address" json:"owner_address"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// Referral 推荐关系
+type Referral struct {
+	ID              int64     `db:"id" json:"id"`
+	ReferrerAddress string    `db:"referrer_address" json:"referrer_address"`
+	RefereeAddress  string    `db:"referee_address" json:"referee_address"`
+	Code            string    `db:"code" json:"code"`
+	Message         string    `db:"message" json:"message"`
+	Signature       string    `db:"signature" json:"signature"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+}
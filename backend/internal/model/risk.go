@@ -0,0 +1,98 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// AddressRisk 地址风险分
+type AddressRisk struct {
+	ID             int64       `db:"id" json:"id"`
+	ChainName      string      `db:"chain_name" json:"chain_name"`
+	Address        string      `db:"address" json:"address"`
+	Score          float64     `db:"score" json:"score"`
+	Signals        RiskSignals `db:"signals" json:"signals"`
+	FunderAddress  *string     `db:"funder_address" json:"funder_address,omitempty"`
+	Override       string      `db:"override" json:"override"` // none, clear, flag, exclude
+	OverrideReason string      `db:"override_reason" json:"override_reason,omitempty"`
+	OverrideBy     *string     `db:"override_by" json:"override_by,omitempty"`
+	OverrideAt     *time.Time  `db:"override_at" json:"override_at,omitempty"`
+	AnalyzedAt     *time.Time  `db:"analyzed_at" json:"analyzed_at,omitempty"`
+	CreatedAt      time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time   `db:"updated_at" json:"updated_at"`
+
+	// 按风险分和人工覆盖得出的处理结果
+	Action     string  `db:"-" json:"action"`     // none, downweight, exclude
+	Multiplier float64 `db:"-" json:"multiplier"` // 积分倍数
+}
+
+// RiskSignal 命中的风险信号
+type RiskSignal struct {
+	Type   string  `json:"type"` // circular, rapid, cluster
+	Score  float64 `json:"score"`
+	Detail string  `json:"detail"`
+}
+
+// RiskSignals 风险信号列表
+type RiskSignals []RiskSignal
+
+// Value 实现 driver.Valuer 接口
+func (s RiskSignals) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (s *RiskSignals) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// AddressRiskAudit 风险覆盖审计
+type AddressRiskAudit struct {
+	ID        int64     `db:"id" json:"id"`
+	ChainName string    `db:"chain_name" json:"chain_name"`
+	Address   string    `db:"address" json:"address"`
+	Override  string    `db:"override" json:"override"`
+	Reason    string    `db:"reason" json:"reason"`
+	Operator  string    `db:"operator" json:"operator"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// TransferEdge 一笔地址间转账（由同一日志的 transfer_out 和 transfer_in 组成）
+type TransferEdge struct {
+	From        string    `db:"from_address" json:"from"`
+	To          string    `db:"to_address" json:"to"`
+	Amount      string    `db:"amount" json:"amount"`
+	BlockNumber int64     `db:"block_number" json:"block_number"`
+	BlockTime   time.Time `db:"block_time" json:"block_time"`
+	TxHash      string    `db:"tx_hash" json:"tx_hash"`
+}
+
+// RiskSignal 类型常量
+const (
+	RiskSignalCircular = "circular"
+	RiskSignalRapid    = "rapid"
+	RiskSignalCluster  = "cluster"
+)
+
+// AddressRisk 人工覆盖常量
+const (
+	RiskOverrideNone    = "none"
+	RiskOverrideClear   = "clear"
+	RiskOverrideFlag    = "flag"
+	RiskOverrideExclude = "exclude"
+)
+
+// AddressRisk 处理结果常量
+const (
+	RiskActionNone       = "none"
+	RiskActionDownweight = "downweight"
+	RiskActionExclude    = "exclude"
+)
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+)
+
+// HoldingStreak 连续持有状态
+type HoldingStreak struct {
+	ID           int64      `db:"id" json:"id"`
+	ChainName    string     `db:"chain_name" json:"chain_name"`
+	UserAddress  string     `db:"user_address" json:"user_address"`
+	HoldingSince *time.Time `db:"holding_since" json:"holding_since"` // 为空表示当前未持有
+	HeldBalance  string     `db:"held_balance" json:"held_balance"`   // 钱包余额 + 质押余额
+	AsOf         time.Time  `db:"as_of" json:"as_of"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+}
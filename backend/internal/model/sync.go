@@ -6,15 +6,23 @@ import (
 
 // SyncState 同步状态模型
 type SyncState struct {
-	ID                 int       `db:"id" json:"id"`
-	ChainName          string    `db:"chain_name" json:"chain_name"`
-	LastSyncedBlock    int64     `db:"last_synced_block" json:"last_synced_block"`
-	LastConfirmedBlock int64     `db:"last_confirmed_block" json:"last_confirmed_block"`
-	LastSyncAt         time.Time `db:"last_sync_at" json:"last_sync_at"`
-	Status             string    `db:"status" json:"status"` // running, stopped, error
-	ErrorMessage       *string   `db:"error_message" json:"error_message,omitempty"`
-	CreatedAt          time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
+	ID                  int        `db:"id" json:"id"`
+	ChainName           string     `db:"chain_name" json:"chain_name"`
+	LastSyncedBlock     int64      `db:"last_synced_block" json:"last_synced_block"`
+	LastConfirmedBlock  int64      `db:"last_confirmed_block" json:"last_confirmed_block"`
+	LastSyncAt          time.Time  `db:"last_sync_at" json:"last_sync_at"`
+	Status              string     `db:"status" json:"status"` // running, stopped, error
+	ErrorMessage        *string    `db:"error_message" json:"error_message,omitempty"`
+	ConsecutiveFailures int        `db:"consecutive_failures" json:"consecutive_failures"`
+	LastErrorAt         *time.Time `db:"last_error_at" json:"last_error_at,omitempty"`
+	LastSuccessAt       *time.Time `db:"last_success_at" json:"last_success_at,omitempty"`
+	ChainHeadBlock      int64      `db:"chain_head_block" json:"chain_head_block"`
+	HeadLagBlocks       int64      `db:"head_lag_blocks" json:"head_lag_blocks"`
+	LastSyncedBlockTime *time.Time `db:"last_synced_block_time" json:"last_synced_block_time,omitempty"`
+	ChainHeadTime       *time.Time `db:"chain_head_time" json:"chain_head_time,omitempty"`
+	EventsProcessed     int64      `db:"events_processed" json:"events_processed"`
+	CreatedAt           time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt           time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 // SyncStatus 同步状态常量
@@ -23,4 +31,3 @@ const (
 	StatusStopped = "stopped"
 	StatusError   = "error"
 )
-
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+)
+
+// PointsTransaction 积分流水
+type PointsTransaction struct {
+	ID           int64     `db:"id" json:"id"`
+	ChainName    string    `db:"chain_name" json:"chain_name"`
+	UserAddress  string    `db:"user_address" json:"user_address"`
+	TxType       string    `db:"tx_type" json:"tx_type"`             // earn, spend, adjust, expire, recalculate
+	Amount       float64   `db:"amount" json:"amount"`               // 正数为增加，负数为减少
+	BalanceAfter float64   `db:"balance_after" json:"balance_after"` // 流水写入后的可用积分
+	ReferenceID  *int64    `db:"reference_id" json:"reference_id,omitempty"`
+	Description  string    `db:"description" json:"description"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// PointsTransaction 类型常量
+const (
+	TxTypeEarn        = "earn"
+	TxTypeSpend       = "spend"
+	TxTypeAdjust      = "adjust"
+	TxTypeExpire      = "expire"
+	TxTypeRecalculate = "recalculate"
+)
+
+// PointsRedemption 积分兑换记录
+type PointsRedemption struct {
+	ID             int64     `db:"id" json:"id"`
+	ChainName      string    `db:"chain_name" json:"chain_name"`
+	UserAddress    string    `db:"user_address" json:"user_address"`
+	Amount         float64   `db:"amount" json:"amount"`
+	Perk           string    `db:"perk" json:"perk"`
+	IdempotencyKey string    `db:"idempotency_key" json:"idempotency_key"`
+	Message        string    `db:"message" json:"-"`
+	Signature      string    `db:"signature" json:"-"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
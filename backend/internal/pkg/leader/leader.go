@@ -0,0 +1,210 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// retryInterval 未当选时重新竞选、当选后续约的间隔
+	retryInterval = 5 * time.Second
+	// releaseTimeout 释放锁的超时时间
+	releaseTimeout = 5 * time.Second
+)
+
+// Lease 领导者记录（锁本身由 advisory lock 保证，记录只用于展示）
+type Lease struct {
+	Name       string    `db:"name" json:"name"`
+	Holder     string    `db:"holder" json:"holder"`
+	AcquiredAt time.Time `db:"acquired_at" json:"acquired_at"`
+	RenewedAt  time.Time `db:"renewed_at" json:"renewed_at"`
+}
+
+// Elector 基于 Postgres advisory lock 的领导者选举
+// 领导者在一个专用连接上持有会话级锁，进程退出或连接断开时锁自动释放，其他实例随后接管
+type Elector struct {
+	db       *sqlx.DB
+	name     string
+	key      int64
+	identity string
+	logger   *logrus.Logger
+
+	mu     sync.RWMutex
+	leader bool
+}
+
+// NewElector 创建领导者选举，name 相同的实例竞争同一把锁
+func NewElector(db *sqlx.DB, name string, logger *logrus.Logger) *Elector {
+	hash := fnv.New64a()
+	hash.Write([]byte("my-token-points:" + name))
+
+	return &Elector{
+		db:       db,
+		name:     name,
+		key:      int64(hash.Sum64()),
+		identity: Identity(),
+		logger:   logger,
+	}
+}
+
+// Identity 当前进程的标识 (hostname:pid)
+func Identity() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
+
+// Name 选举名称
+func (e *Elector) Name() string {
+	return e.name
+}
+
+// Identity 当前实例的标识
+func (e *Elector) Identity() string {
+	return e.identity
+}
+
+// IsLeader 当前实例是否为领导者
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader
+}
+
+// Leader 查询当前领导者记录，从未有实例当选时返回 nil
+func (e *Elector) Leader(ctx context.Context) (*Lease, error) {
+	var lease Lease
+	err := e.db.GetContext(ctx, &lease,
+		`SELECT name, holder, acquired_at, renewed_at FROM leader_leases WHERE name = $1`, e.name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+// Run 竞选并保持领导权，阻塞直到 ctx 被取消
+// 当选后调用 lead，失去领导权或 ctx 被取消时 lead 的 ctx 被取消，lead 返回后才会释放锁
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	for {
+		conn, err := e.tryAcquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			e.logger.Errorf("Failed to campaign for %s leadership: %v", e.name, err)
+		}
+		if conn != nil {
+			e.hold(ctx, conn, lead)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// tryAcquire 尝试获取锁，成功时返回持有锁的连接
+func (e *Elector) tryAcquire(ctx context.Context) (*sql.Conn, error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, e.key).Scan(&acquired); err != nil {
+		discard(conn)
+		return nil, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, nil
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		INSERT INTO leader_leases (name, holder, acquired_at, renewed_at)
+		VALUES ($1, $2, NOW(), NOW())
+		ON CONFLICT (name)
+		DO UPDATE SET holder = EXCLUDED.holder, acquired_at = NOW(), renewed_at = NOW()
+	`, e.name, e.identity)
+	if err != nil {
+		e.release(conn)
+		return nil, fmt.Errorf("failed to record leader: %w", err)
+	}
+	return conn, nil
+}
+
+// hold 执行 lead 并定期续约，直到 ctx 被取消或连接失效
+func (e *Elector) hold(ctx context.Context, conn *sql.Conn, lead func(ctx context.Context)) {
+	e.setLeader(true)
+	e.logger.Infof("Elected as %s leader (%s)", e.name, e.identity)
+
+	leadCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+
+	stepDown := false
+	for !stepDown {
+		select {
+		case <-ctx.Done():
+			stepDown = true
+		case <-done:
+			stepDown = true
+		case <-ticker.C:
+			// 续约同时检查持锁的连接是否仍然可用
+			if _, err := conn.ExecContext(ctx,
+				`UPDATE leader_leases SET renewed_at = NOW() WHERE name = $1 AND holder = $2`,
+				e.name, e.identity); err != nil && ctx.Err() == nil {
+				e.logger.Errorf("Lost %s leadership, failed to renew: %v", e.name, err)
+				stepDown = true
+			}
+		}
+	}
+
+	cancel()
+	<-done
+	e.setLeader(false)
+	e.release(conn)
+	e.logger.Infof("Stepped down as %s leader (%s)", e.name, e.identity)
+}
+
+// release 释放锁并归还连接，释放失败时关闭连接（会话结束后锁自动释放）
+func (e *Elector) release(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, e.key); err != nil {
+		e.logger.Warnf("Failed to release %s leadership lock, closing connection: %v", e.name, err)
+		discard(conn)
+		return
+	}
+	conn.Close()
+}
+
+// setLeader 更新领导者状态
+func (e *Elector) setLeader(leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.leader = leader
+}
+
+// discard 关闭连接而不归还连接池
+func discard(conn *sql.Conn) {
+	conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	conn.Close()
+}
@@ -0,0 +1,46 @@
+package signature
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrSignatureMismatch 签名不是由指定地址生成
+var ErrSignatureMismatch = errors.New("signature does not match address")
+
+// VerifyPersonalSign 验证 EIP-191 (personal_sign) 签名是否由指定地址对 message 签出
+func VerifyPersonalSign(address, message, signatureHex string) error {
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("invalid address: %s", address)
+	}
+
+	sig, err := hexutil.Decode(signatureHex)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("invalid signature length: %d", len(sig))
+	}
+
+	// 钱包返回的 v 为 27/28，go-ethereum 需要 0/1
+	sig = append([]byte(nil), sig...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return fmt.Errorf("failed to recover public key: %w", err)
+	}
+
+	if crypto.PubkeyToAddress(*pub) != common.HexToAddress(address) {
+		return ErrSignatureMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"my-token-points/internal/model"
+)
+
+// AdjustmentRepository 手动积分调整数据访问接口
+type AdjustmentRepository interface {
+	// 创建调整，同时写入积分历史并更新用户总积分
+	CreateAdjustment(ctx context.Context, adjustment *model.PointsAdjustment) error
+
+	// 撤销调整，同时写入反向积分历史并更新用户总积分
+	// 调整不存在时返回 nil，已撤销时返回 false
+	RevertAdjustment(ctx context.Context, id int64, reason, operator string) (*model.PointsAdjustment, bool, error)
+
+	// 按 ID 查询调整
+	GetAdjustment(ctx context.Context, id int64) (*model.PointsAdjustment, error)
+
+	// 分页查询调整记录（chainName/userAddress 为空表示不过滤）
+	ListAdjustments(ctx context.Context, chainName, userAddress string, offset, limit int) ([]*model.PointsAdjustment, error)
+}
+
+// adjustmentRepo 手动积分调整数据访问实现
+type adjustmentRepo struct {
+	db *sqlx.DB
+}
+
+// NewAdjustmentRepository 创建积分调整仓储实例
+func NewAdjustmentRepository(db *sqlx.DB) AdjustmentRepository {
+	return &adjustmentRepo{db: db}
+}
+
+// CreateAdjustment 创建调整
+func (r *adjustmentRepo) CreateAdjustment(ctx context.Context, adjustment *model.PointsAdjustment) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO points_adjustments (chain_name, user_address, amount, reason, operator, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	adjustment.Status = model.AdjustmentStatusApplied
+	err = tx.QueryRowContext(ctx, query,
+		adjustment.ChainName, adjustment.UserAddress, adjustment.Amount,
+		adjustment.Reason, adjustment.Operator, adjustment.Status,
+	).Scan(&adjustment.ID, &adjustment.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := applyAdjustment(ctx, tx, adjustment, adjustment.Amount, adjustment.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevertAdjustment 撤销调整
+func (r *adjustmentRepo) RevertAdjustment(ctx context.Context, id int64, reason, operator string) (*model.PointsAdjustment, bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// 锁定调整记录，防止并发重复撤销
+	var adjustment model.PointsAdjustment
+	err = tx.GetContext(ctx, &adjustment, `
+		SELECT id, chain_name, user_address, amount, reason, operator, status,
+		       reverted_by, revert_reason, reverted_at, created_at
+		FROM points_adjustments
+		WHERE id = $1
+		FOR UPDATE
+	`, id)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if adjustment.Status == model.AdjustmentStatusReverted {
+		return &adjustment, false, nil
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE points_adjustments
+		SET status = $2, reverted_by = $3, revert_reason = $4, reverted_at = NOW()
+		WHERE id = $1
+		RETURNING status, reverted_by, revert_reason, reverted_at
+	`, id, model.AdjustmentStatusReverted, operator, reason).Scan(
+		&adjustment.Status, &adjustment.RevertedBy, &adjustment.RevertReason, &adjustment.RevertedAt,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := applyAdjustment(ctx, tx, &adjustment, -adjustment.Amount, *adjustment.RevertedAt); err != nil {
+		return nil, false, err
+	}
+
+	return &adjustment, true, tx.Commit()
+}
+
+// GetAdjustment 按 ID 查询调整
+func (r *adjustmentRepo) GetAdjustment(ctx context.Context, id int64) (*model.PointsAdjustment, error) {
+	query := `
+		SELECT id, chain_name, user_address, amount, reason, operator, status,
+		       reverted_by, revert_reason, reverted_at, created_at
+		FROM points_adjustments
+		WHERE id = $1
+	`
+
+	var adjustment model.PointsAdjustment
+	err := r.db.GetContext(ctx, &adjustment, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+// ListAdjustments 分页查询调整记录
+func (r *adjustmentRepo) ListAdjustments(ctx context.Context, chainName, userAddress string, offset, limit int) ([]*model.PointsAdjustment, error) {
+	query := `
+		SELECT id, chain_name, user_address, amount, reason, operator, status,
+		       reverted_by, revert_reason, reverted_at, created_at
+		FROM points_adjustments
+		WHERE ($1 = '' OR chain_name = $1)
+		  AND ($2 = '' OR user_address = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	var adjustments []*model.PointsAdjustment
+	if err := r.db.SelectContext(ctx, &adjustments, query, chainName, userAddress, limit, offset); err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
+
+// applyAdjustment 在事务中写入 adjustment 类型的积分历史和流水，并累加用户总积分
+// 发放的积分形成一个不过期的批次，扣回时按先进先出扣减批次
+func applyAdjustment(ctx context.Context, tx *sqlx.Tx, adjustment *model.PointsAdjustment, amount float64, at time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO points_history (
+			chain_name, user_address, calc_period_start, calc_period_end,
+			balance_snapshot, points_earned, calculation_type, adjustment_id, remaining_points
+		)
+		VALUES ($1, $2, $3, $3, '[]', $4, $5, $6, GREATEST($4, 0))
+	`, adjustment.ChainName, adjustment.UserAddress, at, amount, model.CalcTypeAdjustment, adjustment.ID)
+	if err != nil {
+		return err
+	}
+
+	// 直接在数据库中累加，避免与积分计算任务的读-改-写互相覆盖
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_points (chain_name, user_address, total_points)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (chain_name, user_address)
+		DO UPDATE SET
+			total_points = user_points.total_points + EXCLUDED.total_points,
+			updated_at = NOW()
+	`, adjustment.ChainName, adjustment.UserAddress, amount)
+	if err != nil {
+		return err
+	}
+
+	if amount < 0 {
+		if err := consumeAdjustmentLots(ctx, tx, adjustment, -amount); err != nil {
+			return err
+		}
+	}
+
+	description := adjustment.Reason
+	if adjustment.Status == model.AdjustmentStatusReverted {
+		description = "revert: " + *adjustment.RevertReason
+	}
+	return insertPointsTransaction(ctx, tx, &model.PointsTransaction{
+		ChainName:   adjustment.ChainName,
+		UserAddress: adjustment.UserAddress,
+		TxType:      model.TxTypeAdjust,
+		Amount:      amount,
+		ReferenceID: &adjustment.ID,
+		Description: description,
+	})
+}
+
+// consumeAdjustmentLots 扣减批次：撤销发放时优先扣减该调整自身的批次，不足部分按先进先出扣减
+func consumeAdjustmentLots(ctx context.Context, tx *sqlx.Tx, adjustment *model.PointsAdjustment, amount float64) error {
+	if adjustment.Status == model.AdjustmentStatusReverted {
+		var consumed float64
+		err := tx.QueryRowContext(ctx, `
+			WITH lot AS (
+				SELECT id, LEAST(remaining_points, $2) AS consumed
+				FROM points_history
+				WHERE adjustment_id = $1 AND points_earned > 0 AND remaining_points > 0
+			), updated AS (
+				UPDATE points_history p
+				SET remaining_points = p.remaining_points - lot.consumed
+				FROM lot
+				WHERE p.id = lot.id
+			)
+			SELECT COALESCE(SUM(consumed), 0) FROM lot
+		`, adjustment.ID, amount).Scan(&consumed)
+		if err != nil {
+			return err
+		}
+		amount -= consumed
+	}
+
+	return consumePointsLots(ctx, tx, adjustment.ChainName, adjustment.UserAddress, amount)
+}
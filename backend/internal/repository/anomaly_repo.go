@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+
+	"my-token-points/internal/model"
+)
+
+// anomalyColumns balance_anomalies 查询字段
+const anomalyColumns = `
+	id, chain_name, user_address, tx_hash, log_index, block_number, balance_type,
+	balance_before, amount_delta, computed_balance, action, chain_balance, balance_after,
+	occurrences, status, resolved_by, resolution_note, resolved_at, created_at, updated_at
+`
+
+// AnomalyRepository 余额异常数据访问接口
+type AnomalyRepository interface {
+	// 记录余额异常（同一日志重复发生时累加次数并重新打开）
+	RecordAnomaly(ctx context.Context, anomaly *model.BalanceAnomaly) error
+
+	// 查询单个余额异常
+	GetAnomaly(ctx context.Context, id int64) (*model.BalanceAnomaly, error)
+
+	// 按链和状态分页查询余额异常（chainName/status 为空表示不过滤）
+	ListAnomalies(ctx context.Context, chainName, status string, offset, limit int) ([]*model.BalanceAnomaly, error)
+
+	// 标记为已核查，异常不存在或已核查时返回 nil
+	ResolveAnomaly(ctx context.Context, id int64, operator, note string) (*model.BalanceAnomaly, error)
+}
+
+// anomalyRepo 余额异常数据访问实现
+type anomalyRepo struct {
+	db *sqlx.DB
+}
+
+// NewAnomalyRepository 创建余额异常仓储实例
+func NewAnomalyRepository(db *sqlx.DB) AnomalyRepository {
+	return &anomalyRepo{db: db}
+}
+
+// RecordAnomaly 记录余额异常
+func (r *anomalyRepo) RecordAnomaly(ctx context.Context, anomaly *model.BalanceAnomaly) error {
+	query := `
+		INSERT INTO balance_anomalies (
+			chain_name, user_address, tx_hash, log_index, block_number, balance_type,
+			balance_before, amount_delta, computed_balance, action, chain_balance, balance_after
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (chain_name, tx_hash, log_index, user_address, balance_type)
+		DO UPDATE SET
+			balance_before = EXCLUDED.balance_before,
+			computed_balance = EXCLUDED.computed_balance,
+			action = EXCLUDED.action,
+			chain_balance = EXCLUDED.chain_balance,
+			balance_after = EXCLUDED.balance_after,
+			occurrences = balance_anomalies.occurrences + 1,
+			status = 'open',
+			resolved_by = NULL,
+			resolution_note = NULL,
+			resolved_at = NULL,
+			updated_at = NOW()
+		RETURNING id, occurrences, status, created_at, updated_at
+	`
+
+	return r.db.QueryRowContext(
+		ctx, query,
+		anomaly.ChainName, anomaly.UserAddress, anomaly.TxHash, anomaly.LogIndex, anomaly.BlockNumber,
+		anomaly.BalanceType, anomaly.BalanceBefore, anomaly.AmountDelta, anomaly.ComputedBalance,
+		anomaly.Action, anomaly.ChainBalance, anomaly.BalanceAfter,
+	).Scan(&anomaly.ID, &anomaly.Occurrences, &anomaly.Status, &anomaly.CreatedAt, &anomaly.UpdatedAt)
+}
+
+// GetAnomaly 查询单个余额异常
+func (r *anomalyRepo) GetAnomaly(ctx context.Context, id int64) (*model.BalanceAnomaly, error) {
+	var anomaly model.BalanceAnomaly
+	err := r.db.GetContext(ctx, &anomaly, `SELECT `+anomalyColumns+` FROM balance_anomalies WHERE id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &anomaly, nil
+}
+
+// ListAnomalies 分页查询余额异常
+func (r *anomalyRepo) ListAnomalies(ctx context.Context, chainName, status string, offset, limit int) ([]*model.BalanceAnomaly, error) {
+	query := `SELECT ` + anomalyColumns + `
+		FROM balance_anomalies
+		WHERE ($1 = '' OR chain_name = $1)
+		  AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	var anomalies []*model.BalanceAnomaly
+	if err := r.db.SelectContext(ctx, &anomalies, query, chainName, status, limit, offset); err != nil {
+		return nil, err
+	}
+	return anomalies, nil
+}
+
+// ResolveAnomaly 标记余额异常为已核查
+func (r *anomalyRepo) ResolveAnomaly(ctx context.Context, id int64, operator, note string) (*model.BalanceAnomaly, error) {
+	query := `
+		UPDATE balance_anomalies
+		SET status = 'resolved', resolved_by = $2, resolution_note = NULLIF($3, ''),
+		    resolved_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'open'
+		RETURNING ` + anomalyColumns
+
+	var anomaly model.BalanceAnomaly
+	err := r.db.GetContext(ctx, &anomaly, query, id, operator, note)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &anomaly, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"sort"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -22,6 +23,11 @@ type BalanceRepository interface {
 	
 	// 记录余额变动
 	RecordBalanceChange(ctx context.Context, change *model.BalanceChange) error
+
+	// 在一个事务中锁定涉及的用户余额行（不存在时按零余额创建），依次由 apply 根据锁定的余额填写第 i 个变动的
+	// BalanceBefore/BalanceAfter，然后写入变动记录并更新对应类型的余额；任一变动失败时全部回滚
+	// 同一条日志的变动已写入时（重复投递）不调用 apply、不更新余额，返回 false
+	ApplyBalanceChanges(ctx context.Context, changes []*model.BalanceChange, apply func(i int, current *model.UserBalance) error) (bool, error)
 	
 	// 查询余额变动历史
 	GetBalanceChanges(ctx context.Context, chainName, userAddress string, startTime, endTime time.Time) ([]*model.BalanceChange, error)
@@ -34,6 +40,9 @@ type BalanceRepository interface {
 	
 	// 查询某个区块之后的所有余额变动（用于余额重建）
 	GetChangesFromBlock(ctx context.Context, chainName string, fromBlock int64) ([]*model.BalanceChange, error)
+
+	// 清空链上所有余额及余额变动（用于从归档重建）
+	DeleteChainBalances(ctx context.Context, chainName string) error
 }
 
 // balanceRepo 余额数据访问实现
@@ -49,7 +58,7 @@ func NewBalanceRepository(db *sqlx.DB) BalanceRepository {
 // GetUserBalance 查询用户余额
 func (r *balanceRepo) GetUserBalance(ctx context.Context, chainName, userAddress string) (*model.UserBalance, error) {
 	query := `
-		SELECT id, chain_name, user_address, balance, last_update_block, last_update_time, created_at, updated_at
+		SELECT id, chain_name, user_address, balance, staked_balance, last_update_block, last_update_time, created_at, updated_at
 		FROM user_balances
 		WHERE chain_name = $1 AND user_address = $2
 	`
@@ -69,10 +78,10 @@ func (r *balanceRepo) GetUserBalance(ctx context.Context, chainName, userAddress
 // GetUserBalances 批量查询用户余额
 func (r *balanceRepo) GetUserBalances(ctx context.Context, chainName string, offset, limit int) ([]*model.UserBalance, error) {
 	query := `
-		SELECT id, chain_name, user_address, balance, last_update_block, last_update_time, created_at, updated_at
+		SELECT id, chain_name, user_address, balance, staked_balance, last_update_block, last_update_time, created_at, updated_at
 		FROM user_balances
 		WHERE chain_name = $1
-		ORDER BY balance DESC, user_address ASC
+		ORDER BY balance + staked_balance DESC, user_address ASC
 		LIMIT $2 OFFSET $3
 	`
 	
@@ -88,11 +97,12 @@ func (r *balanceRepo) GetUserBalances(ctx context.Context, chainName string, off
 // UpsertUserBalance 更新或创建用户余额
 func (r *balanceRepo) UpsertUserBalance(ctx context.Context, balance *model.UserBalance) error {
 	query := `
-		INSERT INTO user_balances (chain_name, user_address, balance, last_update_block, last_update_time)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO user_balances (chain_name, user_address, balance, staked_balance, last_update_block, last_update_time)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (chain_name, user_address)
 		DO UPDATE SET
 			balance = EXCLUDED.balance,
+			staked_balance = EXCLUDED.staked_balance,
 			last_update_block = EXCLUDED.last_update_block,
 			last_update_time = EXCLUDED.last_update_time,
 			updated_at = NOW()
@@ -101,26 +111,135 @@ func (r *balanceRepo) UpsertUserBalance(ctx context.Context, balance *model.User
 	
 	return r.db.QueryRowContext(
 		ctx, query,
-		balance.ChainName, balance.UserAddress, balance.Balance,
+		balance.ChainName, balance.UserAddress, balance.Balance, balance.StakedBalance,
 		balance.LastUpdateBlock, balance.LastUpdateTime,
 	).Scan(&balance.ID, &balance.CreatedAt, &balance.UpdatedAt)
 }
 
 // RecordBalanceChange 记录余额变动
 func (r *balanceRepo) RecordBalanceChange(ctx context.Context, change *model.BalanceChange) error {
+	return insertBalanceChange(ctx, r.db, change)
+}
+
+// ApplyBalanceChanges 在行锁保护下记录一组余额变动（同一条日志的全部变动）并更新余额，全部成功或全部回滚
+// 同步游标未推进时同一批日志会被重新扫描，已写入的日志直接跳过，返回 false
+func (r *balanceRepo) ApplyBalanceChanges(ctx context.Context, changes []*model.BalanceChange, apply func(i int, current *model.UserBalance) error) (bool, error) {
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// 按地址顺序锁定涉及的余额行，相向转账的并发事务不会互相死锁
+	users := make([]string, 0, len(changes))
+	for _, change := range changes {
+		users = append(users, change.UserAddress)
+	}
+	sort.Strings(users)
+
+	balances := make(map[string]*model.UserBalance, len(users))
+	for _, user := range users {
+		if _, ok := balances[user]; ok {
+			continue
+		}
+
+		// 先确保行存在，新用户的并发写入也能在同一行上串行
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_balances (chain_name, user_address, balance, staked_balance, last_update_block, last_update_time)
+			VALUES ($1, $2, 0, 0, $3, $4)
+			ON CONFLICT (chain_name, user_address) DO NOTHING
+		`, changes[0].ChainName, user, changes[0].BlockNumber, changes[0].BlockTime); err != nil {
+			return false, err
+		}
+
+		var current model.UserBalance
+		if err := tx.GetContext(ctx, &current, `
+			SELECT id, chain_name, user_address, balance, staked_balance,
+			       COALESCE(last_update_block, 0) AS last_update_block,
+			       COALESCE(last_update_time, created_at) AS last_update_time,
+			       created_at, updated_at
+			FROM user_balances
+			WHERE chain_name = $1 AND user_address = $2
+			FOR UPDATE
+		`, changes[0].ChainName, user); err != nil {
+			return false, err
+		}
+		balances[user] = &current
+	}
+
+	// 同一条日志的变动全部写入或全部回滚，并且写入时持有相同的行锁，查到任意一条即说明已处理
+	var recorded bool
+	if err := tx.GetContext(ctx, &recorded, `
+		SELECT EXISTS (
+			SELECT 1 FROM balance_changes
+			WHERE chain_name = $1 AND tx_hash = $2 AND log_index = $3
+		)
+	`, changes[0].ChainName, changes[0].TxHash, changes[0].LogIndex); err != nil {
+		return false, err
+	}
+	if recorded {
+		return false, nil
+	}
+
+	for i, change := range changes {
+		current := balances[change.UserAddress]
+		if err := apply(i, current); err != nil {
+			return false, err
+		}
+
+		if err := insertBalanceChange(ctx, tx, change); err != nil {
+			if err == sql.ErrNoRows {
+				return false, nil
+			}
+			return false, err
+		}
+
+		column := "balance"
+		if change.BalanceType == model.BalanceTypeStaked {
+			column = "staked_balance"
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE user_balances
+			SET `+column+` = $2, last_update_block = $3, last_update_time = $4, updated_at = NOW()
+			WHERE id = $1
+		`, current.ID, change.BalanceAfter, change.BlockNumber, change.BlockTime); err != nil {
+			return false, err
+		}
+
+		// 同一用户的后续变动基于本次变动后的余额
+		if change.BalanceType == model.BalanceTypeStaked {
+			current.StakedBalance = change.BalanceAfter
+		} else {
+			current.Balance = change.BalanceAfter
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// insertBalanceChange 写入余额变动记录，同一日志、用户和余额类型的变动已存在时不写入，返回 sql.ErrNoRows
+func insertBalanceChange(ctx context.Context, q sqlx.QueryerContext, change *model.BalanceChange) error {
 	query := `
 		INSERT INTO balance_changes (
-			chain_name, user_address, tx_hash, block_number, block_time,
-			event_type, amount_delta, balance_before, balance_after, confirmed
+			chain_name, user_address, tx_hash, log_index, contract_address, block_number, block_time,
+			event_type, balance_type, amount_delta, balance_before, balance_after, confirmed
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (chain_name, tx_hash, log_index, user_address, balance_type) DO NOTHING
 		RETURNING id, created_at
 	`
 	
-	return r.db.QueryRowContext(
+	return q.QueryRowxContext(
 		ctx, query,
-		change.ChainName, change.UserAddress, change.TxHash,
-		change.BlockNumber, change.BlockTime, change.EventType,
+		change.ChainName, change.UserAddress, change.TxHash, change.LogIndex, change.ContractAddress,
+		change.BlockNumber, change.BlockTime, change.EventType, change.BalanceType,
 		change.AmountDelta, change.BalanceBefore, change.BalanceAfter, change.Confirmed,
 	).Scan(&change.ID, &change.CreatedAt)
 }
@@ -128,13 +247,13 @@ func (r *balanceRepo) RecordBalanceChange(ctx context.Context, change *model.Bal
 // GetBalanceChanges 查询余额变动历史
 func (r *balanceRepo) GetBalanceChanges(ctx context.Context, chainName, userAddress string, startTime, endTime time.Time) ([]*model.BalanceChange, error) {
 	query := `
-		SELECT id, chain_name, user_address, tx_hash, block_number, block_time,
-			   event_type, amount_delta, balance_before, balance_after, confirmed, created_at
+		SELECT id, chain_name, user_address, tx_hash, log_index, contract_address, block_number, block_time,
+			   event_type, balance_type, amount_delta, balance_before, balance_after, confirmed, created_at
 		FROM balance_changes
 		WHERE chain_name = $1 AND user_address = $2 
 		  AND block_time >= $3 AND block_time < $4
 		  AND confirmed = true
-		ORDER BY block_number ASC, id ASC
+		ORDER BY block_number ASC, log_index ASC, id ASC
 	`
 	
 	var changes []*model.BalanceChange
@@ -149,11 +268,11 @@ func (r *balanceRepo) GetBalanceChanges(ctx context.Context, chainName, userAddr
 // GetUnconfirmedChanges 查询待确认的余额变动
 func (r *balanceRepo) GetUnconfirmedChanges(ctx context.Context, chainName string, beforeBlock int64) ([]*model.BalanceChange, error) {
 	query := `
-		SELECT id, chain_name, user_address, tx_hash, block_number, block_time,
-			   event_type, amount_delta, balance_before, balance_after, confirmed, created_at
+		SELECT id, chain_name, user_address, tx_hash, log_index, contract_address, block_number, block_time,
+			   event_type, balance_type, amount_delta, balance_before, balance_after, confirmed, created_at
 		FROM balance_changes
 		WHERE chain_name = $1 AND confirmed = false AND block_number <= $2
-		ORDER BY block_number ASC, id ASC
+		ORDER BY block_number ASC, log_index ASC, id ASC
 	`
 	
 	var changes []*model.BalanceChange
@@ -180,11 +299,11 @@ func (r *balanceRepo) ConfirmBalanceChange(ctx context.Context, chainName, txHas
 // GetChangesFromBlock 查询某个区块之后的所有余额变动
 func (r *balanceRepo) GetChangesFromBlock(ctx context.Context, chainName string, fromBlock int64) ([]*model.BalanceChange, error) {
 	query := `
-		SELECT id, chain_name, user_address, tx_hash, block_number, block_time,
-			   event_type, amount_delta, balance_before, balance_after, confirmed, created_at
+		SELECT id, chain_name, user_address, tx_hash, log_index, contract_address, block_number, block_time,
+			   event_type, balance_type, amount_delta, balance_before, balance_after, confirmed, created_at
 		FROM balance_changes
 		WHERE chain_name = $1 AND block_number >= $2
-		ORDER BY block_number ASC, id ASC
+		ORDER BY block_number ASC, log_index ASC, id ASC
 	`
 	
 	var changes []*model.BalanceChange
@@ -196,3 +315,20 @@ func (r *balanceRepo) GetChangesFromBlock(ctx context.Context, chainName string,
 	return changes, nil
 }
 
+// DeleteChainBalances 清空链上所有余额及余额变动
+func (r *balanceRepo) DeleteChainBalances(ctx context.Context, chainName string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM balance_changes WHERE chain_name = $1`, chainName); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_balances WHERE chain_name = $1`, chainName); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
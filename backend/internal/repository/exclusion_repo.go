@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+
+	"my-token-points/internal/model"
+)
+
+// ExclusionRepository 积分排除地址数据访问接口
+type ExclusionRepository interface {
+	// 查询排除地址（chainName 非空时返回该链和所有链通用的地址）
+	ListExcludedAddresses(ctx context.Context, chainName string) ([]*model.ExcludedAddress, error)
+
+	// 添加排除地址并记录审计，地址已存在时返回 false
+	AddExcludedAddress(ctx context.Context, address *model.ExcludedAddress) (bool, error)
+
+	// 移除排除地址并记录审计，地址不存在时返回 false
+	RemoveExcludedAddress(ctx context.Context, chainName, address, reason, operator string) (bool, error)
+
+	// 分页查询审计记录（chainName/address 为空表示不过滤）
+	ListExclusionAudit(ctx context.Context, chainName, address string, offset, limit int) ([]*model.ExcludedAddressAudit, error)
+}
+
+// exclusionRepo 积分排除地址数据访问实现
+type exclusionRepo struct {
+	db *sqlx.DB
+}
+
+// NewExclusionRepository 创建排除地址仓储实例
+func NewExclusionRepository(db *sqlx.DB) ExclusionRepository {
+	return &exclusionRepo{db: db}
+}
+
+// ListExcludedAddresses 查询排除地址
+func (r *exclusionRepo) ListExcludedAddresses(ctx context.Context, chainName string) ([]*model.ExcludedAddress, error) {
+	query := `
+		SELECT id, chain_name, address, reason, created_by, created_at
+		FROM excluded_addresses
+		WHERE ($1 = '' OR chain_name IN ('', $1))
+		ORDER BY chain_name ASC, address ASC
+	`
+
+	var addresses []*model.ExcludedAddress
+	if err := r.db.SelectContext(ctx, &addresses, query, chainName); err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+// AddExcludedAddress 添加排除地址并记录审计
+func (r *exclusionRepo) AddExcludedAddress(ctx context.Context, address *model.ExcludedAddress) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO excluded_addresses (chain_name, address, reason, created_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chain_name, address) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRowContext(ctx, query,
+		address.ChainName, address.Address, address.Reason, address.CreatedBy,
+	).Scan(&address.ID, &address.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := insertExclusionAudit(ctx, tx, address.ChainName, address.Address,
+		model.ExclusionActionAdd, address.Reason, address.CreatedBy); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// RemoveExcludedAddress 移除排除地址并记录审计
+func (r *exclusionRepo) RemoveExcludedAddress(ctx context.Context, chainName, address, reason, operator string) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`DELETE FROM excluded_addresses WHERE chain_name = $1 AND address = $2`,
+		chainName, address)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if err := insertExclusionAudit(ctx, tx, chainName, address,
+		model.ExclusionActionRemove, reason, operator); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// ListExclusionAudit 分页查询审计记录
+func (r *exclusionRepo) ListExclusionAudit(ctx context.Context, chainName, address string, offset, limit int) ([]*model.ExcludedAddressAudit, error) {
+	query := `
+		SELECT id, chain_name, address, action, reason, operator, created_at
+		FROM excluded_address_audit
+		WHERE ($1 = '' OR chain_name = $1)
+		  AND ($2 = '' OR address = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	var audits []*model.ExcludedAddressAudit
+	if err := r.db.SelectContext(ctx, &audits, query, chainName, address, limit, offset); err != nil {
+		return nil, err
+	}
+
+	return audits, nil
+}
+
+// insertExclusionAudit 在事务中写入审计记录
+func insertExclusionAudit(ctx context.Context, tx *sqlx.Tx, chainName, address, action, reason, operator string) error {
+	query := `
+		INSERT INTO excluded_address_audit (chain_name, address, action, reason, operator)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := tx.ExecContext(ctx, query, chainName, address, action, reason, operator)
+	return err
+}
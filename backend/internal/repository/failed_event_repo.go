@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"my-token-points/internal/model"
+)
+
+// FailedEventRepository 死信数据访问接口
+type FailedEventRepository interface {
+	// 记录处理失败的事件（重复记录时累加尝试次数）
+	RecordFailedEvent(ctx context.Context, event *model.FailedEvent) error
+
+	// 查询单个死信
+	GetFailedEvent(ctx context.Context, id int64) (*model.FailedEvent, error)
+
+	// 按链和状态分页查询死信（chainName/status 为空表示不过滤）
+	ListFailedEvents(ctx context.Context, chainName, status string, offset, limit int) ([]*model.FailedEvent, error)
+
+	// 查询到期需要重试的死信
+	GetRetryableEvents(ctx context.Context, chainName string, limit int) ([]*model.FailedEvent, error)
+
+	// 记录一次重试失败
+	MarkRetryFailed(ctx context.Context, id int64, errMsg, status string, nextRetryAt *time.Time) error
+
+	// 标记为已处理
+	MarkResolved(ctx context.Context, id int64) error
+
+	// 重新放入重试队列（立即重试）
+	RequeueFailedEvent(ctx context.Context, id int64) (bool, error)
+
+	// 丢弃死信
+	DiscardFailedEvent(ctx context.Context, id int64) (bool, error)
+
+	// 丢弃链上所有未处理的死信（从归档重建前调用，重建时会重新处理这些日志）
+	DiscardChainFailedEvents(ctx context.Context, chainName string) (int64, error)
+}
+
+// failedEventRepo 死信数据访问实现
+type failedEventRepo struct {
+	db *sqlx.DB
+}
+
+// NewFailedEventRepository 创建死信仓储实例
+func NewFailedEventRepository(db *sqlx.DB) FailedEventRepository {
+	return &failedEventRepo{db: db}
+}
+
+// failedEventColumns failed_events 查询字段
+const failedEventColumns = `
+	id, chain_name, contract_address, tx_hash, tx_index, block_number, block_hash, log_index,
+	topics, data, error_message, attempts, status, next_retry_at, resolved_at, created_at, updated_at
+`
+
+// RecordFailedEvent 记录处理失败的事件
+func (r *failedEventRepo) RecordFailedEvent(ctx context.Context, event *model.FailedEvent) error {
+	query := `
+		INSERT INTO failed_events (
+			chain_name, contract_address, tx_hash, tx_index, block_number, block_hash, log_index,
+			topics, data, error_message, attempts, status, next_retry_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, 1, $11, $12)
+		ON CONFLICT (chain_name, tx_hash, log_index)
+		DO UPDATE SET
+			error_message = EXCLUDED.error_message,
+			attempts = failed_events.attempts + 1,
+			status = EXCLUDED.status,
+			next_retry_at = EXCLUDED.next_retry_at,
+			resolved_at = NULL,
+			updated_at = NOW()
+		RETURNING id, attempts, created_at, updated_at
+	`
+
+	return r.db.QueryRowContext(
+		ctx, query,
+		event.ChainName, event.ContractAddress, event.TxHash, event.TxIndex,
+		event.BlockNumber, event.BlockHash, event.LogIndex,
+		event.Topics, event.Data, event.ErrorMessage, event.Status, event.NextRetryAt,
+	).Scan(&event.ID, &event.Attempts, &event.CreatedAt, &event.UpdatedAt)
+}
+
+// GetFailedEvent 查询单个死信
+func (r *failedEventRepo) GetFailedEvent(ctx context.Context, id int64) (*model.FailedEvent, error) {
+	query := `SELECT ` + failedEventColumns + ` FROM failed_events WHERE id = $1`
+
+	var event model.FailedEvent
+	err := r.db.GetContext(ctx, &event, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// ListFailedEvents 分页查询死信
+func (r *failedEventRepo) ListFailedEvents(ctx context.Context, chainName, status string, offset, limit int) ([]*model.FailedEvent, error) {
+	query := `SELECT ` + failedEventColumns + `
+		FROM failed_events
+		WHERE ($1 = '' OR chain_name = $1)
+		  AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	var events []*model.FailedEvent
+	err := r.db.SelectContext(ctx, &events, query, chainName, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetRetryableEvents 查询到期需要重试的死信（按链上顺序）
+func (r *failedEventRepo) GetRetryableEvents(ctx context.Context, chainName string, limit int) ([]*model.FailedEvent, error) {
+	query := `SELECT ` + failedEventColumns + `
+		FROM failed_events
+		WHERE chain_name = $1
+		  AND status = $2
+		  AND (next_retry_at IS NULL OR next_retry_at <= NOW())
+		ORDER BY block_number ASC, log_index ASC
+		LIMIT $3
+	`
+
+	var events []*model.FailedEvent
+	err := r.db.SelectContext(ctx, &events, query, chainName, model.FailedEventPending, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// MarkRetryFailed 记录一次重试失败
+func (r *failedEventRepo) MarkRetryFailed(ctx context.Context, id int64, errMsg, status string, nextRetryAt *time.Time) error {
+	query := `
+		UPDATE failed_events
+		SET error_message = $1,
+			attempts = attempts + 1,
+			status = $2,
+			next_retry_at = $3,
+			updated_at = NOW()
+		WHERE id = $4
+	`
+
+	_, err := r.db.ExecContext(ctx, query, errMsg, status, nextRetryAt, id)
+	return err
+}
+
+// MarkResolved 标记为已处理
+func (r *failedEventRepo) MarkResolved(ctx context.Context, id int64) error {
+	query := `
+		UPDATE failed_events
+		SET status = $1,
+			next_retry_at = NULL,
+			resolved_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $2
+	`
+
+	_, err := r.db.ExecContext(ctx, query, model.FailedEventResolved, id)
+	return err
+}
+
+// RequeueFailedEvent 重新放入重试队列，已处理的死信不受影响
+func (r *failedEventRepo) RequeueFailedEvent(ctx context.Context, id int64) (bool, error) {
+	query := `
+		UPDATE failed_events
+		SET status = $1,
+			next_retry_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $2 AND status <> $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, model.FailedEventPending, id, model.FailedEventResolved)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// DiscardFailedEvent 丢弃死信，已处理的死信不受影响
+func (r *failedEventRepo) DiscardFailedEvent(ctx context.Context, id int64) (bool, error) {
+	query := `
+		UPDATE failed_events
+		SET status = $1,
+			next_retry_at = NULL,
+			updated_at = NOW()
+		WHERE id = $2 AND status <> $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, model.FailedEventDiscarded, id, model.FailedEventResolved)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// DiscardChainFailedEvents 丢弃链上所有未处理的死信
+func (r *failedEventRepo) DiscardChainFailedEvents(ctx context.Context, chainName string) (int64, error) {
+	query := `
+		UPDATE failed_events
+		SET status = $1,
+			next_retry_at = NULL,
+			updated_at = NOW()
+		WHERE chain_name = $2 AND status IN ($3, $4)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		model.FailedEventDiscarded, chainName, model.FailedEventPending, model.FailedEventExhausted)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"my-token-points/internal/model"
+)
+
+// jobColumns 任务表查询列
+const jobColumns = `id, job_type, chain_name, params, status, progress_done, progress_total, cursor_time,
+	cancel_requested, error_message, created_by, worker_id, heartbeat_at,
+	created_at, started_at, finished_at, updated_at`
+
+// JobRepository 后台任务数据访问接口
+type JobRepository interface {
+	// 创建任务，同一条链已有未结束的任务时返回 false
+	// 状态为 running 的任务直接由 job.WorkerID 认领
+	CreateJob(ctx context.Context, job *model.Job) (bool, error)
+
+	// 按 ID 查询任务
+	GetJob(ctx context.Context, id int64) (*model.Job, error)
+
+	// 查询链上未结束的任务
+	GetActiveJob(ctx context.Context, chainName string) (*model.Job, error)
+
+	// 分页查询任务（chainName、status 为空表示不过滤）
+	ListJobs(ctx context.Context, chainName, status string, offset, limit int) ([]*model.Job, error)
+
+	// 查询可认领的任务：待执行，或执行中但心跳早于 staleBefore（执行进程已退出）
+	ListClaimableJobs(ctx context.Context, staleBefore time.Time, limit int) ([]*model.Job, error)
+
+	// 认领任务，已被其他进程认领时返回 false
+	ClaimJob(ctx context.Context, id int64, workerID string, staleBefore time.Time) (bool, error)
+
+	// 更新进度并刷新心跳，任务已不属于该进程时返回 false
+	UpdateProgress(ctx context.Context, id int64, workerID string, done, total int, cursor *time.Time) (bool, error)
+
+	// 更新任务参数
+	UpdateParams(ctx context.Context, id int64, workerID string, params model.JobParams) error
+
+	// 刷新心跳，返回任务是否仍属于该进程以及是否已请求取消
+	Heartbeat(ctx context.Context, id int64, workerID string) (owned bool, cancelRequested bool, err error)
+
+	// 结束任务（completed、failed 或 cancelled）
+	FinishJob(ctx context.Context, id int64, workerID, status string, errorMessage *string) error
+
+	// 释放任务（进程停止时），任务回到 pending 状态等待续跑
+	ReleaseJob(ctx context.Context, id int64, workerID string) error
+
+	// 请求取消任务：待执行的任务直接取消，执行中的任务由执行进程在下一个周期前停止
+	// 任务不存在或已结束时返回 nil
+	RequestCancel(ctx context.Context, id int64) (*model.Job, error)
+}
+
+// jobRepo 后台任务数据访问实现
+type jobRepo struct {
+	db *sqlx.DB
+}
+
+// NewJobRepository 创建后台任务仓储实例
+func NewJobRepository(db *sqlx.DB) JobRepository {
+	return &jobRepo{db: db}
+}
+
+// CreateJob 创建任务
+func (r *jobRepo) CreateJob(ctx context.Context, job *model.Job) (bool, error) {
+	query := `
+		INSERT INTO jobs (job_type, chain_name, params, status, created_by, worker_id, heartbeat_at, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6,
+		        CASE WHEN $4 = 'running' THEN NOW() END,
+		        CASE WHEN $4 = 'running' THEN NOW() END)
+		ON CONFLICT (chain_name) WHERE status IN ('pending', 'running') DO NOTHING
+		RETURNING id, heartbeat_at, created_at, started_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		job.JobType, job.ChainName, job.Params, job.Status, job.CreatedBy, job.WorkerID,
+	).Scan(&job.ID, &job.HeartbeatAt, &job.CreatedAt, &job.StartedAt, &job.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetJob 按 ID 查询任务
+func (r *jobRepo) GetJob(ctx context.Context, id int64) (*model.Job, error) {
+	var job model.Job
+	err := r.db.GetContext(ctx, &job, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetActiveJob 查询链上未结束的任务
+func (r *jobRepo) GetActiveJob(ctx context.Context, chainName string) (*model.Job, error) {
+	var job model.Job
+	err := r.db.GetContext(ctx, &job, `
+		SELECT `+jobColumns+` FROM jobs
+		WHERE chain_name = $1 AND status IN ('pending', 'running')
+	`, chainName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobs 分页查询任务
+func (r *jobRepo) ListJobs(ctx context.Context, chainName, status string, offset, limit int) ([]*model.Job, error) {
+	query := `
+		SELECT ` + jobColumns + ` FROM jobs
+		WHERE ($1 = '' OR chain_name = $1)
+		  AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		OFFSET $3 LIMIT $4
+	`
+
+	var jobs []*model.Job
+	if err := r.db.SelectContext(ctx, &jobs, query, chainName, status, offset, limit); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ListClaimableJobs 查询可认领的任务
+func (r *jobRepo) ListClaimableJobs(ctx context.Context, staleBefore time.Time, limit int) ([]*model.Job, error) {
+	query := `
+		SELECT ` + jobColumns + ` FROM jobs
+		WHERE status = 'pending'
+		   OR (status = 'running' AND (heartbeat_at IS NULL OR heartbeat_at < $1))
+		ORDER BY id
+		LIMIT $2
+	`
+
+	var jobs []*model.Job
+	if err := r.db.SelectContext(ctx, &jobs, query, staleBefore, limit); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ClaimJob 认领任务
+func (r *jobRepo) ClaimJob(ctx context.Context, id int64, workerID string, staleBefore time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = 'running', worker_id = $2, heartbeat_at = NOW(),
+		    started_at = COALESCE(started_at, NOW()), updated_at = NOW()
+		WHERE id = $1
+		  AND (status = 'pending'
+		       OR (status = 'running' AND (heartbeat_at IS NULL OR heartbeat_at < $3)))
+	`, id, workerID, staleBefore)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UpdateProgress 更新进度并刷新心跳
+func (r *jobRepo) UpdateProgress(ctx context.Context, id int64, workerID string, done, total int, cursor *time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET progress_done = $3, progress_total = $4, cursor_time = COALESCE($5, cursor_time),
+		    heartbeat_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND worker_id = $2 AND status = 'running'
+	`, id, workerID, done, total, cursor)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UpdateParams 更新任务参数
+func (r *jobRepo) UpdateParams(ctx context.Context, id int64, workerID string, params model.JobParams) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET params = $3, updated_at = NOW()
+		WHERE id = $1 AND worker_id = $2 AND status = 'running'
+	`, id, workerID, params)
+	return err
+}
+
+// Heartbeat 刷新心跳
+func (r *jobRepo) Heartbeat(ctx context.Context, id int64, workerID string) (bool, bool, error) {
+	var cancelRequested bool
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE jobs SET heartbeat_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND worker_id = $2 AND status = 'running'
+		RETURNING cancel_requested
+	`, id, workerID).Scan(&cancelRequested)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, cancelRequested, nil
+}
+
+// FinishJob 结束任务
+func (r *jobRepo) FinishJob(ctx context.Context, id int64, workerID, status string, errorMessage *string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = $3, error_message = $4, finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND worker_id = $2 AND status = 'running'
+	`, id, workerID, status, errorMessage)
+	return err
+}
+
+// ReleaseJob 释放任务
+func (r *jobRepo) ReleaseJob(ctx context.Context, id int64, workerID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = 'pending', worker_id = NULL, heartbeat_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND worker_id = $2 AND status = 'running'
+	`, id, workerID)
+	return err
+}
+
+// RequestCancel 请求取消任务
+func (r *jobRepo) RequestCancel(ctx context.Context, id int64) (*model.Job, error) {
+	query := `
+		UPDATE jobs
+		SET cancel_requested = TRUE,
+		    status = CASE WHEN status = 'pending' THEN 'cancelled' ELSE status END,
+		    finished_at = CASE WHEN status = 'pending' THEN NOW() ELSE finished_at END,
+		    updated_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'running')
+		RETURNING ` + jobColumns
+
+	var job model.Job
+	err := r.db.GetContext(ctx, &job, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"my-token-points/internal/model"
 )
 
@@ -14,14 +15,12 @@ type PointsRepository interface {
 	// 查询用户积分
 	GetUserPoints(ctx context.Context, chainName, userAddress string) (*model.UserPoints, error)
 	
-	// 批量查询用户积分
-	GetUserPointsList(ctx context.Context, chainName string, offset, limit int) ([]*model.UserPoints, error)
+	// 批量查询用户积分（按积分降序，排除指定地址）
+	GetUserPointsList(ctx context.Context, chainName string, excludedAddresses []string, offset, limit int) ([]*model.UserPoints, error)
 	
-	// 更新或创建用户积分
-	UpsertUserPoints(ctx context.Context, points *model.UserPoints) error
-	
-	// 记录积分计算历史
-	RecordPointsHistory(ctx context.Context, history *model.PointsHistory) error
+	// 在一个事务中写入积分历史（批次）、累加用户总积分并记录 earn 流水，保证可用积分与批次剩余积分一致
+	// 同一周期已写入时跳过并返回 false
+	RecordEarnedPoints(ctx context.Context, history *model.PointsHistory) (bool, error)
 	
 	// 查询积分历史
 	GetPointsHistory(ctx context.Context, chainName, userAddress string, startTime, endTime time.Time) ([]*model.PointsHistory, error)
@@ -29,8 +28,35 @@ type PointsRepository interface {
 	// 获取最后一次计算的时间
 	GetLastCalculationTime(ctx context.Context, chainName string) (*time.Time, error)
 	
-	// 查询需要计算积分的时间区间（未计算的小时）
-	GetUncalculatedPeriods(ctx context.Context, chainName string, fromTime, toTime time.Time) ([]time.Time, error)
+	// 查询需要计算积分的时间区间（按 period 划分，返回未计算周期的开始时间）
+	GetUncalculatedPeriods(ctx context.Context, chainName string, fromTime, toTime time.Time, period time.Duration) ([]time.Time, error)
+
+	// 记录积分流水（balance_after 按当前可用积分填充）
+	RecordTransaction(ctx context.Context, transaction *model.PointsTransaction) error
+
+	// 分页查询用户积分流水（按时间倒序，txType 为空表示不过滤）
+	ListTransactions(ctx context.Context, chainName, userAddress, txType string, offset, limit int) ([]*model.PointsTransaction, error)
+
+	// 查询在 asOf 之前有过期批次的用户
+	ListUsersWithExpiredLots(ctx context.Context, chainName string, asOf time.Time) ([]string, error)
+
+	// 过期用户在 asOf 之前到期的批次，更新已过期积分并记录 expire 流水，返回过期积分
+	ExpireUserLots(ctx context.Context, chainName, userAddress string, asOf time.Time) (float64, error)
+
+	// 查询 [from, to] 内将要过期的积分和最近的过期时间
+	GetExpiringPoints(ctx context.Context, chainName, userAddress string, from, to time.Time) (float64, *time.Time, error)
+
+	// 查询用户连续持有状态
+	GetHoldingStreak(ctx context.Context, chainName, userAddress string) (*model.HoldingStreak, error)
+
+	// 更新或创建用户连续持有状态
+	UpsertHoldingStreak(ctx context.Context, streak *model.HoldingStreak) error
+
+	// 按用户汇总 [startTime, endTime] 内计算得到的积分（normal、backfill、referral、recalculation，不含已被取代的记录）
+	SumCalculatedPoints(ctx context.Context, chainName string, startTime, endTime time.Time) (map[string]float64, error)
+
+	// 按 granularity（day、week、month）在 timezone 时区汇总用户 [startTime, endTime) 内开始的积分历史（不含已被取代的记录）
+	GetPointsRollup(ctx context.Context, chainName, userAddress, granularity, timezone string, startTime, endTime time.Time) ([]*model.PointsRollup, error)
 }
 
 // pointsRepo 积分数据访问实现
@@ -46,7 +72,9 @@ func NewPointsRepository(db *sqlx.DB) PointsRepository {
 // GetUserPoints 查询用户积分
 func (r *pointsRepo) GetUserPoints(ctx context.Context, chainName, userAddress string) (*model.UserPoints, error) {
 	query := `
-		SELECT id, chain_name, user_address, total_points, last_calc_at, created_at, updated_at
+		SELECT id, chain_name, user_address, total_points, spent_points, expired_points,
+		       total_points - spent_points - expired_points AS available_points,
+		       last_calc_at, created_at, updated_at
 		FROM user_points
 		WHERE chain_name = $1 AND user_address = $2
 	`
@@ -64,17 +92,20 @@ func (r *pointsRepo) GetUserPoints(ctx context.Context, chainName, userAddress s
 }
 
 // GetUserPointsList 批量查询用户积分
-func (r *pointsRepo) GetUserPointsList(ctx context.Context, chainName string, offset, limit int) ([]*model.UserPoints, error) {
+func (r *pointsRepo) GetUserPointsList(ctx context.Context, chainName string, excludedAddresses []string, offset, limit int) ([]*model.UserPoints, error) {
 	query := `
-		SELECT id, chain_name, user_address, total_points, last_calc_at, created_at, updated_at
+		SELECT id, chain_name, user_address, total_points, spent_points, expired_points,
+		       total_points - spent_points - expired_points AS available_points,
+		       last_calc_at, created_at, updated_at
 		FROM user_points
 		WHERE chain_name = $1
+		  AND NOT (user_address = ANY($2))
 		ORDER BY total_points DESC, user_address ASC
-		LIMIT $2 OFFSET $3
+		LIMIT $3 OFFSET $4
 	`
 	
 	var pointsList []*model.UserPoints
-	err := r.db.SelectContext(ctx, &pointsList, query, chainName, limit, offset)
+	err := r.db.SelectContext(ctx, &pointsList, query, chainName, pq.Array(excludedAddresses), limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -82,51 +113,94 @@ func (r *pointsRepo) GetUserPointsList(ctx context.Context, chainName string, of
 	return pointsList, nil
 }
 
-// UpsertUserPoints 更新或创建用户积分
-func (r *pointsRepo) UpsertUserPoints(ctx context.Context, points *model.UserPoints) error {
-	query := `
+// RecordEarnedPoints 写入积分历史并累加用户总积分、记录 earn 流水
+// 总积分直接在数据库中累加，与手动调整、积分重建等并发写入不会互相覆盖
+// 其他实例或回溯任务已写入同一周期时（唯一索引冲突）不写入任何记录，返回 false
+func (r *pointsRepo) RecordEarnedPoints(ctx context.Context, history *model.PointsHistory) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err := insertPointsHistory(ctx, tx, history); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := addEarnedPoints(ctx, tx, history.ChainName, history.UserAddress, history.PointsEarned, history.CalcPeriodEnd, history.CalculationType); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// addEarnedPoints 在事务中累加用户总积分并记录 earn 流水
+func addEarnedPoints(ctx context.Context, tx *sqlx.Tx, chainName, userAddress string, points float64, calcTime time.Time, description string) error {
+	if _, err := tx.ExecContext(ctx, `
 		INSERT INTO user_points (chain_name, user_address, total_points, last_calc_at)
 		VALUES ($1, $2, $3, $4)
 		ON CONFLICT (chain_name, user_address)
 		DO UPDATE SET
-			total_points = EXCLUDED.total_points,
+			total_points = user_points.total_points + EXCLUDED.total_points,
 			last_calc_at = EXCLUDED.last_calc_at,
 			updated_at = NOW()
-		RETURNING id, created_at, updated_at
-	`
-	
-	return r.db.QueryRowContext(
-		ctx, query,
-		points.ChainName, points.UserAddress, points.TotalPoints, points.LastCalcAt,
-	).Scan(&points.ID, &points.CreatedAt, &points.UpdatedAt)
+	`, chainName, userAddress, points, calcTime); err != nil {
+		return err
+	}
+
+	if points == 0 {
+		return nil
+	}
+	return insertPointsTransaction(ctx, tx, &model.PointsTransaction{
+		ChainName:   chainName,
+		UserAddress: userAddress,
+		TxType:      model.TxTypeEarn,
+		Amount:      points,
+		Description: description,
+	})
 }
 
-// RecordPointsHistory 记录积分计算历史
-func (r *pointsRepo) RecordPointsHistory(ctx context.Context, history *model.PointsHistory) error {
+// insertPointsHistory 写入积分历史，可在事务中调用（正数积分形成批次）
+// 同一周期的计算积分已存在时不写入，返回 sql.ErrNoRows
+func insertPointsHistory(ctx context.Context, q sqlx.QueryerContext, history *model.PointsHistory) error {
 	query := `
 		INSERT INTO points_history (
 			chain_name, user_address, calc_period_start, calc_period_end,
-			balance_snapshot, points_earned, calculation_type
+			balance_snapshot, points_earned, calculation_type, source_address,
+			remaining_points, expires_at, risk_multiplier, recalculation_id, based_on_block
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, created_at
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, GREATEST($6, 0), $9, $10, $11, $12)
+		ON CONFLICT DO NOTHING
+		RETURNING id, remaining_points, created_at
 	`
-	
-	return r.db.QueryRowContext(
+
+	riskMultiplier := history.RiskMultiplier
+	if riskMultiplier == 0 {
+		riskMultiplier = 1
+	}
+
+	return q.QueryRowxContext(
 		ctx, query,
 		history.ChainName, history.UserAddress, history.CalcPeriodStart, history.CalcPeriodEnd,
-		history.BalanceSnapshot, history.PointsEarned, history.CalculationType,
-	).Scan(&history.ID, &history.CreatedAt)
+		history.BalanceSnapshot, history.PointsEarned, history.CalculationType, history.SourceAddress,
+		history.ExpiresAt, riskMultiplier, history.RecalculationID, history.BasedOnBlock,
+	).Scan(&history.ID, &history.RemainingPoints, &history.CreatedAt)
 }
 
 // GetPointsHistory 查询积分历史
 func (r *pointsRepo) GetPointsHistory(ctx context.Context, chainName, userAddress string, startTime, endTime time.Time) ([]*model.PointsHistory, error) {
 	query := `
 		SELECT id, chain_name, user_address, calc_period_start, calc_period_end,
-			   balance_snapshot, points_earned, calculation_type, created_at
+			   balance_snapshot, points_earned, calculation_type, source_address, adjustment_id,
+			   remaining_points, expires_at, risk_multiplier, recalculation_id, based_on_block, created_at
 		FROM points_history
 		WHERE chain_name = $1 AND user_address = $2
 		  AND calc_period_start >= $3 AND calc_period_end <= $4
+		  AND superseded_by IS NULL
 		ORDER BY calc_period_start ASC
 	`
 	
@@ -145,6 +219,8 @@ func (r *pointsRepo) GetLastCalculationTime(ctx context.Context, chainName strin
 		SELECT MAX(calc_period_end) as last_time
 		FROM points_history
 		WHERE chain_name = $1
+		  AND calculation_type IN ('normal', 'backfill', 'recalculation')
+		  AND superseded_by IS NULL
 	`
 	
 	var lastTime sql.NullTime
@@ -161,40 +237,301 @@ func (r *pointsRepo) GetLastCalculationTime(ctx context.Context, chainName strin
 }
 
 // GetUncalculatedPeriods 查询需要计算积分的时间区间
-func (r *pointsRepo) GetUncalculatedPeriods(ctx context.Context, chainName string, fromTime, toTime time.Time) ([]time.Time, error) {
-	// 获取已计算的小时
+// 与已有记录有重叠的周期视为已计算，链的计算周期调整后不会重复计算同一段时间
+func (r *pointsRepo) GetUncalculatedPeriods(ctx context.Context, chainName string, fromTime, toTime time.Time, period time.Duration) ([]time.Time, error) {
+	current := fromTime.UTC().Truncate(period)
+	end := toTime.UTC().Truncate(period)
+
+	// 获取与查询范围重叠的已计算周期
 	query := `
-		SELECT DISTINCT calc_period_start
+		SELECT DISTINCT calc_period_start, calc_period_end
 		FROM points_history
 		WHERE chain_name = $1
-		  AND calc_period_start >= $2
+		  AND calc_period_end > $2
 		  AND calc_period_start < $3
+		  AND calculation_type IN ('normal', 'backfill', 'recalculation')
+		  AND superseded_by IS NULL
 		ORDER BY calc_period_start
 	`
-	
-	var calculated []time.Time
-	err := r.db.SelectContext(ctx, &calculated, query, chainName, fromTime, toTime)
-	if err != nil {
-		return nil, err
+
+	var calculated []struct {
+		Start time.Time `db:"calc_period_start"`
+		End   time.Time `db:"calc_period_end"`
 	}
-	
-	// 生成所有应该计算的小时
-	calculatedMap := make(map[int64]bool)
-	for _, t := range calculated {
-		calculatedMap[t.Unix()] = true
+	if err := r.db.SelectContext(ctx, &calculated, query, chainName, current, end); err != nil {
+		return nil, err
 	}
-	
+
+	// 已计算周期按开始时间排序，逐个周期向后推进检查是否重叠
 	var uncalculated []time.Time
-	current := fromTime.Truncate(time.Hour)
-	end := toTime.Truncate(time.Hour)
-	
-	for current.Before(end) {
-		if !calculatedMap[current.Unix()] {
-			uncalculated = append(uncalculated, current)
+	next := 0
+	for ; current.Before(end); current = current.Add(period) {
+		for next < len(calculated) && !calculated[next].End.After(current) {
+			next++
+		}
+		if next < len(calculated) && calculated[next].Start.Before(current.Add(period)) {
+			continue
 		}
-		current = current.Add(time.Hour)
+		uncalculated = append(uncalculated, current)
 	}
-	
+
 	return uncalculated, nil
 }
 
+
+// RecordTransaction 记录积分流水
+func (r *pointsRepo) RecordTransaction(ctx context.Context, transaction *model.PointsTransaction) error {
+	return insertPointsTransaction(ctx, r.db, transaction)
+}
+
+// ListTransactions 分页查询用户积分流水
+func (r *pointsRepo) ListTransactions(ctx context.Context, chainName, userAddress, txType string, offset, limit int) ([]*model.PointsTransaction, error) {
+	query := `
+		SELECT id, chain_name, user_address, tx_type, amount, balance_after,
+		       reference_id, description, created_at
+		FROM points_transactions
+		WHERE chain_name = $1 AND user_address = $2
+		  AND ($3 = '' OR tx_type = $3)
+		ORDER BY id DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	var transactions []*model.PointsTransaction
+	if err := r.db.SelectContext(ctx, &transactions, query, chainName, userAddress, txType, limit, offset); err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// insertPointsTransaction 写入积分流水，可在事务中调用（需在更新 user_points 之后调用）
+func insertPointsTransaction(ctx context.Context, q sqlx.QueryerContext, transaction *model.PointsTransaction) error {
+	query := `
+		INSERT INTO points_transactions (
+			chain_name, user_address, tx_type, amount, balance_after, reference_id, description
+		)
+		VALUES (
+			$1, $2, $3, $4,
+			COALESCE((
+				SELECT total_points - spent_points - expired_points
+				FROM user_points
+				WHERE chain_name = $1 AND user_address = $2
+			), 0),
+			$5, $6
+		)
+		RETURNING id, balance_after, created_at
+	`
+
+	return q.QueryRowxContext(ctx, query,
+		transaction.ChainName, transaction.UserAddress, transaction.TxType, transaction.Amount,
+		transaction.ReferenceID, transaction.Description,
+	).Scan(&transaction.ID, &transaction.BalanceAfter, &transaction.CreatedAt)
+}
+
+// ListUsersWithExpiredLots 查询有过期批次的用户
+func (r *pointsRepo) ListUsersWithExpiredLots(ctx context.Context, chainName string, asOf time.Time) ([]string, error) {
+	query := `
+		SELECT DISTINCT user_address
+		FROM points_history
+		WHERE chain_name = $1
+		  AND remaining_points > 0
+		  AND expires_at <= $2
+		ORDER BY user_address
+	`
+
+	var users []string
+	if err := r.db.SelectContext(ctx, &users, query, chainName, asOf); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// ExpireUserLots 过期用户的到期批次
+func (r *pointsRepo) ExpireUserLots(ctx context.Context, chainName, userAddress string, asOf time.Time) (float64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// 与兑换、调整使用同一把行锁，保证批次扣减串行
+	if _, err := tx.ExecContext(ctx,
+		`SELECT 1 FROM user_points WHERE chain_name = $1 AND user_address = $2 FOR UPDATE`,
+		chainName, userAddress); err != nil {
+		return 0, err
+	}
+
+	var expired float64
+	err = tx.QueryRowContext(ctx, `
+		WITH expired AS (
+			SELECT id, remaining_points
+			FROM points_history
+			WHERE chain_name = $1 AND user_address = $2
+			  AND remaining_points > 0
+			  AND expires_at <= $3
+		), updated AS (
+			UPDATE points_history p
+			SET remaining_points = 0
+			FROM expired e
+			WHERE p.id = e.id
+		)
+		SELECT COALESCE(SUM(remaining_points), 0) FROM expired
+	`, chainName, userAddress, asOf).Scan(&expired)
+	if err != nil {
+		return 0, err
+	}
+	if expired == 0 {
+		return 0, tx.Commit()
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE user_points
+		SET expired_points = expired_points + $3, updated_at = NOW()
+		WHERE chain_name = $1 AND user_address = $2
+	`, chainName, userAddress, expired); err != nil {
+		return 0, err
+	}
+
+	err = insertPointsTransaction(ctx, tx, &model.PointsTransaction{
+		ChainName:   chainName,
+		UserAddress: userAddress,
+		TxType:      model.TxTypeExpire,
+		Amount:      -expired,
+		Description: "lots expired before " + asOf.Format(time.RFC3339),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return expired, tx.Commit()
+}
+
+// GetExpiringPoints 查询将要过期的积分
+func (r *pointsRepo) GetExpiringPoints(ctx context.Context, chainName, userAddress string, from, to time.Time) (float64, *time.Time, error) {
+	query := `
+		SELECT COALESCE(SUM(remaining_points), 0), MIN(expires_at)
+		FROM points_history
+		WHERE chain_name = $1 AND user_address = $2
+		  AND remaining_points > 0
+		  AND expires_at > $3 AND expires_at <= $4
+	`
+
+	var amount float64
+	var nextExpiry sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, chainName, userAddress, from, to).Scan(&amount, &nextExpiry); err != nil {
+		return 0, nil, err
+	}
+	if !nextExpiry.Valid {
+		return amount, nil, nil
+	}
+	return amount, &nextExpiry.Time, nil
+}
+
+// consumePointsLots 按先进先出扣减用户批次剩余积分，调用方需在事务中持有 user_points 行锁
+func consumePointsLots(ctx context.Context, tx *sqlx.Tx, chainName, userAddress string, amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		WITH lots AS (
+			SELECT id, remaining_points,
+			       SUM(remaining_points) OVER (ORDER BY calc_period_end, id) - remaining_points AS consumed_before
+			FROM points_history
+			WHERE chain_name = $1 AND user_address = $2
+			  AND remaining_points > 0
+		)
+		UPDATE points_history p
+		SET remaining_points = p.remaining_points - LEAST(l.remaining_points, $3 - l.consumed_before)
+		FROM lots l
+		WHERE p.id = l.id
+		  AND l.consumed_before < $3
+	`, chainName, userAddress, amount)
+	return err
+}
+
+// GetHoldingStreak 查询用户连续持有状态
+func (r *pointsRepo) GetHoldingStreak(ctx context.Context, chainName, userAddress string) (*model.HoldingStreak, error) {
+	query := `
+		SELECT id, chain_name, user_address, holding_since, held_balance, as_of, updated_at
+		FROM holding_streaks
+		WHERE chain_name = $1 AND user_address = $2
+	`
+
+	var streak model.HoldingStreak
+	err := r.db.GetContext(ctx, &streak, query, chainName, userAddress)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &streak, nil
+}
+
+// UpsertHoldingStreak 更新或创建用户连续持有状态
+func (r *pointsRepo) UpsertHoldingStreak(ctx context.Context, streak *model.HoldingStreak) error {
+	query := `
+		INSERT INTO holding_streaks (chain_name, user_address, holding_since, held_balance, as_of)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (chain_name, user_address)
+		DO UPDATE SET
+			holding_since = EXCLUDED.holding_since,
+			held_balance = EXCLUDED.held_balance,
+			as_of = EXCLUDED.as_of,
+			updated_at = NOW()
+		RETURNING id, updated_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		streak.ChainName, streak.UserAddress, streak.HoldingSince, streak.HeldBalance, streak.AsOf,
+	).Scan(&streak.ID, &streak.UpdatedAt)
+}
+
+// SumCalculatedPoints 按用户汇总时间段内计算得到的积分
+func (r *pointsRepo) SumCalculatedPoints(ctx context.Context, chainName string, startTime, endTime time.Time) (map[string]float64, error) {
+	query := `
+		SELECT user_address, SUM(points_earned) AS points
+		FROM points_history
+		WHERE chain_name = $1
+		  AND calc_period_start >= $2 AND calc_period_end <= $3
+		  AND calculation_type IN ('normal', 'backfill', 'referral', 'recalculation')
+		  AND superseded_by IS NULL
+		GROUP BY user_address
+	`
+
+	var rows []struct {
+		UserAddress string  `db:"user_address"`
+		Points      float64 `db:"points"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, chainName, startTime, endTime); err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		totals[row.UserAddress] = row.Points
+	}
+	return totals, nil
+}
+
+// GetPointsRollup 按报表时区的日/周/月汇总用户积分历史
+// 计算周期按 UTC 存储，先转换到报表时区再截断，汇总周期的开始时间转换回绝对时间
+func (r *pointsRepo) GetPointsRollup(ctx context.Context, chainName, userAddress, granularity, timezone string, startTime, endTime time.Time) ([]*model.PointsRollup, error) {
+	query := `
+		SELECT date_trunc($3, calc_period_start AT TIME ZONE $4) AT TIME ZONE $4 AS period_start,
+		       SUM(points_earned) AS points_earned,
+		       COUNT(*) AS records
+		FROM points_history
+		WHERE chain_name = $1 AND user_address = $2
+		  AND calc_period_start >= $5 AND calc_period_start < $6
+		  AND superseded_by IS NULL
+		GROUP BY 1
+		ORDER BY 1
+	`
+
+	var rollups []*model.PointsRollup
+	if err := r.db.SelectContext(ctx, &rollups, query, chainName, userAddress, granularity, timezone, startTime, endTime); err != nil {
+		return nil, err
+	}
+	return rollups, nil
+}
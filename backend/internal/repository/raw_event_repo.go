@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"my-token-points/internal/model"
+)
+
+// RawEventRepository 原始事件归档数据访问接口
+type RawEventRepository interface {
+	// 保存原始事件（已存在时忽略）
+	SaveRawEvent(ctx context.Context, event *model.RawEvent) error
+
+	// 按链上顺序分页查询原始事件，返回 (afterBlock, afterLogIndex) 之后的记录
+	ListRawEvents(ctx context.Context, chainName string, afterBlock int64, afterLogIndex, limit int) ([]*model.RawEvent, error)
+}
+
+// rawEventRepo 原始事件归档数据访问实现
+type rawEventRepo struct {
+	db *sqlx.DB
+}
+
+// NewRawEventRepository 创建原始事件归档仓储实例
+func NewRawEventRepository(db *sqlx.DB) RawEventRepository {
+	return &rawEventRepo{db: db}
+}
+
+// SaveRawEvent 保存原始事件
+func (r *rawEventRepo) SaveRawEvent(ctx context.Context, event *model.RawEvent) error {
+	query := `
+		INSERT INTO raw_events (
+			chain_name, contract_address, event_name, block_number, block_hash, block_time,
+			tx_hash, tx_index, log_index, topics, data
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (chain_name, tx_hash, log_index) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(
+		ctx, query,
+		event.ChainName, event.ContractAddress, event.EventName, event.BlockNumber,
+		event.BlockHash, event.BlockTime, event.TxHash, event.TxIndex, event.LogIndex,
+		event.Topics, event.Data,
+	)
+	return err
+}
+
+// ListRawEvents 按链上顺序分页查询原始事件
+func (r *rawEventRepo) ListRawEvents(ctx context.Context, chainName string, afterBlock int64, afterLogIndex, limit int) ([]*model.RawEvent, error) {
+	query := `
+		SELECT id, chain_name, contract_address, event_name, block_number, block_hash, block_time,
+			   tx_hash, tx_index, log_index, topics, data, created_at
+		FROM raw_events
+		WHERE chain_name = $1
+		  AND (block_number, log_index) > ($2, $3)
+		ORDER BY block_number ASC, log_index ASC
+		LIMIT $4
+	`
+
+	var events []*model.RawEvent
+	err := r.db.SelectContext(ctx, &events, query, chainName, afterBlock, afterLogIndex, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
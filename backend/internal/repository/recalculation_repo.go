@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"my-token-points/internal/model"
+)
+
+// RecalculationRepository 积分重算数据访问接口
+type RecalculationRepository interface {
+	// 创建重算记录（状态为 running）
+	CreateRecalculation(ctx context.Context, recalculation *model.PointsRecalculation) error
+
+	// 记录重算前 [startTime, endTime) 内每个用户的计算积分（已记录的用户不覆盖）
+	SaveBaseline(ctx context.Context, recalculationID int64, chainName string, startTime, endTime time.Time) error
+
+	// 用重算结果取代 [periodStart, periodEnd) 内的计算积分历史，并更新已重算的小时数
+	ReplacePeriodHistory(ctx context.Context, recalculationID int64, chainName string, periodStart, periodEnd time.Time, histories []*model.PointsHistory) error
+
+	// 查询重算前后在时间段内有积分的用户
+	ListRecalculationUsers(ctx context.Context, recalculationID int64) ([]string, error)
+
+	// 按未被取代的积分历史重建用户总积分和批次剩余量，记录 recalculate 流水和差异
+	// 可重复调用，重算前的总积分只在第一次重建时记录
+	RebuildUserPoints(ctx context.Context, recalculationID int64, chainName, userAddress string) (*model.RecalculationDiff, error)
+
+	// 结束重算（completed、failed 或 cancelled），按差异汇总结果
+	FinishRecalculation(ctx context.Context, recalculation *model.PointsRecalculation) error
+
+	// 按 ID 查询重算记录
+	GetRecalculation(ctx context.Context, id int64) (*model.PointsRecalculation, error)
+
+	// 分页查询重算记录（chainName 为空表示不过滤）
+	ListRecalculations(ctx context.Context, chainName string, offset, limit int) ([]*model.PointsRecalculation, error)
+
+	// 分页查询重算差异（按总积分变化绝对值降序）
+	ListRecalculationDiffs(ctx context.Context, recalculationID int64, offset, limit int) ([]*model.RecalculationDiff, error)
+}
+
+// recalculationRepo 积分重算数据访问实现
+type recalculationRepo struct {
+	db *sqlx.DB
+}
+
+// NewRecalculationRepository 创建积分重算仓储实例
+func NewRecalculationRepository(db *sqlx.DB) RecalculationRepository {
+	return &recalculationRepo{db: db}
+}
+
+// CreateRecalculation 创建重算记录
+func (r *recalculationRepo) CreateRecalculation(ctx context.Context, recalculation *model.PointsRecalculation) error {
+	query := `
+		INSERT INTO points_recalculations (chain_name, period_start, period_end, status, reason, operator)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	recalculation.Status = model.RecalculationStatusRunning
+	return r.db.QueryRowContext(ctx, query,
+		recalculation.ChainName, recalculation.PeriodStart, recalculation.PeriodEnd,
+		recalculation.Status, recalculation.Reason, recalculation.Operator,
+	).Scan(&recalculation.ID, &recalculation.CreatedAt)
+}
+
+// SaveBaseline 记录重算前的计算积分
+func (r *recalculationRepo) SaveBaseline(ctx context.Context, recalculationID int64, chainName string, startTime, endTime time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO points_recalculation_diffs (
+			recalculation_id, user_address, period_points_before, period_points_after, total_before, total_after
+		)
+		SELECT $1, user_address, SUM(points_earned), 0, 0, 0
+		FROM points_history
+		WHERE chain_name = $2
+		  AND calc_period_start >= $3 AND calc_period_start < $4
+		  AND calculation_type IN ('normal', 'backfill', 'referral', 'recalculation')
+		  AND superseded_by IS NULL
+		GROUP BY user_address
+		ON CONFLICT (recalculation_id, user_address) DO NOTHING
+	`, recalculationID, chainName, startTime, endTime)
+	return err
+}
+
+// ReplacePeriodHistory 在一个事务中标记旧记录并写入重算结果
+// 被取代的记录剩余积分清零，不再参与过期和扣减
+func (r *recalculationRepo) ReplacePeriodHistory(
+	ctx context.Context,
+	recalculationID int64,
+	chainName string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	histories []*model.PointsHistory,
+) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE points_history
+		SET superseded_by = $4, remaining_points = 0
+		WHERE chain_name = $1
+		  AND calc_period_start >= $2 AND calc_period_start < $3
+		  AND calculation_type IN ('normal', 'backfill', 'referral', 'recalculation')
+		  AND superseded_by IS NULL
+	`, chainName, periodStart, periodEnd, recalculationID)
+	if err != nil {
+		return err
+	}
+
+	for _, history := range histories {
+		history.RecalculationID = &recalculationID
+		if err := insertPointsHistory(ctx, tx, history); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE points_recalculations SET periods_done = periods_done + 1 WHERE id = $1`,
+		recalculationID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListRecalculationUsers 查询重算前后在时间段内有积分的用户
+func (r *recalculationRepo) ListRecalculationUsers(ctx context.Context, recalculationID int64) ([]string, error) {
+	query := `
+		SELECT user_address FROM points_recalculation_diffs WHERE recalculation_id = $1
+		UNION
+		SELECT DISTINCT user_address FROM points_history WHERE recalculation_id = $1
+		ORDER BY user_address
+	`
+
+	var users []string
+	if err := r.db.SelectContext(ctx, &users, query, recalculationID); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// RebuildUserPoints 重建用户总积分和批次剩余量
+// 总积分 = 未被取代的积分历史合计；已消费、已过期和扣回的积分按先进先出重新从批次中扣减
+func (r *recalculationRepo) RebuildUserPoints(ctx context.Context, recalculationID int64, chainName, userAddress string) (*model.RecalculationDiff, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 与兑换、调整、过期使用同一把行锁
+	var current, spent, expired float64
+	err = tx.QueryRowContext(ctx, `
+		SELECT total_points, spent_points, expired_points
+		FROM user_points
+		WHERE chain_name = $1 AND user_address = $2
+		FOR UPDATE
+	`, chainName, userAddress).Scan(&current, &spent, &expired)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	diff := &model.RecalculationDiff{RecalculationID: recalculationID, UserAddress: userAddress}
+	err = tx.QueryRowContext(ctx, `
+		SELECT period_points_before, total_before, rebuilt_at
+		FROM points_recalculation_diffs
+		WHERE recalculation_id = $1 AND user_address = $2
+	`, recalculationID, userAddress).Scan(&diff.PeriodPointsBefore, &diff.TotalBefore, &diff.RebuiltAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if diff.RebuiltAt == nil {
+		diff.TotalBefore = current
+	}
+
+	var deducted float64
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(points_earned), 0),
+		       COALESCE(SUM(GREATEST(-points_earned, 0)), 0),
+		       COALESCE(SUM(points_earned) FILTER (WHERE recalculation_id = $3), 0)
+		FROM points_history
+		WHERE chain_name = $1 AND user_address = $2
+		  AND superseded_by IS NULL
+	`, chainName, userAddress, recalculationID).Scan(&diff.TotalAfter, &deducted, &diff.PeriodPointsAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_points (chain_name, user_address, total_points)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (chain_name, user_address)
+		DO UPDATE SET
+			total_points = EXCLUDED.total_points,
+			updated_at = NOW()
+	`, chainName, userAddress, diff.TotalAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先恢复所有批次，再按先进先出扣减
+	_, err = tx.ExecContext(ctx, `
+		UPDATE points_history
+		SET remaining_points = GREATEST(points_earned, 0)
+		WHERE chain_name = $1 AND user_address = $2
+		  AND superseded_by IS NULL
+	`, chainName, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := consumePointsLots(ctx, tx, chainName, userAddress, spent+expired+deducted); err != nil {
+		return nil, err
+	}
+
+	if amount := diff.TotalAfter - current; amount != 0 {
+		err = insertPointsTransaction(ctx, tx, &model.PointsTransaction{
+			ChainName:   chainName,
+			UserAddress: userAddress,
+			TxType:      model.TxTypeRecalculate,
+			Amount:      amount,
+			ReferenceID: &recalculationID,
+			Description: "points recalculation",
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO points_recalculation_diffs (
+			recalculation_id, user_address, period_points_before, period_points_after,
+			total_before, total_after, rebuilt_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (recalculation_id, user_address)
+		DO UPDATE SET
+			period_points_after = EXCLUDED.period_points_after,
+			total_before = EXCLUDED.total_before,
+			total_after = EXCLUDED.total_after,
+			rebuilt_at = EXCLUDED.rebuilt_at
+		RETURNING id, rebuilt_at, created_at
+	`, recalculationID, userAddress, diff.PeriodPointsBefore, diff.PeriodPointsAfter,
+		diff.TotalBefore, diff.TotalAfter).Scan(&diff.ID, &diff.RebuiltAt, &diff.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return diff, tx.Commit()
+}
+
+// FinishRecalculation 结束重算
+func (r *recalculationRepo) FinishRecalculation(ctx context.Context, recalculation *model.PointsRecalculation) error {
+	query := `
+		UPDATE points_recalculations r
+		SET status = $2, error_message = $3, completed_at = NOW(),
+		    users_changed = d.users_changed, points_before = d.points_before, points_after = d.points_after
+		FROM (
+			SELECT COUNT(*) FILTER (WHERE rebuilt_at IS NOT NULL AND ABS(total_after - total_before) > 0.000000001) AS users_changed,
+			       COALESCE(SUM(period_points_before), 0) AS points_before,
+			       COALESCE(SUM(period_points_after), 0) AS points_after
+			FROM points_recalculation_diffs
+			WHERE recalculation_id = $1
+		) d
+		WHERE r.id = $1
+		RETURNING r.periods_done, r.users_changed, r.points_before, r.points_after, r.completed_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		recalculation.ID, recalculation.Status, recalculation.ErrorMessage,
+	).Scan(&recalculation.PeriodsDone, &recalculation.UsersChanged,
+		&recalculation.PointsBefore, &recalculation.PointsAfter, &recalculation.CompletedAt)
+}
+
+// GetRecalculation 按 ID 查询重算记录
+func (r *recalculationRepo) GetRecalculation(ctx context.Context, id int64) (*model.PointsRecalculation, error) {
+	query := `
+		SELECT id, chain_name, period_start, period_end, status, reason, operator,
+		       periods_done, users_changed, points_before, points_after,
+		       error_message, created_at, completed_at
+		FROM points_recalculations
+		WHERE id = $1
+	`
+
+	var recalculation model.PointsRecalculation
+	err := r.db.GetContext(ctx, &recalculation, query, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &recalculation, nil
+}
+
+// ListRecalculations 分页查询重算记录
+func (r *recalculationRepo) ListRecalculations(ctx context.Context, chainName string, offset, limit int) ([]*model.PointsRecalculation, error) {
+	query := `
+		SELECT id, chain_name, period_start, period_end, status, reason, operator,
+		       periods_done, users_changed, points_before, points_after,
+		       error_message, created_at, completed_at
+		FROM points_recalculations
+		WHERE ($1 = '' OR chain_name = $1)
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	var recalculations []*model.PointsRecalculation
+	if err := r.db.SelectContext(ctx, &recalculations, query, chainName, limit, offset); err != nil {
+		return nil, err
+	}
+	return recalculations, nil
+}
+
+// ListRecalculationDiffs 分页查询重算差异
+func (r *recalculationRepo) ListRecalculationDiffs(ctx context.Context, recalculationID int64, offset, limit int) ([]*model.RecalculationDiff, error) {
+	query := `
+		SELECT id, recalculation_id, user_address, period_points_before, period_points_after,
+		       total_before, total_after, rebuilt_at, created_at
+		FROM points_recalculation_diffs
+		WHERE recalculation_id = $1
+		ORDER BY ABS(total_after - total_before) DESC, user_address ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	var diffs []*model.RecalculationDiff
+	if err := r.db.SelectContext(ctx, &diffs, query, recalculationID, limit, offset); err != nil {
+		return nil, err
+	}
+	return diffs, nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+
+	"my-token-points/internal/model"
+)
+
+// RedeemResult 兑换结果
+type RedeemResult int
+
+const (
+	// RedeemCreated 兑换成功
+	RedeemCreated RedeemResult = iota
+	// RedeemDuplicate 幂等键已使用，redemption 被填充为已有记录
+	RedeemDuplicate
+	// RedeemInsufficient 可用积分不足
+	RedeemInsufficient
+)
+
+// RedemptionRepository 积分兑换数据访问接口
+type RedemptionRepository interface {
+	// 兑换积分：锁定用户积分行，检查幂等键和可用积分后扣减并写入流水
+	Redeem(ctx context.Context, redemption *model.PointsRedemption) (RedeemResult, error)
+
+	// 分页查询用户兑换记录
+	ListRedemptions(ctx context.Context, chainName, userAddress string, offset, limit int) ([]*model.PointsRedemption, error)
+}
+
+// redemptionRepo 积分兑换数据访问实现
+type redemptionRepo struct {
+	db *sqlx.DB
+}
+
+// NewRedemptionRepository 创建积分兑换仓储实例
+func NewRedemptionRepository(db *sqlx.DB) RedemptionRepository {
+	return &redemptionRepo{db: db}
+}
+
+// Redeem 兑换积分
+func (r *redemptionRepo) Redeem(ctx context.Context, redemption *model.PointsRedemption) (RedeemResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// 先锁定用户积分行，同一用户的并发兑换在此排队，避免超额消费
+	var available float64
+	err = tx.QueryRowContext(ctx, `
+		SELECT total_points - spent_points - expired_points
+		FROM user_points
+		WHERE chain_name = $1 AND user_address = $2
+		FOR UPDATE
+	`, redemption.ChainName, redemption.UserAddress).Scan(&available)
+	if err == sql.ErrNoRows {
+		return RedeemInsufficient, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// 持有行锁后再检查幂等键，重复请求直接返回已有记录
+	err = tx.GetContext(ctx, redemption, `
+		SELECT id, chain_name, user_address, amount, perk, idempotency_key, message, signature, created_at
+		FROM points_redemptions
+		WHERE chain_name = $1 AND user_address = $2 AND idempotency_key = $3
+	`, redemption.ChainName, redemption.UserAddress, redemption.IdempotencyKey)
+	if err == nil {
+		return RedeemDuplicate, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	if available < redemption.Amount {
+		return RedeemInsufficient, nil
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO points_redemptions (chain_name, user_address, amount, perk, idempotency_key, message, signature)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, redemption.ChainName, redemption.UserAddress, redemption.Amount, redemption.Perk,
+		redemption.IdempotencyKey, redemption.Message, redemption.Signature,
+	).Scan(&redemption.ID, &redemption.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_points
+		SET spent_points = spent_points + $3, updated_at = NOW()
+		WHERE chain_name = $1 AND user_address = $2
+	`, redemption.ChainName, redemption.UserAddress, redemption.Amount)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := consumePointsLots(ctx, tx, redemption.ChainName, redemption.UserAddress, redemption.Amount); err != nil {
+		return 0, err
+	}
+
+	err = insertPointsTransaction(ctx, tx, &model.PointsTransaction{
+		ChainName:   redemption.ChainName,
+		UserAddress: redemption.UserAddress,
+		TxType:      model.TxTypeSpend,
+		Amount:      -redemption.Amount,
+		ReferenceID: &redemption.ID,
+		Description: redemption.Perk,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return RedeemCreated, tx.Commit()
+}
+
+// ListRedemptions 分页查询用户兑换记录
+func (r *redemptionRepo) ListRedemptions(ctx context.Context, chainName, userAddress string, offset, limit int) ([]*model.PointsRedemption, error) {
+	query := `
+		SELECT id, chain_name, user_address, amount, perk, idempotency_key, message, signature, created_at
+		FROM points_redemptions
+		WHERE chain_name = $1 AND user_address = $2
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	var redemptions []*model.PointsRedemption
+	if err := r.db.SelectContext(ctx, &redemptions, query, chainName, userAddress, limit, offset); err != nil {
+		return nil, err
+	}
+	return redemptions, nil
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"my-token-points/internal/model"
+)
+
+// ReferralRepository 推荐关系数据访问接口
+type ReferralRepository interface {
+	// 创建推荐码，推荐码或地址已存在时返回 false
+	CreateReferralCode(ctx context.Context, code *model.ReferralCode) (bool, error)
+
+	// 按推荐码查询
+	GetReferralCode(ctx context.Context, code string) (*model.ReferralCode, error)
+
+	// 按地址查询推荐码
+	GetReferralCodeByOwner(ctx context.Context, ownerAddress string) (*model.ReferralCode, error)
+
+	// 创建推荐关系，被推荐人已绑定时返回 false
+	CreateReferral(ctx context.Context, referral *model.Referral) (bool, error)
+
+	// 查询被推荐人的推荐关系
+	GetReferralByReferee(ctx context.Context, refereeAddress string) (*model.Referral, error)
+
+	// 分页查询推荐人邀请的地址
+	ListReferees(ctx context.Context, referrerAddress string, offset, limit int) ([]*model.Referral, error)
+
+	// 批量查询被推荐人对应的推荐人 (referee -> referrer)
+	GetReferrers(ctx context.Context, refereeAddresses []string) (map[string]string, error)
+}
+
+// referralRepo 推荐关系数据访问实现
+type referralRepo struct {
+	db *sqlx.DB
+}
+
+// NewReferralRepository 创建推荐关系仓储实例
+func NewReferralRepository(db *sqlx.DB) ReferralRepository {
+	return &referralRepo{db: db}
+}
+
+// CreateReferralCode 创建推荐码
+func (r *referralRepo) CreateReferralCode(ctx context.Context, code *model.ReferralCode) (bool, error) {
+	query := `
+		INSERT INTO referral_codes (code, owner_address)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, code.Code, code.OwnerAddress).Scan(&code.ID, &code.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetReferralCode 按推荐码查询
+func (r *referralRepo) GetReferralCode(ctx context.Context, code string) (*model.ReferralCode, error) {
+	query := `SELECT id, code, owner_address, created_at FROM referral_codes WHERE code = $1`
+
+	var referralCode model.ReferralCode
+	err := r.db.GetContext(ctx, &referralCode, query, code)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &referralCode, nil
+}
+
+// GetReferralCodeByOwner 按地址查询推荐码
+func (r *referralRepo) GetReferralCodeByOwner(ctx context.Context, ownerAddress string) (*model.ReferralCode, error) {
+	query := `SELECT id, code, owner_address, created_at FROM referral_codes WHERE owner_address = $1`
+
+	var referralCode model.ReferralCode
+	err := r.db.GetContext(ctx, &referralCode, query, ownerAddress)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &referralCode, nil
+}
+
+// CreateReferral 创建推荐关系
+func (r *referralRepo) CreateReferral(ctx context.Context, referral *model.Referral) (bool, error) {
+	query := `
+		INSERT INTO referrals (referrer_address, referee_address, code, message, signature)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (referee_address) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		referral.ReferrerAddress, referral.RefereeAddress, referral.Code, referral.Message, referral.Signature,
+	).Scan(&referral.ID, &referral.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetReferralByReferee 查询被推荐人的推荐关系
+func (r *referralRepo) GetReferralByReferee(ctx context.Context, refereeAddress string) (*model.Referral, error) {
+	query := `
+		SELECT id, referrer_address, referee_address, code, message, signature, created_at
+		FROM referrals
+		WHERE referee_address = $1
+	`
+
+	var referral model.Referral
+	err := r.db.GetContext(ctx, &referral, query, refereeAddress)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &referral, nil
+}
+
+// ListReferees 分页查询推荐人邀请的地址
+func (r *referralRepo) ListReferees(ctx context.Context, referrerAddress string, offset, limit int) ([]*model.Referral, error) {
+	query := `
+		SELECT id, referrer_address, referee_address, code, message, signature, created_at
+		FROM referrals
+		WHERE referrer_address = $1
+		ORDER BY id ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	var referrals []*model.Referral
+	if err := r.db.SelectContext(ctx, &referrals, query, referrerAddress, limit, offset); err != nil {
+		return nil, err
+	}
+	return referrals, nil
+}
+
+// GetReferrers 批量查询被推荐人对应的推荐人
+func (r *referralRepo) GetReferrers(ctx context.Context, refereeAddresses []string) (map[string]string, error) {
+	query := `
+		SELECT referee_address, referrer_address
+		FROM referrals
+		WHERE referee_address = ANY($1)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(refereeAddresses))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	referrers := make(map[string]string)
+	for rows.Next() {
+		var referee, referrer string
+		if err := rows.Scan(&referee, &referrer); err != nil {
+			return nil, err
+		}
+		referrers[referee] = referrer
+	}
+
+	return referrers, rows.Err()
+}
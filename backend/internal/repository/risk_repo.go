@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"my-token-points/internal/model"
+)
+
+// RiskRepository 地址风险数据访问接口
+type RiskRepository interface {
+	// 查询 [startTime, endTime) 内已确认的钱包间转账
+	GetTransferEdges(ctx context.Context, chainName string, startTime, endTime time.Time) ([]*model.TransferEdge, error)
+
+	// 查询每个地址的首笔转入（From 为资金来源）
+	GetFirstFundingEdges(ctx context.Context, chainName string) ([]*model.TransferEdge, error)
+
+	// 写入一次检测结果：先清零链上已有的检测分，再写入本次命中的地址（保留人工覆盖）
+	ReplaceRiskScores(ctx context.Context, chainName string, risks []*model.AddressRisk, analyzedAt time.Time) error
+
+	// 查询风险分不低于 minScore 或有人工覆盖的地址（按风险分降序）
+	ListRiskScores(ctx context.Context, chainName string, minScore float64, offset, limit int) ([]*model.AddressRisk, error)
+
+	// 查询单个地址的风险分
+	GetRiskScore(ctx context.Context, chainName, address string) (*model.AddressRisk, error)
+
+	// 设置人工覆盖并记录审计（地址没有风险记录时创建）
+	SetRiskOverride(ctx context.Context, chainName, address, override, reason, operator string) (*model.AddressRisk, error)
+
+	// 分页查询风险覆盖审计记录
+	ListRiskAudit(ctx context.Context, chainName, address string, offset, limit int) ([]*model.AddressRiskAudit, error)
+}
+
+// riskRepo 地址风险数据访问实现
+type riskRepo struct {
+	db *sqlx.DB
+}
+
+// NewRiskRepository 创建地址风险仓储实例
+func NewRiskRepository(db *sqlx.DB) RiskRepository {
+	return &riskRepo{db: db}
+}
+
+// riskColumns address_risk_scores 查询列
+const riskColumns = `id, chain_name, address, score, signals, funder_address, override, override_reason,
+	override_by, override_at, analyzed_at, created_at, updated_at`
+
+// GetTransferEdges 查询时间段内的钱包间转账
+// 同一日志的 transfer_out 和 transfer_in 两条变动组成一笔转账
+func (r *riskRepo) GetTransferEdges(ctx context.Context, chainName string, startTime, endTime time.Time) ([]*model.TransferEdge, error) {
+	query := `
+		SELECT o.user_address AS from_address, i.user_address AS to_address, i.amount_delta AS amount,
+		       i.block_number, i.block_time, i.tx_hash
+		FROM balance_changes o
+		JOIN balance_changes i
+		  ON i.chain_name = o.chain_name AND i.tx_hash = o.tx_hash AND i.log_index = o.log_index
+		 AND i.event_type = 'transfer_in' AND i.balance_type = 'wallet' AND i.confirmed = true
+		WHERE o.chain_name = $1
+		  AND o.event_type = 'transfer_out' AND o.balance_type = 'wallet' AND o.confirmed = true
+		  AND o.block_time >= $2 AND o.block_time < $3
+		ORDER BY i.block_number ASC, i.log_index ASC
+	`
+
+	var edges []*model.TransferEdge
+	if err := r.db.SelectContext(ctx, &edges, query, chainName, startTime, endTime); err != nil {
+		return nil, err
+	}
+	return edges, nil
+}
+
+// GetFirstFundingEdges 查询每个地址的首笔转入
+func (r *riskRepo) GetFirstFundingEdges(ctx context.Context, chainName string) ([]*model.TransferEdge, error) {
+	query := `
+		SELECT DISTINCT ON (i.user_address)
+		       o.user_address AS from_address, i.user_address AS to_address, i.amount_delta AS amount,
+		       i.block_number, i.block_time, i.tx_hash
+		FROM balance_changes i
+		JOIN balance_changes o
+		  ON o.chain_name = i.chain_name AND o.tx_hash = i.tx_hash AND o.log_index = i.log_index
+		 AND o.event_type = 'transfer_out' AND o.balance_type = 'wallet' AND o.confirmed = true
+		WHERE i.chain_name = $1
+		  AND i.event_type = 'transfer_in' AND i.balance_type = 'wallet' AND i.confirmed = true
+		ORDER BY i.user_address, i.block_number ASC, i.log_index ASC
+	`
+
+	var edges []*model.TransferEdge
+	if err := r.db.SelectContext(ctx, &edges, query, chainName); err != nil {
+		return nil, err
+	}
+	return edges, nil
+}
+
+// ReplaceRiskScores 写入一次检测结果
+func (r *riskRepo) ReplaceRiskScores(ctx context.Context, chainName string, risks []*model.AddressRisk, analyzedAt time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 本次未命中的地址风险分清零，人工覆盖保持不变
+	_, err = tx.ExecContext(ctx, `
+		UPDATE address_risk_scores
+		SET score = 0, signals = '[]', funder_address = NULL, analyzed_at = $2, updated_at = NOW()
+		WHERE chain_name = $1 AND analyzed_at IS NOT NULL
+	`, chainName, analyzedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, risk := range risks {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO address_risk_scores (chain_name, address, score, signals, funder_address, analyzed_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (chain_name, address)
+			DO UPDATE SET
+				score = EXCLUDED.score,
+				signals = EXCLUDED.signals,
+				funder_address = EXCLUDED.funder_address,
+				analyzed_at = EXCLUDED.analyzed_at,
+				updated_at = NOW()
+		`, chainName, risk.Address, risk.Score, risk.Signals, risk.FunderAddress, analyzedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListRiskScores 查询风险地址
+func (r *riskRepo) ListRiskScores(ctx context.Context, chainName string, minScore float64, offset, limit int) ([]*model.AddressRisk, error) {
+	query := `
+		SELECT ` + riskColumns + `
+		FROM address_risk_scores
+		WHERE chain_name = $1
+		  AND (score >= $2 OR override <> 'none')
+		ORDER BY score DESC, address ASC
+		LIMIT $3 OFFSET $4
+	`
+
+	var risks []*model.AddressRisk
+	if err := r.db.SelectContext(ctx, &risks, query, chainName, minScore, limit, offset); err != nil {
+		return nil, err
+	}
+	return risks, nil
+}
+
+// GetRiskScore 查询单个地址的风险分
+func (r *riskRepo) GetRiskScore(ctx context.Context, chainName, address string) (*model.AddressRisk, error) {
+	query := `
+		SELECT ` + riskColumns + `
+		FROM address_risk_scores
+		WHERE chain_name = $1 AND address = $2
+	`
+
+	var risk model.AddressRisk
+	err := r.db.GetContext(ctx, &risk, query, chainName, address)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &risk, nil
+}
+
+// SetRiskOverride 设置人工覆盖并记录审计
+func (r *riskRepo) SetRiskOverride(ctx context.Context, chainName, address, override, reason, operator string) (*model.AddressRisk, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var risk model.AddressRisk
+	err = tx.GetContext(ctx, &risk, `
+		INSERT INTO address_risk_scores (chain_name, address, override, override_reason, override_by, override_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (chain_name, address)
+		DO UPDATE SET
+			override = EXCLUDED.override,
+			override_reason = EXCLUDED.override_reason,
+			override_by = EXCLUDED.override_by,
+			override_at = EXCLUDED.override_at,
+			updated_at = NOW()
+		RETURNING `+riskColumns,
+		chainName, address, override, reason, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO address_risk_audit (chain_name, address, override, reason, operator)
+		VALUES ($1, $2, $3, $4, $5)
+	`, chainName, address, override, reason, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &risk, tx.Commit()
+}
+
+// ListRiskAudit 分页查询风险覆盖审计记录
+func (r *riskRepo) ListRiskAudit(ctx context.Context, chainName, address string, offset, limit int) ([]*model.AddressRiskAudit, error) {
+	query := `
+		SELECT id, chain_name, address, override, reason, operator, created_at
+		FROM address_risk_audit
+		WHERE ($1 = '' OR chain_name = $1)
+		  AND ($2 = '' OR address = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	var audits []*model.AddressRiskAudit
+	if err := r.db.SelectContext(ctx, &audits, query, chainName, address, limit, offset); err != nil {
+		return nil, err
+	}
+	return audits, nil
+}
@@ -14,9 +14,18 @@ type SyncRepository interface {
 	// 获取链的同步状态
 	GetSyncState(ctx context.Context, chainName string) (*model.SyncState, error)
 
+	// 查询所有链的同步状态
+	ListSyncStates(ctx context.Context) ([]*model.SyncState, error)
+
 	// 更新同步状态
 	UpdateSyncState(ctx context.Context, state *model.SyncState) error
 
+	// 记录扫描失败
+	RecordSyncError(ctx context.Context, chainName, status, errMsg string, failures int) error
+
+	// 更新运行状态（启动/停止）
+	UpdateSyncStatus(ctx context.Context, chainName, status string) error
+
 	// 初始化同步状态
 	InitSyncState(ctx context.Context, chainName string, startBlock int64) error
 }
@@ -31,10 +40,17 @@ func NewSyncRepository(db *sqlx.DB) SyncRepository {
 	return &syncRepo{db: db}
 }
 
+// syncStateColumns sync_state 查询字段
+const syncStateColumns = `
+	id, chain_name, last_synced_block, last_confirmed_block, last_sync_at, status, error_message,
+	consecutive_failures, last_error_at, last_success_at, chain_head_block, head_lag_blocks,
+	last_synced_block_time, chain_head_time, events_processed,
+	created_at, updated_at
+`
+
 // GetSyncState 获取链的同步状态
 func (r *syncRepo) GetSyncState(ctx context.Context, chainName string) (*model.SyncState, error) {
-	query := `
-		SELECT id, chain_name, last_synced_block, last_confirmed_block, last_sync_at, status, error_message, created_at, updated_at
+	query := `SELECT ` + syncStateColumns + `
 		FROM sync_state
 		WHERE chain_name = $1
 	`
@@ -51,6 +67,21 @@ func (r *syncRepo) GetSyncState(ctx context.Context, chainName string) (*model.S
 	return &state, nil
 }
 
+// ListSyncStates 查询所有链的同步状态
+func (r *syncRepo) ListSyncStates(ctx context.Context) ([]*model.SyncState, error) {
+	query := `SELECT ` + syncStateColumns + `
+		FROM sync_state
+		ORDER BY chain_name ASC
+	`
+
+	var states []*model.SyncState
+	if err := r.db.SelectContext(ctx, &states, query); err != nil {
+		return nil, err
+	}
+
+	return states, nil
+}
+
 // UpdateSyncState 更新同步状态
 func (r *syncRepo) UpdateSyncState(ctx context.Context, state *model.SyncState) error {
 	query := `
@@ -60,18 +91,58 @@ func (r *syncRepo) UpdateSyncState(ctx context.Context, state *model.SyncState)
 			last_sync_at = $3,
 			status = $4,
 			error_message = $5,
+			consecutive_failures = $6,
+			last_success_at = $7,
+			chain_head_block = $8,
+			head_lag_blocks = $9,
+			last_synced_block_time = $10,
+			chain_head_time = $11,
+			events_processed = $12,
 			updated_at = NOW()
-		WHERE chain_name = $6
+		WHERE chain_name = $13
 		RETURNING updated_at
 	`
 
 	return r.db.QueryRowContext(
 		ctx, query,
 		state.LastSyncedBlock, state.LastConfirmedBlock, state.LastSyncAt,
-		state.Status, state.ErrorMessage, state.ChainName,
+		state.Status, state.ErrorMessage, state.ConsecutiveFailures,
+		state.LastSuccessAt, state.ChainHeadBlock, state.HeadLagBlocks,
+		state.LastSyncedBlockTime, state.ChainHeadTime, state.EventsProcessed,
+		state.ChainName,
 	).Scan(&state.UpdatedAt)
 }
 
+// RecordSyncError 记录扫描失败
+func (r *syncRepo) RecordSyncError(ctx context.Context, chainName, status, errMsg string, failures int) error {
+	query := `
+		UPDATE sync_state
+		SET status = $1,
+			error_message = $2,
+			consecutive_failures = $3,
+			last_error_at = NOW(),
+			updated_at = NOW()
+		WHERE chain_name = $4
+	`
+
+	_, err := r.db.ExecContext(ctx, query, status, errMsg, failures, chainName)
+	return err
+}
+
+// UpdateSyncStatus 更新运行状态
+func (r *syncRepo) UpdateSyncStatus(ctx context.Context, chainName, status string) error {
+	query := `
+		UPDATE sync_state
+		SET status = $1,
+			consecutive_failures = CASE WHEN $1 = 'running' THEN 0 ELSE consecutive_failures END,
+			updated_at = NOW()
+		WHERE chain_name = $2
+	`
+
+	_, err := r.db.ExecContext(ctx, query, status, chainName)
+	return err
+}
+
 // InitSyncState 初始化同步状态
 func (r *syncRepo) InitSyncState(ctx context.Context, chainName string, startBlock int64) error {
 	query := `
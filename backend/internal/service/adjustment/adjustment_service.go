@@ -0,0 +1,137 @@
+package adjustment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+)
+
+var (
+	// ErrInvalidAddress 地址格式错误
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrUnknownChain 链未配置
+	ErrUnknownChain = errors.New("unknown chain")
+	// ErrInvalidAmount 调整积分不能为 0
+	ErrInvalidAmount = errors.New("amount must be non-zero")
+	// ErrReasonRequired 缺少原因
+	ErrReasonRequired = errors.New("reason is required")
+	// ErrOperatorRequired 缺少操作人
+	ErrOperatorRequired = errors.New("operator is required")
+	// ErrAdjustmentNotFound 调整不存在
+	ErrAdjustmentNotFound = errors.New("adjustment not found")
+	// ErrAlreadyReverted 调整已撤销
+	ErrAlreadyReverted = errors.New("adjustment is already reverted")
+)
+
+// AdjustmentService 手动积分调整服务
+type AdjustmentService struct {
+	adjustmentRepo repository.AdjustmentRepository
+	chains         map[string]bool
+	logger         *logrus.Logger
+}
+
+// NewAdjustmentService 创建积分调整服务
+func NewAdjustmentService(
+	adjustmentRepo repository.AdjustmentRepository,
+	chainNames []string,
+	logger *logrus.Logger,
+) *AdjustmentService {
+	chains := make(map[string]bool, len(chainNames))
+	for _, name := range chainNames {
+		chains[name] = true
+	}
+
+	return &AdjustmentService{
+		adjustmentRepo: adjustmentRepo,
+		chains:         chains,
+		logger:         logger,
+	}
+}
+
+// Adjust 发放（amount > 0）或扣回（amount < 0）积分
+func (s *AdjustmentService) Adjust(ctx context.Context, chainName, address string, amount float64, reason, operator string) (*model.PointsAdjustment, error) {
+	if !s.chains[chainName] {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChain, chainName)
+	}
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	if amount == 0 {
+		return nil, ErrInvalidAmount
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrReasonRequired
+	}
+	if operator == "" {
+		return nil, ErrOperatorRequired
+	}
+
+	adjustment := &model.PointsAdjustment{
+		ChainName:   chainName,
+		UserAddress: strings.ToLower(address),
+		Amount:      amount,
+		Reason:      reason,
+		Operator:    operator,
+	}
+
+	if err := s.adjustmentRepo.CreateAdjustment(ctx, adjustment); err != nil {
+		return nil, fmt.Errorf("failed to create adjustment: %w", err)
+	}
+
+	s.logger.Infof("Points adjusted for %s on %s by %s: %+.4f (%s), adjustment #%d",
+		adjustment.UserAddress, chainName, operator, amount, reason, adjustment.ID)
+	return adjustment, nil
+}
+
+// Revert 撤销调整（写入反向积分历史，原记录保留）
+func (s *AdjustmentService) Revert(ctx context.Context, id int64, reason, operator string) (*model.PointsAdjustment, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrReasonRequired
+	}
+	if operator == "" {
+		return nil, ErrOperatorRequired
+	}
+
+	adjustment, reverted, err := s.adjustmentRepo.RevertAdjustment(ctx, id, reason, operator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revert adjustment: %w", err)
+	}
+	if adjustment == nil {
+		return nil, ErrAdjustmentNotFound
+	}
+	if !reverted {
+		return nil, ErrAlreadyReverted
+	}
+
+	s.logger.Infof("Adjustment #%d reverted by %s: %s (%+.4f points for %s on %s)",
+		id, operator, reason, -adjustment.Amount, adjustment.UserAddress, adjustment.ChainName)
+	return adjustment, nil
+}
+
+// GetAdjustment 查询调整
+func (s *AdjustmentService) GetAdjustment(ctx context.Context, id int64) (*model.PointsAdjustment, error) {
+	adjustment, err := s.adjustmentRepo.GetAdjustment(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get adjustment: %w", err)
+	}
+	if adjustment == nil {
+		return nil, ErrAdjustmentNotFound
+	}
+	return adjustment, nil
+}
+
+// ListAdjustments 分页查询调整记录
+func (s *AdjustmentService) ListAdjustments(ctx context.Context, chainName, address string, offset, limit int) ([]*model.PointsAdjustment, error) {
+	adjustments, err := s.adjustmentRepo.ListAdjustments(ctx, chainName, strings.ToLower(address), offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list adjustments: %w", err)
+	}
+	return adjustments, nil
+}
@@ -0,0 +1,77 @@
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+)
+
+var (
+	// ErrAnomalyNotFound 余额异常不存在或已核查
+	ErrAnomalyNotFound = errors.New("balance anomaly not found or already resolved")
+	// ErrOperatorRequired 缺少操作人
+	ErrOperatorRequired = errors.New("operator is required")
+)
+
+// AnomalyService 余额异常管理服务
+// 异常由 BalanceService 在余额变为负数时记录，这里只负责查询和人工核查
+type AnomalyService struct {
+	anomalyRepo repository.AnomalyRepository
+	logger      *logrus.Logger
+}
+
+// NewAnomalyService 创建余额异常管理服务
+func NewAnomalyService(
+	anomalyRepo repository.AnomalyRepository,
+	logger *logrus.Logger,
+) *AnomalyService {
+	return &AnomalyService{
+		anomalyRepo: anomalyRepo,
+		logger:      logger,
+	}
+}
+
+// ListAnomalies 分页查询余额异常
+func (s *AnomalyService) ListAnomalies(ctx context.Context, chainName, status string, offset, limit int) ([]*model.BalanceAnomaly, error) {
+	anomalies, err := s.anomalyRepo.ListAnomalies(ctx, chainName, status, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list balance anomalies: %w", err)
+	}
+	return anomalies, nil
+}
+
+// GetAnomaly 查询单个余额异常
+func (s *AnomalyService) GetAnomaly(ctx context.Context, id int64) (*model.BalanceAnomaly, error) {
+	anomaly, err := s.anomalyRepo.GetAnomaly(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance anomaly: %w", err)
+	}
+	if anomaly == nil {
+		return nil, ErrAnomalyNotFound
+	}
+	return anomaly, nil
+}
+
+// ResolveAnomaly 标记余额异常为已核查
+func (s *AnomalyService) ResolveAnomaly(ctx context.Context, id int64, note, operator string) (*model.BalanceAnomaly, error) {
+	if operator == "" {
+		return nil, ErrOperatorRequired
+	}
+
+	anomaly, err := s.anomalyRepo.ResolveAnomaly(ctx, id, operator, strings.TrimSpace(note))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve balance anomaly: %w", err)
+	}
+	if anomaly == nil {
+		return nil, ErrAnomalyNotFound
+	}
+
+	s.logger.Infof("Balance anomaly %d (%s on %s) resolved by %s", id, anomaly.UserAddress, anomaly.ChainName, operator)
+	return anomaly, nil
+}
@@ -2,6 +2,7 @@ package balance
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -9,118 +10,261 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"my-token-points/config"
 	"my-token-points/internal/model"
 	"my-token-points/internal/repository"
 )
 
 // BalanceUpdate 余额更新请求
 type BalanceUpdate struct {
-	ChainName   string
-	UserAddress string
-	TxHash      string
-	BlockNumber int64
-	BlockTime   time.Time
-	EventType   model.EventType
-	AmountDelta string // 可以是正数或负数（string格式的big.Int）
+	ChainName       string
+	UserAddress     string
+	TxHash          string
+	LogIndex        int
+	ContractAddress string
+	BlockNumber     int64
+	BlockTime       time.Time
+	EventType       model.EventType
+	BalanceType     model.BalanceType // 为空表示钱包余额
+	AmountDelta     string            // 可以是正数或负数（string格式的big.Int）
+	OnChain         BalanceReader     // 链上余额查询，reconcile 策略使用（离线重放时为空）
 }
 
+// BalanceReader 链上余额查询
+type BalanceReader interface {
+	// BalanceAt 查询代币合约中用户在指定区块结束时的余额
+	BalanceAt(ctx context.Context, contractAddress, userAddress string, blockNumber int64) (*big.Int, error)
+}
+
+// ErrNegativeBalance 事件使余额为负且策略为 reject
+var ErrNegativeBalance = errors.New("balance would become negative")
+
+// errChainBalanceNeeded reconcile 策略需要先在事务外查询链上余额
+var errChainBalanceNeeded = errors.New("on-chain balance is needed to reconcile")
+
 // BalanceService 余额服务
 type BalanceService struct {
-	balanceRepo repository.BalanceRepository
-	logger      *logrus.Logger
+	balanceRepo    repository.BalanceRepository
+	anomalyRepo    repository.AnomalyRepository
+	negativePolicy string
+	logger         *logrus.Logger
 }
 
-// NewBalanceService 创建余额服务
+// NewBalanceService 创建余额服务，negativePolicy 为余额变为负数时的处理策略（见 config.NegativeBalance*）
 func NewBalanceService(
 	balanceRepo repository.BalanceRepository,
+	anomalyRepo repository.AnomalyRepository,
+	negativePolicy string,
 	logger *logrus.Logger,
 ) *BalanceService {
 	return &BalanceService{
-		balanceRepo: balanceRepo,
-		logger:      logger,
+		balanceRepo:    balanceRepo,
+		anomalyRepo:    anomalyRepo,
+		negativePolicy: negativePolicy,
+		logger:         logger,
 	}
 }
 
 // UpdateBalance 更新用户余额
 func (s *BalanceService) UpdateBalance(ctx context.Context, update *BalanceUpdate) error {
-	// 标准化地址（转为小写）
-	userAddress := strings.ToLower(update.UserAddress)
+	return s.UpdateBalances(ctx, []*BalanceUpdate{update})
+}
 
-	// 解析变动金额
-	amountDelta := new(big.Int)
-	if _, ok := amountDelta.SetString(update.AmountDelta, 10); !ok {
-		return fmt.Errorf("invalid amount delta: %s", update.AmountDelta)
+// UpdateBalances 在一个事务中应用同一条日志产生的全部余额变动，任一变动失败时都不写入
+// 例如转账的扣减和入账必须一起成功，否则死信重试时已写入的扣减会与唯一键冲突
+func (s *BalanceService) UpdateBalances(ctx context.Context, updates []*BalanceUpdate) error {
+	if len(updates) == 0 {
+		return nil
 	}
 
-	// 获取当前余额
-	currentBalance, err := s.balanceRepo.GetUserBalance(ctx, update.ChainName, userAddress)
-	if err != nil {
-		return fmt.Errorf("failed to get user balance: %w", err)
-	}
+	// 解析变动金额并构造变动记录
+	changes := make([]*model.BalanceChange, 0, len(updates))
+	amounts := make([]*big.Int, 0, len(updates))
+	for _, update := range updates {
+		// 标准化地址（转为小写）
+		userAddress := strings.ToLower(update.UserAddress)
 
-	// 计算新余额
-	var balanceBefore, balanceAfter *big.Int
+		balanceType := update.BalanceType
+		if balanceType == "" {
+			balanceType = model.BalanceTypeWallet
+		}
 
-	if currentBalance == nil {
-		// 新用户
-		balanceBefore = big.NewInt(0)
-	} else {
-		balanceBefore = new(big.Int)
-		if _, ok := balanceBefore.SetString(currentBalance.Balance, 10); !ok {
-			return fmt.Errorf("invalid current balance: %s", currentBalance.Balance)
+		amountDelta := new(big.Int)
+		if _, ok := amountDelta.SetString(update.AmountDelta, 10); !ok {
+			return fmt.Errorf("invalid amount delta: %s", update.AmountDelta)
 		}
+
+		changes = append(changes, &model.BalanceChange{
+			ChainName:       update.ChainName,
+			UserAddress:     userAddress,
+			TxHash:          update.TxHash,
+			LogIndex:        update.LogIndex,
+			ContractAddress: strings.ToLower(update.ContractAddress),
+			BlockNumber:     update.BlockNumber,
+			BlockTime:       update.BlockTime,
+			EventType:       update.EventType,
+			BalanceType:     balanceType,
+			AmountDelta:     amountDelta.String(),
+			Confirmed:       true, // 直接标记为已确认，因为事件监听已经有6区块延迟
+		})
+		amounts = append(amounts, amountDelta)
 	}
 
-	balanceAfter = new(big.Int).Add(balanceBefore, amountDelta)
+	// reconcile 策略需要的链上余额在事务外查询，查询期间不持有行锁，查询后重新加锁计算
+	// 每次重试至少多一个已查询的变动，重试次数不超过变动数
+	chainBalances := make(map[int]*big.Int)
+	var anomalies []*model.BalanceAnomaly
+	var applied bool
+	var err error
+	for {
+		var needed int
+		applied, anomalies, needed, err = s.applyBalanceChanges(ctx, updates, changes, amounts, chainBalances)
+		if !errors.Is(err, errChainBalanceNeeded) {
+			break
+		}
 
-	// 余额不能为负
-	if balanceAfter.Sign() < 0 {
-		s.logger.Warnf("Negative balance detected for user %s on %s: before=%s, delta=%s, after=%s",
-			userAddress, update.ChainName, balanceBefore.String(), amountDelta.String(), balanceAfter.String())
-		// 根据业务需求，可以选择：
-		// 1. 返回错误
-		// 2. 将余额设置为 0
-		// 这里我们将余额设置为 0 并记录警告
-		balanceAfter = big.NewInt(0)
+		update := updates[needed]
+		chainBalance, queryErr := update.OnChain.BalanceAt(ctx, update.ContractAddress, changes[needed].UserAddress, update.BlockNumber-1)
+		if queryErr != nil {
+			return fmt.Errorf("failed to query on-chain balance: %w", queryErr)
+		}
+		chainBalances[needed] = chainBalance
 	}
-
-	// 记录余额变动（初始状态为未确认）
-	change := &model.BalanceChange{
-		ChainName:     update.ChainName,
-		UserAddress:   userAddress,
-		TxHash:        update.TxHash,
-		BlockNumber:   update.BlockNumber,
-		BlockTime:     update.BlockTime,
-		EventType:     update.EventType,
-		AmountDelta:   amountDelta.String(),
-		BalanceBefore: balanceBefore.String(),
-		BalanceAfter:  balanceAfter.String(),
-		Confirmed:     true, // 直接标记为已确认，因为事件监听已经有6区块延迟
+	if errors.Is(err, ErrNegativeBalance) {
+		// 拒绝时整条日志回滚，只记录被拒绝的变动（最后一个异常）
+		anomalies = anomalies[len(anomalies)-1:]
 	}
-
-	if err := s.balanceRepo.RecordBalanceChange(ctx, change); err != nil {
-		return fmt.Errorf("failed to record balance change: %w", err)
+	if err == nil || errors.Is(err, ErrNegativeBalance) {
+		// 余额已经按策略处理，异常记录失败不影响事件处理
+		for _, anomaly := range anomalies {
+			if recErr := s.anomalyRepo.RecordAnomaly(ctx, anomaly); recErr != nil {
+				s.logger.Errorf("Failed to record balance anomaly for %s on %s (tx %s#%d): %v",
+					anomaly.UserAddress, anomaly.ChainName, anomaly.TxHash, anomaly.LogIndex, recErr)
+			}
+		}
 	}
-
-	// 更新用户余额
-	newBalance := &model.UserBalance{
-		ChainName:       update.ChainName,
-		UserAddress:     userAddress,
-		Balance:         balanceAfter.String(),
-		LastUpdateBlock: update.BlockNumber,
-		LastUpdateTime:  update.BlockTime,
+	if err != nil {
+		return fmt.Errorf("failed to apply balance change: %w", err)
 	}
-
-	if err := s.balanceRepo.UpsertUserBalance(ctx, newBalance); err != nil {
-		return fmt.Errorf("failed to update user balance: %w", err)
+	if !applied {
+		// 重新扫描或死信重试时日志已处理过，余额不变，视为成功
+		s.logger.Debugf("Balance changes of %s#%d on %s already recorded, skipped",
+			changes[0].TxHash, changes[0].LogIndex, changes[0].ChainName)
+		return nil
 	}
 
-	s.logger.Debugf("Updated balance for %s on %s: %s -> %s (delta: %s)",
-		userAddress, update.ChainName, balanceBefore.String(), balanceAfter.String(), amountDelta.String())
+	for _, change := range changes {
+		s.logger.Debugf("Updated %s balance for %s on %s: %s -> %s (delta: %s)",
+			change.BalanceType, change.UserAddress, change.ChainName, change.BalanceBefore, change.BalanceAfter, change.AmountDelta)
+	}
 
 	return nil
 }
 
+// applyBalanceChanges 在余额行锁内读取当前余额并计算新余额，并发写入同一用户时不会丢失更新
+// reconcile 策略需要尚未查询的链上余额时回滚并返回 errChainBalanceNeeded 和对应变动的下标；
+// 日志的变动已写入时返回 false
+func (s *BalanceService) applyBalanceChanges(
+	ctx context.Context,
+	updates []*BalanceUpdate,
+	changes []*model.BalanceChange,
+	amounts []*big.Int,
+	chainBalances map[int]*big.Int,
+) (bool, []*model.BalanceAnomaly, int, error) {
+	var anomalies []*model.BalanceAnomaly
+	needed := -1
+	applied, err := s.balanceRepo.ApplyBalanceChanges(ctx, changes, func(i int, currentBalance *model.UserBalance) error {
+		update, change, amountDelta := updates[i], changes[i], amounts[i]
+
+		// 钱包余额和质押余额分别计算
+		current := currentBalance.Balance
+		if change.BalanceType == model.BalanceTypeStaked {
+			current = currentBalance.StakedBalance
+		}
+		balanceBefore := new(big.Int)
+		if _, ok := balanceBefore.SetString(current, 10); !ok {
+			return fmt.Errorf("invalid current %s balance: %s", change.BalanceType, current)
+		}
+
+		balanceAfter := new(big.Int).Add(balanceBefore, amountDelta)
+
+		// 余额不能为负，按策略处理并记录异常
+		if balanceAfter.Sign() < 0 {
+			s.logger.Warnf("Negative %s balance detected for user %s on %s: before=%s, delta=%s, after=%s (policy: %s)",
+				change.BalanceType, change.UserAddress, change.ChainName, balanceBefore.String(), amountDelta.String(), balanceAfter.String(), s.negativePolicy)
+
+			anomaly := &model.BalanceAnomaly{
+				ChainName:       change.ChainName,
+				UserAddress:     change.UserAddress,
+				TxHash:          change.TxHash,
+				LogIndex:        change.LogIndex,
+				BlockNumber:     change.BlockNumber,
+				BalanceType:     change.BalanceType,
+				BalanceBefore:   balanceBefore.String(),
+				AmountDelta:     amountDelta.String(),
+				ComputedBalance: balanceAfter.String(),
+			}
+			anomalies = append(anomalies, anomaly)
+			resolved, err := s.resolveNegativeBalance(update, change.UserAddress, change.BalanceType, amountDelta, chainBalances[i], anomaly)
+			if errors.Is(err, errChainBalanceNeeded) {
+				needed = i
+			}
+			if err != nil {
+				return err
+			}
+			balanceAfter = resolved
+			after := balanceAfter.String()
+			anomaly.BalanceAfter = &after
+		}
+
+		change.BalanceBefore = balanceBefore.String()
+		change.BalanceAfter = balanceAfter.String()
+		return nil
+	})
+	return applied, anomalies, needed, err
+}
+
+// resolveNegativeBalance 按策略处理为负的余额，返回最终写入的余额并填写异常的处理方式
+// chainBalance 为事件所在区块之前的链上余额，reconcile 策略需要但尚未查询时返回 errChainBalanceNeeded
+func (s *BalanceService) resolveNegativeBalance(
+	update *BalanceUpdate,
+	userAddress string,
+	balanceType model.BalanceType,
+	amountDelta *big.Int,
+	chainBalance *big.Int,
+	anomaly *model.BalanceAnomaly,
+) (*big.Int, error) {
+	switch s.negativePolicy {
+	case config.NegativeBalanceReject:
+		anomaly.Action = model.AnomalyActionRejected
+		return nil, fmt.Errorf("%w: %s balance of %s on %s would be %s",
+			ErrNegativeBalance, balanceType, userAddress, update.ChainName, anomaly.ComputedBalance)
+
+	case config.NegativeBalanceReconcile:
+		// 钱包余额的变动来自代币合约本身，查询事件所在区块之前的链上余额再加上本次变动；
+		// 质押余额无法通过 balanceOf 得到，离线重放没有链上查询，都退化为 clamp
+		if balanceType == model.BalanceTypeStaked || update.OnChain == nil {
+			break
+		}
+		if chainBalance == nil {
+			return nil, errChainBalanceNeeded
+		}
+		reconciled := new(big.Int).Add(chainBalance, amountDelta)
+		if reconciled.Sign() >= 0 {
+			chainBefore := chainBalance.String()
+			anomaly.Action = model.AnomalyActionReconciled
+			anomaly.ChainBalance = &chainBefore
+			s.logger.Warnf("Reconciled %s balance of %s on %s from chain: %s -> %s",
+				balanceType, userAddress, update.ChainName, anomaly.BalanceBefore, reconciled.String())
+			return reconciled, nil
+		}
+		s.logger.Warnf("On-chain balance of %s on %s is still negative after delta, clamping to zero", userAddress, update.ChainName)
+	}
+
+	anomaly.Action = model.AnomalyActionClamped
+	return big.NewInt(0), nil
+}
+
 // GetUserBalance 查询用户余额
 func (s *BalanceService) GetUserBalance(ctx context.Context, chainName, userAddress string) (*model.UserBalance, error) {
 	userAddress = strings.ToLower(userAddress)
@@ -133,6 +277,16 @@ func (s *BalanceService) GetBalanceChanges(ctx context.Context, chainName, userA
 	return s.balanceRepo.GetBalanceChanges(ctx, chainName, userAddress, startTime, endTime)
 }
 
+// ResetChainBalances 清空链上所有余额及余额变动，之后需要重放事件重建
+func (s *BalanceService) ResetChainBalances(ctx context.Context, chainName string) error {
+	if err := s.balanceRepo.DeleteChainBalances(ctx, chainName); err != nil {
+		return fmt.Errorf("failed to delete chain balances: %w", err)
+	}
+
+	s.logger.Warnf("Reset all balances and balance changes on %s", chainName)
+	return nil
+}
+
 // RebuildBalance 重建用户余额（从某个区块开始重新计算）
 func (s *BalanceService) RebuildBalance(ctx context.Context, chainName, userAddress string, fromBlock int64) error {
 	userAddress = strings.ToLower(userAddress)
@@ -156,8 +310,9 @@ func (s *BalanceService) RebuildBalance(ctx context.Context, chainName, userAddr
 		return nil
 	}
 
-	// 重新计算余额
+	// 重新计算余额（钱包余额和质押余额分别累加）
 	balance := big.NewInt(0)
+	stakedBalance := big.NewInt(0)
 	var lastBlock int64
 	var lastTime time.Time
 
@@ -167,11 +322,16 @@ func (s *BalanceService) RebuildBalance(ctx context.Context, chainName, userAddr
 			return fmt.Errorf("invalid amount delta in change %d: %s", change.ID, change.AmountDelta)
 		}
 
-		balance.Add(balance, delta)
+		if change.BalanceType == model.BalanceTypeStaked {
+			stakedBalance.Add(stakedBalance, delta)
+		} else {
+			balance.Add(balance, delta)
+		}
 		lastBlock = change.BlockNumber
 		lastTime = change.BlockTime
 
-		s.logger.Debugf("Rebuild: block=%d, delta=%s, balance=%s", change.BlockNumber, delta.String(), balance.String())
+		s.logger.Debugf("Rebuild: block=%d, type=%s, delta=%s, balance=%s, staked=%s",
+			change.BlockNumber, change.BalanceType, delta.String(), balance.String(), stakedBalance.String())
 	}
 
 	// 更新余额
@@ -179,6 +339,7 @@ func (s *BalanceService) RebuildBalance(ctx context.Context, chainName, userAddr
 		ChainName:       chainName,
 		UserAddress:     userAddress,
 		Balance:         balance.String(),
+		StakedBalance:   stakedBalance.String(),
 		LastUpdateBlock: lastBlock,
 		LastUpdateTime:  lastTime,
 	}
@@ -187,9 +348,8 @@ func (s *BalanceService) RebuildBalance(ctx context.Context, chainName, userAddr
 		return fmt.Errorf("failed to update rebuilt balance: %w", err)
 	}
 
-	s.logger.Infof("Rebuilt balance for %s on %s: %s (processed %d changes)",
-		userAddress, chainName, balance.String(), len(userChanges))
+	s.logger.Infof("Rebuilt balance for %s on %s: %s, staked %s (processed %d changes)",
+		userAddress, chainName, balance.String(), stakedBalance.String(), len(userChanges))
 
 	return nil
 }
-
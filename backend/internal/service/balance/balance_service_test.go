@@ -0,0 +1,212 @@
+package balance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/config"
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+)
+
+// lockingBalanceRepo 记录回调是否处于行锁事务中的余额仓储，所有余额为零
+type lockingBalanceRepo struct {
+	repository.BalanceRepository
+	locked  bool
+	applied []*model.BalanceChange
+}
+
+func (r *lockingBalanceRepo) ApplyBalanceChanges(ctx context.Context, changes []*model.BalanceChange, apply func(i int, current *model.UserBalance) error) (bool, error) {
+	r.locked = true
+	defer func() { r.locked = false }()
+
+	for i := range changes {
+		if err := apply(i, &model.UserBalance{Balance: "0", StakedBalance: "0"}); err != nil {
+			return false, err
+		}
+	}
+	r.applied = changes
+	return true, nil
+}
+
+// memoryAnomalyRepo 在内存中记录余额异常
+type memoryAnomalyRepo struct {
+	repository.AnomalyRepository
+	anomalies []*model.BalanceAnomaly
+}
+
+func (r *memoryAnomalyRepo) RecordAnomaly(ctx context.Context, anomaly *model.BalanceAnomaly) error {
+	r.anomalies = append(r.anomalies, anomaly)
+	return nil
+}
+
+// lockCheckingReader 链上余额查询，在行锁事务中被调用时测试失败
+type lockCheckingReader struct {
+	t       *testing.T
+	repo    *lockingBalanceRepo
+	balance int64
+	calls   int
+}
+
+func (r *lockCheckingReader) BalanceAt(ctx context.Context, contractAddress, userAddress string, blockNumber int64) (*big.Int, error) {
+	if r.repo.locked {
+		r.t.Error("BalanceAt called while holding the balance row lock")
+	}
+	r.calls++
+	return big.NewInt(r.balance), nil
+}
+
+func TestReconcileQueriesChainOutsideLock(t *testing.T) {
+	repo := &lockingBalanceRepo{}
+	anomalies := &memoryAnomalyRepo{}
+	reader := &lockCheckingReader{t: t, repo: repo, balance: 100}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	service := NewBalanceService(repo, anomalies, config.NegativeBalanceReconcile, logger)
+
+	err := service.UpdateBalance(context.Background(), &BalanceUpdate{
+		ChainName:   "testnet",
+		UserAddress: "0x00000000000000000000000000000000000000AA",
+		TxHash:      "0x01",
+		BlockNumber: 10,
+		BlockTime:   time.Now().UTC(),
+		EventType:   model.EventTypeTransferOut,
+		AmountDelta: "-40",
+		OnChain:     reader,
+	})
+	if err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+
+	if reader.calls != 1 {
+		t.Errorf("BalanceAt called %d times, want 1", reader.calls)
+	}
+	if len(repo.applied) != 1 || repo.applied[0].BalanceBefore != "0" || repo.applied[0].BalanceAfter != "60" {
+		t.Fatalf("applied changes = %+v, want 0 -> 60", repo.applied)
+	}
+	if len(anomalies.anomalies) != 1 || anomalies.anomalies[0].Action != model.AnomalyActionReconciled {
+		t.Errorf("anomalies = %+v, want one reconciled anomaly", anomalies.anomalies)
+	}
+}
+
+// testDB 连接 TEST_DATABASE_DSN 指定的数据库（需已执行全部迁移），未设置时跳过测试
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestConcurrentUpdateBalance(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	// 每次运行使用独立的链名，结束后清理
+	chainName := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	const userAddress = "0x00000000000000000000000000000000000000aa"
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM balance_changes WHERE chain_name = $1`, chainName)
+		db.Exec(`DELETE FROM balance_anomalies WHERE chain_name = $1`, chainName)
+		db.Exec(`DELETE FROM user_balances WHERE chain_name = $1`, chainName)
+	})
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	service := NewBalanceService(repository.NewBalanceRepository(db), repository.NewAnomalyRepository(db),
+		config.NegativeBalanceReject, logger)
+
+	update := func(logIndex int, eventType model.EventType, delta string) *BalanceUpdate {
+		return &BalanceUpdate{
+			ChainName:       chainName,
+			UserAddress:     userAddress,
+			TxHash:          fmt.Sprintf("0x%064x", logIndex),
+			LogIndex:        logIndex,
+			ContractAddress: "0x5CCEC1a2039Dd249B376033feB2d5479482614bb",
+			BlockNumber:     int64(logIndex),
+			BlockTime:       time.Now().UTC(),
+			EventType:       eventType,
+			AmountDelta:     delta,
+		}
+	}
+
+	if err := service.UpdateBalance(ctx, update(0, model.EventTypeMint, "1000")); err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+
+	// 并发入账和扣减，任何执行顺序下余额都不会为负
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 1; i <= workers; i++ {
+		u := update(i, model.EventTypeTransferIn, "10")
+		if i%2 == 0 {
+			u = update(i, model.EventTypeTransferOut, "-5")
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- service.UpdateBalance(ctx, u)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("UpdateBalance: %v", err)
+		}
+	}
+
+	current, err := repository.NewBalanceRepository(db).GetUserBalance(ctx, chainName, userAddress)
+	if err != nil {
+		t.Fatalf("GetUserBalance: %v", err)
+	}
+	if want := fmt.Sprint(1000 + workers/2*10 - workers/2*5); current == nil || current.Balance != want {
+		t.Fatalf("balance = %+v, want %s", current, want)
+	}
+
+	// 变动在行锁下依次写入：每条的变动前余额等于上一条的变动后余额
+	var changes []*model.BalanceChange
+	if err := db.SelectContext(ctx, &changes, `
+		SELECT id, amount_delta, balance_before, balance_after
+		FROM balance_changes
+		WHERE chain_name = $1 AND user_address = $2
+		ORDER BY id
+	`, chainName, userAddress); err != nil {
+		t.Fatalf("failed to list balance changes: %v", err)
+	}
+	if len(changes) != workers+1 {
+		t.Fatalf("got %d balance changes, want %d", len(changes), workers+1)
+	}
+
+	previous := "0"
+	for _, change := range changes {
+		if change.BalanceBefore != previous {
+			t.Errorf("change %d: balance_before = %s, want %s", change.ID, change.BalanceBefore, previous)
+		}
+		previous = change.BalanceAfter
+	}
+	if previous != current.Balance {
+		t.Errorf("last balance_after = %s, want %s", previous, current.Balance)
+	}
+}
@@ -0,0 +1,82 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+)
+
+// ErrFailedEventNotFound 死信不存在或已处理
+var ErrFailedEventNotFound = errors.New("failed event not found or already resolved")
+
+// DeadLetterService 死信管理服务
+// 实际的重试由各链的 EventListener 完成，这里只负责查询和调整死信状态
+type DeadLetterService struct {
+	failedEventRepo repository.FailedEventRepository
+	logger          *logrus.Logger
+}
+
+// NewDeadLetterService 创建死信管理服务
+func NewDeadLetterService(
+	failedEventRepo repository.FailedEventRepository,
+	logger *logrus.Logger,
+) *DeadLetterService {
+	return &DeadLetterService{
+		failedEventRepo: failedEventRepo,
+		logger:          logger,
+	}
+}
+
+// ListFailedEvents 分页查询死信
+func (s *DeadLetterService) ListFailedEvents(ctx context.Context, chainName, status string, offset, limit int) ([]*model.FailedEvent, error) {
+	events, err := s.failedEventRepo.ListFailedEvents(ctx, chainName, status, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed events: %w", err)
+	}
+	return events, nil
+}
+
+// GetFailedEvent 查询单个死信
+func (s *DeadLetterService) GetFailedEvent(ctx context.Context, id int64) (*model.FailedEvent, error) {
+	event, err := s.failedEventRepo.GetFailedEvent(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed event: %w", err)
+	}
+	if event == nil {
+		return nil, ErrFailedEventNotFound
+	}
+	return event, nil
+}
+
+// RetryFailedEvent 将死信放回队列，监听器会在下一轮重试中处理
+func (s *DeadLetterService) RetryFailedEvent(ctx context.Context, id int64) error {
+	ok, err := s.failedEventRepo.RequeueFailedEvent(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to requeue failed event: %w", err)
+	}
+	if !ok {
+		return ErrFailedEventNotFound
+	}
+
+	s.logger.Infof("Failed event %d requeued for retry", id)
+	return nil
+}
+
+// DiscardFailedEvent 丢弃死信
+func (s *DeadLetterService) DiscardFailedEvent(ctx context.Context, id int64) error {
+	ok, err := s.failedEventRepo.DiscardFailedEvent(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to discard failed event: %w", err)
+	}
+	if !ok {
+		return ErrFailedEventNotFound
+	}
+
+	s.logger.Infof("Failed event %d discarded", id)
+	return nil
+}
@@ -0,0 +1,217 @@
+package exclusion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/config"
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+)
+
+var (
+	// ErrInvalidAddress 地址格式错误
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrOperatorRequired 缺少操作人
+	ErrOperatorRequired = errors.New("operator is required")
+	// ErrAlreadyExcluded 地址已在排除列表中
+	ErrAlreadyExcluded = errors.New("address is already excluded")
+	// ErrNotExcluded 地址不在数据库排除列表中
+	ErrNotExcluded = errors.New("address is not in the exclusion list")
+	// ErrStaticExclusion 内置或配置文件中的地址不能通过接口移除
+	ErrStaticExclusion = errors.New("address is excluded by built-in rules or config file")
+)
+
+// builtinExcludedAddresses 所有链都排除的地址（零地址和常用的销毁地址）
+var builtinExcludedAddresses = []config.ExcludedAddressConfig{
+	{Address: "0x0000000000000000000000000000000000000000", Reason: "zero address"},
+	{Address: "0x000000000000000000000000000000000000dead", Reason: "burn address"},
+}
+
+// ExclusionService 积分排除地址服务
+// 排除地址来自三部分：内置地址、配置文件和数据库（管理接口维护）
+type ExclusionService struct {
+	exclusionRepo repository.ExclusionRepository
+	configured    []config.ExcludedAddressConfig
+	logger        *logrus.Logger
+}
+
+// NewExclusionService 创建排除地址服务
+func NewExclusionService(
+	exclusionRepo repository.ExclusionRepository,
+	configured []config.ExcludedAddressConfig,
+	logger *logrus.Logger,
+) *ExclusionService {
+	return &ExclusionService{
+		exclusionRepo: exclusionRepo,
+		configured:    configured,
+		logger:        logger,
+	}
+}
+
+// ListExcludedAddresses 查询链上生效的排除地址（chainName 为空时返回全部）
+func (s *ExclusionService) ListExcludedAddresses(ctx context.Context, chainName string) ([]*model.ExcludedAddress, error) {
+	var addresses []*model.ExcludedAddress
+
+	for _, excluded := range builtinExcludedAddresses {
+		addresses = append(addresses, &model.ExcludedAddress{
+			Address: excluded.Address,
+			Reason:  excluded.Reason,
+			Source:  model.ExclusionSourceBuiltin,
+		})
+	}
+
+	for _, excluded := range s.configured {
+		if chainName != "" && excluded.Chain != "" && excluded.Chain != chainName {
+			continue
+		}
+		addresses = append(addresses, &model.ExcludedAddress{
+			ChainName: excluded.Chain,
+			Address:   strings.ToLower(excluded.Address),
+			Reason:    excluded.Reason,
+			Source:    model.ExclusionSourceConfig,
+		})
+	}
+
+	stored, err := s.exclusionRepo.ListExcludedAddresses(ctx, chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list excluded addresses: %w", err)
+	}
+	for _, excluded := range stored {
+		excluded.Source = model.ExclusionSourceDatabase
+		addresses = append(addresses, excluded)
+	}
+
+	return addresses, nil
+}
+
+// ExcludedSet 返回链上生效的排除地址集合（小写地址）
+func (s *ExclusionService) ExcludedSet(ctx context.Context, chainName string) (map[string]bool, error) {
+	addresses, err := s.ListExcludedAddresses(ctx, chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool, len(addresses))
+	for _, excluded := range addresses {
+		set[excluded.Address] = true
+	}
+	return set, nil
+}
+
+// ExcludedList 返回链上生效的排除地址列表（小写地址）
+func (s *ExclusionService) ExcludedList(ctx context.Context, chainName string) ([]string, error) {
+	set, err := s.ExcludedSet(ctx, chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]string, 0, len(set))
+	for address := range set {
+		list = append(list, address)
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// AddExcludedAddress 添加排除地址（chainName 为空表示所有链）
+func (s *ExclusionService) AddExcludedAddress(ctx context.Context, chainName, address, reason, operator string) (*model.ExcludedAddress, error) {
+	address, err := normalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if operator == "" {
+		return nil, ErrOperatorRequired
+	}
+
+	excluded := &model.ExcludedAddress{
+		ChainName: chainName,
+		Address:   address,
+		Reason:    reason,
+		CreatedBy: operator,
+		Source:    model.ExclusionSourceDatabase,
+	}
+
+	created, err := s.exclusionRepo.AddExcludedAddress(ctx, excluded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add excluded address: %w", err)
+	}
+	if !created {
+		return nil, ErrAlreadyExcluded
+	}
+
+	s.logger.Infof("Address %s excluded from points on %s by %s: %s", address, chainLabel(chainName), operator, reason)
+	return excluded, nil
+}
+
+// RemoveExcludedAddress 移除数据库中的排除地址
+func (s *ExclusionService) RemoveExcludedAddress(ctx context.Context, chainName, address, reason, operator string) error {
+	address, err := normalizeAddress(address)
+	if err != nil {
+		return err
+	}
+	if operator == "" {
+		return ErrOperatorRequired
+	}
+
+	removed, err := s.exclusionRepo.RemoveExcludedAddress(ctx, chainName, address, reason, operator)
+	if err != nil {
+		return fmt.Errorf("failed to remove excluded address: %w", err)
+	}
+	if !removed {
+		if s.isStaticExclusion(chainName, address) {
+			return ErrStaticExclusion
+		}
+		return ErrNotExcluded
+	}
+
+	s.logger.Infof("Address %s removed from exclusion list on %s by %s: %s", address, chainLabel(chainName), operator, reason)
+	return nil
+}
+
+// ListAudit 分页查询排除地址审计记录
+func (s *ExclusionService) ListAudit(ctx context.Context, chainName, address string, offset, limit int) ([]*model.ExcludedAddressAudit, error) {
+	audits, err := s.exclusionRepo.ListExclusionAudit(ctx, chainName, strings.ToLower(address), offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exclusion audit: %w", err)
+	}
+	return audits, nil
+}
+
+// isStaticExclusion 判断地址是否由内置规则或配置文件排除
+func (s *ExclusionService) isStaticExclusion(chainName, address string) bool {
+	for _, excluded := range builtinExcludedAddresses {
+		if excluded.Address == address {
+			return true
+		}
+	}
+	for _, excluded := range s.configured {
+		if excluded.Chain == chainName && strings.EqualFold(excluded.Address, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAddress 校验并标准化地址（转小写）
+func normalizeAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if !common.IsHexAddress(address) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	return strings.ToLower(address), nil
+}
+
+// chainLabel 日志中显示的链名称
+func chainLabel(chainName string) string {
+	if chainName == "" {
+		return "all chains"
+	}
+	return chainName
+}
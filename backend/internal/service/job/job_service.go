@@ -0,0 +1,476 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/points"
+)
+
+const (
+	// pollInterval 查询待执行任务的间隔
+	pollInterval = 10 * time.Second
+	// heartbeatInterval 执行中任务的心跳间隔
+	heartbeatInterval = 15 * time.Second
+	// staleAfter 心跳超过该时长的执行中任务视为执行进程已退出，可由其他进程续跑
+	staleAfter = 2 * time.Minute
+	// claimBatchSize 每次查询的可认领任务数
+	claimBatchSize = 10
+)
+
+var (
+	// ErrUnknownChain 链未配置
+	ErrUnknownChain = errors.New("unknown chain")
+	// ErrInvalidJob 任务参数错误
+	ErrInvalidJob = errors.New("invalid job")
+	// ErrOperatorRequired 缺少操作人
+	ErrOperatorRequired = errors.New("operator is required")
+	// ErrChainBusy 链上已有未结束的任务
+	ErrChainBusy = errors.New("another job is already active on this chain")
+	// ErrJobNotFound 任务不存在
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobFinished 任务已结束，不能取消
+	ErrJobFinished = errors.New("job has already finished")
+)
+
+// JobService 后台任务服务：记录回溯和重算任务的参数、进度和结果，
+// 支持取消以及进程重启后从最后完成的周期续跑，同一条链同时只能有一个未结束的任务
+type JobService struct {
+	jobRepo       repository.JobRepository
+	pointsService *points.PointsService
+	chains        map[string]bool
+	workerID      string
+	wake          chan struct{}
+	logger        *logrus.Logger
+}
+
+// NewJobService 创建后台任务服务
+func NewJobService(
+	jobRepo repository.JobRepository,
+	pointsService *points.PointsService,
+	chainNames []string,
+	logger *logrus.Logger,
+) *JobService {
+	chains := make(map[string]bool, len(chainNames))
+	for _, name := range chainNames {
+		chains[name] = true
+	}
+
+	hostname, _ := os.Hostname()
+	return &JobService{
+		jobRepo:       jobRepo,
+		pointsService: pointsService,
+		chains:        chains,
+		workerID:      fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		wake:          make(chan struct{}, 1),
+		logger:        logger,
+	}
+}
+
+// NewBackfillJob 校验参数并构造回溯任务（不写入）
+func (s *JobService) NewBackfillJob(chainName string, start, end time.Time, operator string) (*model.Job, error) {
+	if !s.chains[chainName] {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChain, chainName)
+	}
+	if operator == "" {
+		return nil, ErrOperatorRequired
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end_time must be after start_time", ErrInvalidJob)
+	}
+
+	return &model.Job{
+		JobType:   model.JobTypeBackfill,
+		ChainName: chainName,
+		Params:    model.JobParams{StartTime: start.UTC(), EndTime: end.UTC()},
+		CreatedBy: operator,
+	}, nil
+}
+
+// NewRecalculationJob 校验参数并构造重算任务（不写入），重算记录在任务开始执行时创建
+func (s *JobService) NewRecalculationJob(chainName string, start, end time.Time, reason, operator string) (*model.Job, error) {
+	if !s.chains[chainName] {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChain, chainName)
+	}
+	if operator == "" {
+		return nil, ErrOperatorRequired
+	}
+	recalculation, err := s.pointsService.NewRecalculation(chainName, start, end, reason, operator)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+
+	return &model.Job{
+		JobType:   model.JobTypeRecalculation,
+		ChainName: chainName,
+		Params: model.JobParams{
+			StartTime: recalculation.PeriodStart,
+			EndTime:   recalculation.PeriodEnd,
+			Reason:    recalculation.Reason,
+		},
+		CreatedBy: operator,
+	}, nil
+}
+
+// Submit 提交任务，由 Run 启动的执行进程异步执行
+func (s *JobService) Submit(ctx context.Context, job *model.Job) error {
+	job.Status = model.JobStatusPending
+	job.WorkerID = nil
+	if err := s.create(ctx, job); err != nil {
+		return err
+	}
+
+	s.logger.Infof("Job %d (%s on %s) submitted by %s", job.ID, job.JobType, job.ChainName, job.CreatedBy)
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Execute 在当前进程中立即执行任务（命令行使用），返回时 job 为任务的最终状态
+// ctx 被取消时任务回到 pending 状态，可由服务进程续跑
+func (s *JobService) Execute(ctx context.Context, job *model.Job) error {
+	job.Status = model.JobStatusRunning
+	job.WorkerID = &s.workerID
+	if err := s.create(ctx, job); err != nil {
+		return err
+	}
+
+	s.run(ctx, job)
+
+	final, err := s.Get(context.WithoutCancel(ctx), job.ID)
+	if err != nil {
+		return err
+	}
+	*job = *final
+	return nil
+}
+
+// create 写入任务，链上已有未结束的任务时返回 ErrChainBusy
+func (s *JobService) create(ctx context.Context, job *model.Job) error {
+	created, err := s.jobRepo.CreateJob(ctx, job)
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	if created {
+		return nil
+	}
+
+	active, err := s.jobRepo.GetActiveJob(ctx, job.ChainName)
+	if err != nil {
+		return fmt.Errorf("failed to get active job: %w", err)
+	}
+	if active == nil {
+		// 活跃任务刚好结束
+		return ErrChainBusy
+	}
+	return fmt.Errorf("%w: job %d (%s) is %s", ErrChainBusy, active.ID, active.JobType, active.Status)
+}
+
+// Get 按 ID 查询任务
+func (s *JobService) Get(ctx context.Context, id int64) (*model.Job, error) {
+	job, err := s.jobRepo.GetJob(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// List 分页查询任务（chainName、status 为空表示不过滤）
+func (s *JobService) List(ctx context.Context, chainName, status string, offset, limit int) ([]*model.Job, error) {
+	return s.jobRepo.ListJobs(ctx, chainName, status, offset, limit)
+}
+
+// Cancel 取消任务：待执行的任务立即取消，执行中的任务在当前周期完成后停止
+func (s *JobService) Cancel(ctx context.Context, id int64) (*model.Job, error) {
+	job, err := s.jobRepo.RequestCancel(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	if job == nil {
+		if _, err := s.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrJobFinished
+	}
+
+	s.logger.Infof("Cancellation requested for job %d (%s on %s)", job.ID, job.JobType, job.ChainName)
+
+	// 停机时释放的重算任务已有未完成的重算记录
+	if job.Status == model.JobStatusCancelled {
+		s.cancelRecalculation(ctx, job)
+	}
+	return job, nil
+}
+
+// Run 执行任务，阻塞直到 ctx 被取消且当前任务已释放
+// 任务按提交顺序逐个执行；执行进程退出后心跳超时的任务会被重新认领并续跑
+func (s *JobService) Run(ctx context.Context) {
+	s.logger.Infof("Job worker %s started", s.workerID)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.runClaimable(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.logger.Infof("Job worker %s stopped", s.workerID)
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// runClaimable 认领并执行可执行的任务
+func (s *JobService) runClaimable(ctx context.Context) {
+	staleBefore := time.Now().Add(-staleAfter)
+	jobs, err := s.jobRepo.ListClaimableJobs(ctx, staleBefore, claimBatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Errorf("Failed to list claimable jobs: %v", err)
+		}
+		return
+	}
+
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		claimed, err := s.jobRepo.ClaimJob(ctx, job.ID, s.workerID, staleBefore)
+		if err != nil {
+			s.logger.Errorf("Failed to claim job %d: %v", job.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if job.Status == model.JobStatusRunning {
+			s.logger.Warnf("Resuming job %d (%s on %s) abandoned by %s",
+				job.ID, job.JobType, job.ChainName, stringValue(job.WorkerID))
+		}
+		job.Status = model.JobStatusRunning
+		job.WorkerID = &s.workerID
+		s.run(ctx, job)
+	}
+}
+
+// run 执行已认领的任务并记录结果
+func (s *JobService) run(ctx context.Context, job *model.Job) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var cancelRequested, lost atomic.Bool
+	cancelRequested.Store(job.CancelRequested)
+
+	// 心跳：刷新存活时间，检查取消请求和任务归属
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			owned, requested, err := s.jobRepo.Heartbeat(runCtx, job.ID, s.workerID)
+			if err != nil {
+				if runCtx.Err() == nil {
+					s.logger.Errorf("Failed to heartbeat job %d: %v", job.ID, err)
+				}
+				continue
+			}
+			if !owned {
+				lost.Store(true)
+				cancel()
+				return
+			}
+			if requested {
+				cancelRequested.Store(true)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	onProgress := func(done, total int, cursor time.Time) {
+		job.ProgressDone, job.ProgressTotal, job.Cursor = done, total, &cursor
+		owned, err := s.jobRepo.UpdateProgress(runCtx, job.ID, s.workerID, done, total, &cursor)
+		if err != nil {
+			if runCtx.Err() == nil {
+				s.logger.Errorf("Failed to update progress of job %d: %v", job.ID, err)
+			}
+			return
+		}
+		if !owned {
+			lost.Store(true)
+			cancel()
+		}
+	}
+
+	s.logger.Infof("Running job %d (%s on %s, %s to %s)", job.ID, job.JobType, job.ChainName,
+		job.Params.StartTime.Format(time.RFC3339), job.Params.EndTime.Format(time.RFC3339))
+
+	// 取消请求在执行进程退出期间提交的任务不再执行
+	executed := !cancelRequested.Load()
+	var err error
+	if executed {
+		err = s.execute(runCtx, job, onProgress)
+	}
+	cancel()
+	wg.Wait()
+
+	// 任务结果必须记录，不能使用已取消的 ctx
+	finishCtx := context.WithoutCancel(ctx)
+	switch {
+	case lost.Load():
+		s.logger.Warnf("Job %d was taken over by another worker, stopped", job.ID)
+	case executed && err == nil:
+		s.finish(finishCtx, job, model.JobStatusCompleted, nil)
+	case cancelRequested.Load():
+		s.cancelRecalculation(finishCtx, job)
+		s.finish(finishCtx, job, model.JobStatusCancelled, nil)
+	case ctx.Err() != nil:
+		if releaseErr := s.jobRepo.ReleaseJob(finishCtx, job.ID, s.workerID); releaseErr != nil {
+			s.logger.Errorf("Failed to release job %d: %v", job.ID, releaseErr)
+			return
+		}
+		s.logger.Infof("Job %d released at %d/%d, will resume on restart", job.ID, job.ProgressDone, job.ProgressTotal)
+	default:
+		message := err.Error()
+		s.finish(finishCtx, job, model.JobStatusFailed, &message)
+	}
+}
+
+// execute 按任务类型执行
+func (s *JobService) execute(ctx context.Context, job *model.Job, onProgress points.ProgressFunc) error {
+	switch job.JobType {
+	case model.JobTypeBackfill:
+		return s.executeBackfill(ctx, job, onProgress)
+	case model.JobTypeRecalculation:
+		return s.executeRecalculation(ctx, job, onProgress)
+	default:
+		return fmt.Errorf("%w: unknown job type %s", ErrInvalidJob, job.JobType)
+	}
+}
+
+// executeBackfill 执行回溯，续跑时从最后完成的周期之后开始
+// 进度按整个任务时间段计算：游标之前的周期均已完成（包括之前已计算、本次跳过的周期），
+// 续跑时不会重复计数游标之后已计算的周期
+func (s *JobService) executeBackfill(ctx context.Context, job *model.Job, onProgress points.ProgressFunc) error {
+	start := job.Params.StartTime
+	if job.Cursor != nil && job.Cursor.After(start) {
+		start = *job.Cursor
+	}
+
+	total := s.pointsService.PeriodCount(job.ChainName, job.Params.StartTime, job.Params.EndTime)
+	err := s.pointsService.BackfillPoints(ctx, job.ChainName, start, job.Params.EndTime,
+		func(_, _ int, cursor time.Time) {
+			onProgress(s.pointsService.PeriodCount(job.ChainName, job.Params.StartTime, cursor), total, cursor)
+		})
+	if err != nil {
+		return err
+	}
+
+	onProgress(total, total, job.Params.EndTime)
+	return nil
+}
+
+// executeRecalculation 执行重算，续跑时沿用任务对应的重算记录
+func (s *JobService) executeRecalculation(ctx context.Context, job *model.Job, onProgress points.ProgressFunc) error {
+	var recalculation *model.PointsRecalculation
+	if job.Params.RecalculationID == nil {
+		var err error
+		recalculation, err = s.pointsService.NewRecalculation(job.ChainName, job.Params.StartTime, job.Params.EndTime, job.Params.Reason, job.CreatedBy)
+		if err != nil {
+			return err
+		}
+		if err := s.pointsService.CreateRecalculation(ctx, recalculation); err != nil {
+			return err
+		}
+
+		job.Params.RecalculationID = &recalculation.ID
+		if err := s.jobRepo.UpdateParams(ctx, job.ID, s.workerID, job.Params); err != nil {
+			return fmt.Errorf("failed to save recalculation id: %w", err)
+		}
+	} else {
+		var err error
+		recalculation, err = s.pointsService.GetRecalculation(ctx, *job.Params.RecalculationID)
+		if err != nil {
+			return err
+		}
+		switch recalculation.Status {
+		case model.RecalculationStatusCompleted:
+			return nil
+		case model.RecalculationStatusRunning:
+		default:
+			return fmt.Errorf("recalculation %d is %s", recalculation.ID, recalculation.Status)
+		}
+	}
+
+	return s.pointsService.RunRecalculation(ctx, recalculation, onProgress)
+}
+
+// cancelRecalculation 取消重算任务对应的未完成重算记录
+func (s *JobService) cancelRecalculation(ctx context.Context, job *model.Job) {
+	if job.JobType != model.JobTypeRecalculation || job.Params.RecalculationID == nil {
+		return
+	}
+
+	recalculation, err := s.pointsService.GetRecalculation(ctx, *job.Params.RecalculationID)
+	if err != nil {
+		s.logger.Errorf("Failed to get recalculation of job %d: %v", job.ID, err)
+		return
+	}
+	if recalculation.Status != model.RecalculationStatusRunning {
+		return
+	}
+	if err := s.pointsService.CancelRecalculation(ctx, recalculation); err != nil {
+		s.logger.Errorf("Failed to cancel recalculation of job %d: %v", job.ID, err)
+	}
+}
+
+// finish 记录任务结果
+func (s *JobService) finish(ctx context.Context, job *model.Job, status string, errorMessage *string) {
+	if err := s.jobRepo.FinishJob(ctx, job.ID, s.workerID, status, errorMessage); err != nil {
+		s.logger.Errorf("Failed to finish job %d: %v", job.ID, err)
+		return
+	}
+
+	if errorMessage != nil {
+		s.logger.Errorf("Job %d (%s on %s) %s: %s", job.ID, job.JobType, job.ChainName, status, *errorMessage)
+		return
+	}
+	s.logger.Infof("Job %d (%s on %s) %s at %d/%d", job.ID, job.JobType, job.ChainName, status, job.ProgressDone, job.ProgressTotal)
+}
+
+// stringValue 返回字符串指针的值（nil 为空字符串）
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,154 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/config"
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/balance"
+)
+
+// archiveReplayBatchSize 从归档重放时每批读取的事件数
+const archiveReplayBatchSize = 1000
+
+// archiveLog 将匹配的原始日志写入归档表，不是我们关心的事件则忽略
+func (l *EventListener) archiveLog(ctx context.Context, vLog types.Log) error {
+	handler, ok := l.registry.Lookup(vLog)
+	if !ok {
+		return nil
+	}
+
+	blockTime, err := l.getBlockTime(ctx, vLog.BlockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get block time: %w", err)
+	}
+
+	return l.rawEventRepo.SaveRawEvent(ctx, &model.RawEvent{
+		ChainName:       l.chainName,
+		ContractAddress: vLog.Address.Hex(),
+		EventName:       handler.Event.Name,
+		BlockNumber:     int64(vLog.BlockNumber),
+		BlockHash:       vLog.BlockHash.Hex(),
+		BlockTime:       blockTime,
+		TxHash:          vLog.TxHash.Hex(),
+		TxIndex:         int(vLog.TxIndex),
+		LogIndex:        int(vLog.Index),
+		Topics:          encodeTopics(vLog.Topics),
+		Data:            hexutil.Encode(vLog.Data),
+	})
+}
+
+// rawEventToLog 将归档记录还原为原始日志
+func rawEventToLog(event *model.RawEvent) (types.Log, error) {
+	return decodeLog(event.ContractAddress, event.Topics, event.Data,
+		event.BlockNumber, event.BlockHash, event.TxHash, event.TxIndex, event.LogIndex)
+}
+
+// resetChainData 清空链上余额数据并丢弃未处理的死信，用于完整重放之前
+func (l *EventListener) resetChainData(ctx context.Context) error {
+	if err := l.balanceService.ResetChainBalances(ctx, l.chainName); err != nil {
+		return err
+	}
+
+	discarded, err := l.failedEventRepo.DiscardChainFailedEvents(ctx, l.chainName)
+	if err != nil {
+		return fmt.Errorf("failed to discard failed events: %w", err)
+	}
+	if discarded > 0 {
+		l.logger.Infof("Discarded %d pending failed events on %s before replay", discarded, l.chainName)
+	}
+
+	return nil
+}
+
+// ArchiveReplayer 从原始事件归档重建余额，不访问 RPC
+type ArchiveReplayer struct {
+	listener     *EventListener
+	blockTimes   StaticBlockTimes
+	rawEventRepo repository.RawEventRepository
+	logger       *logrus.Logger
+}
+
+// NewArchiveReplayer 创建归档重放器，处理失败的事件按 listenerConfig 写入死信表
+func NewArchiveReplayer(
+	chainName string,
+	chainConfig *config.ChainConfig,
+	listenerConfig *config.ListenerConfig,
+	failedEventRepo repository.FailedEventRepository,
+	rawEventRepo repository.RawEventRepository,
+	balanceService *balance.BalanceService,
+	logger *logrus.Logger,
+) (*ArchiveReplayer, error) {
+	blockTimes := StaticBlockTimes{}
+
+	l, err := newEventListener(chainName, chainConfig, nil, blockTimes,
+		failedEventRepo, rawEventRepo, balanceService, logger)
+	if err != nil {
+		return nil, err
+	}
+	l.listenerConfig = listenerConfig
+
+	return &ArchiveReplayer{
+		listener:     l,
+		blockTimes:   blockTimes,
+		rawEventRepo: rawEventRepo,
+		logger:       logger,
+	}, nil
+}
+
+// Rebuild 清空链上余额数据后按链上顺序重放全部归档事件，返回成功处理的事件数
+// 重建期间该链的监听器必须停止，否则实时事件会与重放结果交错
+func (r *ArchiveReplayer) Rebuild(ctx context.Context) (int, error) {
+	chainName := r.listener.chainName
+
+	// 未处理的死信都已在归档中，重放时会重新处理，失败的会再次写入死信表
+	if err := r.listener.resetChainData(ctx); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	afterBlock, afterLogIndex := int64(-1), -1
+
+	for {
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+
+		events, err := r.rawEventRepo.ListRawEvents(ctx, chainName, afterBlock, afterLogIndex, archiveReplayBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to list raw events: %w", err)
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		logs := make([]types.Log, 0, len(events))
+		for _, event := range events {
+			vLog, err := rawEventToLog(event)
+			if err != nil {
+				return total, fmt.Errorf("invalid raw event %d: %w", event.ID, err)
+			}
+			r.blockTimes[vLog.BlockNumber] = event.BlockTime
+			logs = append(logs, vLog)
+		}
+
+		processed, err := r.listener.processLogs(ctx, logs, false)
+		total += processed
+		if err != nil {
+			return total, err
+		}
+
+		last := events[len(events)-1]
+		afterBlock, afterLogIndex = last.BlockNumber, last.LogIndex
+
+		r.logger.Infof("Replayed %d archived events on %s (up to block %d)", total, chainName, afterBlock)
+	}
+
+	return total, nil
+}
@@ -0,0 +1,179 @@
+package listener
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/config"
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/balance"
+)
+
+const testContractAddress = "0x5CCEC1a2039Dd249B376033feB2d5479482614bb"
+
+// emptyBalanceRepo 所有余额都为零的余额仓储，ApplyBalanceChanges 计算完成后不写入
+type emptyBalanceRepo struct {
+	repository.BalanceRepository
+}
+
+func (r *emptyBalanceRepo) ApplyBalanceChanges(ctx context.Context, changes []*model.BalanceChange, apply func(i int, current *model.UserBalance) error) (bool, error) {
+	for i := range changes {
+		if err := apply(i, &model.UserBalance{Balance: "0", StakedBalance: "0"}); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (r *emptyBalanceRepo) DeleteChainBalances(ctx context.Context, chainName string) error {
+	return nil
+}
+
+// memoryAnomalyRepo 在内存中记录余额异常
+type memoryAnomalyRepo struct {
+	repository.AnomalyRepository
+	anomalies []*model.BalanceAnomaly
+}
+
+func (r *memoryAnomalyRepo) RecordAnomaly(ctx context.Context, anomaly *model.BalanceAnomaly) error {
+	r.anomalies = append(r.anomalies, anomaly)
+	return nil
+}
+
+// memoryFailedEventRepo 在内存中记录死信
+type memoryFailedEventRepo struct {
+	repository.FailedEventRepository
+	events []*model.FailedEvent
+}
+
+func (r *memoryFailedEventRepo) RecordFailedEvent(ctx context.Context, event *model.FailedEvent) error {
+	event.ID = int64(len(r.events) + 1)
+	event.Attempts = 1
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *memoryFailedEventRepo) DiscardChainFailedEvents(ctx context.Context, chainName string) (int64, error) {
+	return 0, nil
+}
+
+// memoryRawEventRepo 在内存中保存归档事件
+type memoryRawEventRepo struct {
+	repository.RawEventRepository
+	events []*model.RawEvent
+}
+
+func (r *memoryRawEventRepo) SaveRawEvent(ctx context.Context, event *model.RawEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *memoryRawEventRepo) ListRawEvents(ctx context.Context, chainName string, afterBlock int64, afterLogIndex, limit int) ([]*model.RawEvent, error) {
+	var events []*model.RawEvent
+	for _, event := range r.events {
+		if event.BlockNumber > afterBlock || (event.BlockNumber == afterBlock && event.LogIndex > afterLogIndex) {
+			events = append(events, event)
+		}
+	}
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func testChainConfig() *config.ChainConfig {
+	return &config.ChainConfig{Name: "testnet", ChainID: 1, ContractAddress: testContractAddress}
+}
+
+// rejectingBalanceService 余额为负时拒绝事件的余额服务（所有余额为零，任何转出都会失败）
+func rejectingBalanceService(anomalies *memoryAnomalyRepo) *balance.BalanceService {
+	return balance.NewBalanceService(&emptyBalanceRepo{}, anomalies, config.NegativeBalanceReject, testLogger())
+}
+
+// transferLog 构造 MyToken 的 Transfer 日志
+func transferLog(t *testing.T, from, to common.Address, amount int64, blockNumber uint64, logIndex uint) types.Log {
+	t.Helper()
+
+	contractABI, err := abi.JSON(strings.NewReader(MyTokenABI))
+	if err != nil {
+		t.Fatalf("failed to parse ABI: %v", err)
+	}
+
+	return types.Log{
+		Address: common.HexToAddress(testContractAddress),
+		Topics: []common.Hash{
+			contractABI.Events["Transfer"].ID,
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(to.Bytes()),
+		},
+		Data:        common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+		BlockNumber: blockNumber,
+		TxHash:      common.HexToHash("0x01"),
+		BlockHash:   common.HexToHash("0x02"),
+		Index:       logIndex,
+	}
+}
+
+func TestArchiveReplayerRecordsFailedLog(t *testing.T) {
+	vLog := transferLog(t, common.HexToAddress("0xaa"), common.HexToAddress("0xbb"), 100, 10, 0)
+	rawEvents := &memoryRawEventRepo{events: []*model.RawEvent{{
+		ChainName:       "testnet",
+		ContractAddress: vLog.Address.Hex(),
+		EventName:       "Transfer",
+		BlockNumber:     int64(vLog.BlockNumber),
+		BlockHash:       vLog.BlockHash.Hex(),
+		BlockTime:       time.Unix(1700000000, 0).UTC(),
+		TxHash:          vLog.TxHash.Hex(),
+		LogIndex:        int(vLog.Index),
+		Topics:          encodeTopics(vLog.Topics),
+		Data:            hexutil.Encode(vLog.Data),
+	}}}
+	failedEvents := &memoryFailedEventRepo{}
+	anomalies := &memoryAnomalyRepo{}
+
+	replayer, err := NewArchiveReplayer("testnet", testChainConfig(), &config.ListenerConfig{RetryInterval: 60},
+		failedEvents, rawEvents, rejectingBalanceService(anomalies), testLogger())
+	if err != nil {
+		t.Fatalf("NewArchiveReplayer: %v", err)
+	}
+
+	processed, err := replayer.Rebuild(context.Background())
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if processed != 0 {
+		t.Errorf("processed = %d, want 0", processed)
+	}
+
+	if len(failedEvents.events) != 1 {
+		t.Fatalf("recorded %d failed events, want 1", len(failedEvents.events))
+	}
+	event := failedEvents.events[0]
+	if event.NextRetryAt == nil || !event.NextRetryAt.After(time.Now()) {
+		t.Errorf("next_retry_at = %v, want a time in the future", event.NextRetryAt)
+	}
+	if !strings.Contains(event.ErrorMessage, "negative") {
+		t.Errorf("error message = %q, want the negative balance rejection", event.ErrorMessage)
+	}
+
+	if len(anomalies.anomalies) != 1 || anomalies.anomalies[0].Action != model.AnomalyActionRejected {
+		t.Errorf("anomalies = %+v, want one rejected anomaly", anomalies.anomalies)
+	}
+}
@@ -0,0 +1,39 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// balanceOfSelector ERC20 balanceOf(address) 的函数选择器
+var balanceOfSelector = common.FromHex("0x70a08231")
+
+// rpcBalanceReader 通过 RPC 调用 balanceOf 查询链上余额
+type rpcBalanceReader struct {
+	client *ethclient.Client
+}
+
+// newRPCBalanceReader 创建基于 RPC 的链上余额查询
+func newRPCBalanceReader(client *ethclient.Client) *rpcBalanceReader {
+	return &rpcBalanceReader{client: client}
+}
+
+// BalanceAt 查询代币合约中用户在指定区块结束时的余额
+func (r *rpcBalanceReader) BalanceAt(ctx context.Context, contractAddress, userAddress string, blockNumber int64) (*big.Int, error) {
+	contract := common.HexToAddress(contractAddress)
+	data := append(append([]byte{}, balanceOfSelector...), common.LeftPadBytes(common.HexToAddress(userAddress).Bytes(), 32)...)
+
+	out, err := r.client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, big.NewInt(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) != 32 {
+		return nil, fmt.Errorf("unexpected balanceOf result from %s: %d bytes", contractAddress, len(out))
+	}
+	return new(big.Int).SetBytes(out), nil
+}
@@ -0,0 +1,72 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// blockTimeCacheSize 区块时间缓存的最大条目数，超过后整体清空
+const blockTimeCacheSize = 10000
+
+// BlockTimeSource 区块时间来源
+type BlockTimeSource interface {
+	BlockTime(ctx context.Context, blockNumber uint64) (time.Time, error)
+}
+
+// rpcBlockTimes 通过 RPC 查询区块时间，同一批日志通常落在少数几个区块内，因此带简单缓存
+type rpcBlockTimes struct {
+	client *ethclient.Client
+
+	mu    sync.Mutex
+	cache map[uint64]time.Time
+}
+
+// newRPCBlockTimes 创建基于 RPC 的区块时间来源
+func newRPCBlockTimes(client *ethclient.Client) *rpcBlockTimes {
+	return &rpcBlockTimes{
+		client: client,
+		cache:  make(map[uint64]time.Time),
+	}
+}
+
+// BlockTime 查询区块时间
+func (r *rpcBlockTimes) BlockTime(ctx context.Context, blockNumber uint64) (time.Time, error) {
+	r.mu.Lock()
+	if t, ok := r.cache[blockNumber]; ok {
+		r.mu.Unlock()
+		return t, nil
+	}
+	r.mu.Unlock()
+
+	header, err := r.client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return time.Time{}, err
+	}
+	blockTime := time.Unix(int64(header.Time), 0).UTC()
+
+	r.mu.Lock()
+	if len(r.cache) >= blockTimeCacheSize {
+		r.cache = make(map[uint64]time.Time)
+	}
+	r.cache[blockNumber] = blockTime
+	r.mu.Unlock()
+
+	return blockTime, nil
+}
+
+// StaticBlockTimes 预先加载的区块时间，用于不访问 RPC 的离线重放
+type StaticBlockTimes map[uint64]time.Time
+
+// BlockTime 查询区块时间
+func (s StaticBlockTimes) BlockTime(ctx context.Context, blockNumber uint64) (time.Time, error) {
+	t, ok := s[blockNumber]
+	if !ok {
+		return time.Time{}, fmt.Errorf("block time not found for block %d", blockNumber)
+	}
+	return t, nil
+}
@@ -0,0 +1,178 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"my-token-points/internal/model"
+)
+
+const (
+	// retryBatchSize 每轮最多重试的死信数量
+	retryBatchSize = 100
+	// maxRetryBackoff 死信重试的最大退避时间
+	maxRetryBackoff = 6 * time.Hour
+)
+
+// recordFailedLog 将处理失败的日志写入死信表
+func (l *EventListener) recordFailedLog(ctx context.Context, vLog types.Log, procErr error) error {
+	event := newFailedEvent(l.chainName, vLog, procErr)
+	event.Status = model.FailedEventPending
+	nextRetryAt := time.Now().Add(l.retryDelay(1))
+	event.NextRetryAt = &nextRetryAt
+
+	if err := l.failedEventRepo.RecordFailedEvent(ctx, event); err != nil {
+		return err
+	}
+
+	l.logger.Warnf("Recorded failed log %s#%d on %s to dead letter table (id=%d, attempts=%d)",
+		event.TxHash, event.LogIndex, l.chainName, event.ID, event.Attempts)
+	return nil
+}
+
+// runRetryWorker 定时重试死信
+func (l *EventListener) runRetryWorker(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(l.listenerConfig.RetryInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-l.stopChan:
+			return
+		case <-ticker.C:
+			if err := l.retryFailedEvents(ctx); err != nil && ctx.Err() == nil {
+				l.logger.Errorf("Error retrying failed events for %s: %v", l.chainName, err)
+			}
+		}
+	}
+}
+
+// retryFailedEvents 重试到期的死信
+// 注意：重试时按当前余额计算 balance_before，延迟处理的事件不会改写之后的变动记录
+func (l *EventListener) retryFailedEvents(ctx context.Context) error {
+	events, err := l.failedEventRepo.GetRetryableEvents(ctx, l.chainName, retryBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to get retryable events: %w", err)
+	}
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// 变动已由重新扫描写入的事件，processLog 跳过写入并返回成功，死信直接解决
+		vLog, err := failedEventToLog(event)
+		if err == nil {
+			err = l.processLog(ctx, vLog)
+		}
+
+		if err == nil {
+			if err := l.failedEventRepo.MarkResolved(ctx, event.ID); err != nil {
+				return fmt.Errorf("failed to mark event %d resolved: %w", event.ID, err)
+			}
+			l.logger.Infof("Retried failed event %d (%s#%d) on %s successfully",
+				event.ID, event.TxHash, event.LogIndex, l.chainName)
+			continue
+		}
+
+		attempts := event.Attempts + 1
+		status := model.FailedEventPending
+		var nextRetryAt *time.Time
+		if attempts >= l.listenerConfig.MaxRetryAttempts {
+			status = model.FailedEventExhausted
+		} else {
+			next := time.Now().Add(l.retryDelay(attempts))
+			nextRetryAt = &next
+		}
+
+		l.logger.Warnf("Retry of failed event %d on %s failed (attempts: %d, status: %s): %v",
+			event.ID, l.chainName, attempts, status, err)
+
+		if err := l.failedEventRepo.MarkRetryFailed(ctx, event.ID, err.Error(), status, nextRetryAt); err != nil {
+			return fmt.Errorf("failed to update event %d: %w", event.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// retryDelay 第 attempts 次失败后的重试等待时间（指数退避）
+func (l *EventListener) retryDelay(attempts int) time.Duration {
+	delay := time.Duration(l.listenerConfig.RetryInterval) * time.Second
+	for i := 1; i < attempts && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
+
+// newFailedEvent 将原始日志转换为死信记录
+func newFailedEvent(chainName string, vLog types.Log, procErr error) *model.FailedEvent {
+	return &model.FailedEvent{
+		ChainName:       chainName,
+		ContractAddress: vLog.Address.Hex(),
+		TxHash:          vLog.TxHash.Hex(),
+		TxIndex:         int(vLog.TxIndex),
+		BlockNumber:     int64(vLog.BlockNumber),
+		BlockHash:       vLog.BlockHash.Hex(),
+		LogIndex:        int(vLog.Index),
+		Topics:          encodeTopics(vLog.Topics),
+		Data:            hexutil.Encode(vLog.Data),
+		ErrorMessage:    procErr.Error(),
+	}
+}
+
+// failedEventToLog 将死信记录还原为原始日志
+func failedEventToLog(event *model.FailedEvent) (types.Log, error) {
+	return decodeLog(event.ContractAddress, event.Topics, event.Data,
+		event.BlockNumber, event.BlockHash, event.TxHash, event.TxIndex, event.LogIndex)
+}
+
+// encodeTopics 将 topics 编码为十六进制字符串数组
+func encodeTopics(topics []common.Hash) model.HexStrings {
+	encoded := make(model.HexStrings, 0, len(topics))
+	for _, topic := range topics {
+		encoded = append(encoded, topic.Hex())
+	}
+	return encoded
+}
+
+// decodeLog 根据数据库中保存的字段还原原始日志
+func decodeLog(
+	contractAddress string,
+	topics model.HexStrings,
+	data string,
+	blockNumber int64,
+	blockHash, txHash string,
+	txIndex, logIndex int,
+) (types.Log, error) {
+	decodedData, err := hexutil.Decode(data)
+	if err != nil {
+		return types.Log{}, fmt.Errorf("invalid log data: %w", err)
+	}
+
+	decodedTopics := make([]common.Hash, 0, len(topics))
+	for _, topic := range topics {
+		decodedTopics = append(decodedTopics, common.HexToHash(topic))
+	}
+
+	return types.Log{
+		Address:     common.HexToAddress(contractAddress),
+		Topics:      decodedTopics,
+		Data:        decodedData,
+		BlockNumber: uint64(blockNumber),
+		TxHash:      common.HexToHash(txHash),
+		TxIndex:     uint(txIndex),
+		BlockHash:   common.HexToHash(blockHash),
+		Index:       uint(logIndex),
+	}, nil
+}
@@ -4,12 +4,10 @@ import (
 	"context"
 	"fmt"
 	"math/big"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/sirupsen/logrus"
@@ -24,13 +22,22 @@ import (
 type EventListener struct {
 	chainName       string
 	chainConfig     *config.ChainConfig
+	listenerConfig  *config.ListenerConfig
 	client          *ethclient.Client
-	contractABI     abi.ABI
+	blockTimes      BlockTimeSource
+	balances        balance.BalanceReader // 链上余额查询（离线重放时为空）
+	registry        *HandlerRegistry
 	syncRepo        repository.SyncRepository
+	failedEventRepo repository.FailedEventRepository
+	rawEventRepo    repository.RawEventRepository
 	balanceService  *balance.BalanceService
 	confirmBlocks   int64
 	logger          *logrus.Logger
 	stopChan        chan struct{}
+	wg              sync.WaitGroup
+
+	// 连续扫描失败次数（仅由 run 协程访问）
+	consecutiveFailures int
 }
 
 // NewEventListener 创建事件监听器
@@ -38,7 +45,10 @@ func NewEventListener(
 	chainName string,
 	chainConfig *config.ChainConfig,
 	confirmBlocks int,
+	listenerConfig *config.ListenerConfig,
 	syncRepo repository.SyncRepository,
+	failedEventRepo repository.FailedEventRepository,
+	rawEventRepo repository.RawEventRepository,
 	balanceService *balance.BalanceService,
 	logger *logrus.Logger,
 ) (*EventListener, error) {
@@ -48,22 +58,48 @@ func NewEventListener(
 		return nil, fmt.Errorf("failed to connect to %s: %w", chainName, err)
 	}
 
-	// 解析合约 ABI
-	contractABI, err := abi.JSON(strings.NewReader(MyTokenABI))
+	l, err := newEventListener(chainName, chainConfig, client, newRPCBlockTimes(client),
+		failedEventRepo, rawEventRepo, balanceService, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	l.listenerConfig = listenerConfig
+	l.confirmBlocks = int64(confirmBlocks)
+	l.syncRepo = syncRepo
+	l.balances = newRPCBalanceReader(client)
+
+	return l, nil
+}
+
+// newEventListener 创建事件处理所需的公共部分，离线重放时 client 为空
+func newEventListener(
+	chainName string,
+	chainConfig *config.ChainConfig,
+	client *ethclient.Client,
+	blockTimes BlockTimeSource,
+	failedEventRepo repository.FailedEventRepository,
+	rawEventRepo repository.RawEventRepository,
+	balanceService *balance.BalanceService,
+	logger *logrus.Logger,
+) (*EventListener, error) {
+	// 加载合约 ABI 和事件映射
+	registry, err := NewHandlerRegistry(chainConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse contract ABI: %w", err)
+		return nil, err
 	}
 
 	return &EventListener{
-		chainName:      chainName,
-		chainConfig:    chainConfig,
-		client:         client,
-		contractABI:    contractABI,
-		syncRepo:       syncRepo,
-		balanceService: balanceService,
-		confirmBlocks:  int64(confirmBlocks),
-		logger:         logger,
-		stopChan:       make(chan struct{}),
+		chainName:       chainName,
+		chainConfig:     chainConfig,
+		client:          client,
+		blockTimes:      blockTimes,
+		registry:        registry,
+		failedEventRepo: failedEventRepo,
+		rawEventRepo:    rawEventRepo,
+		balanceService:  balanceService,
+		logger:          logger,
+		stopChan:        make(chan struct{}),
 	}, nil
 }
 
@@ -76,22 +112,49 @@ func (l *EventListener) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to init sync state: %w", err)
 	}
 
+	// 上次运行可能以 stopped/error 结束，重新标记为运行中
+	if err := l.syncRepo.UpdateSyncStatus(ctx, l.chainName, model.StatusRunning); err != nil {
+		return fmt.Errorf("failed to update sync status: %w", err)
+	}
+
 	// 启动主循环
-	go l.run(ctx)
+	l.wg.Add(2)
+	go func() {
+		defer l.wg.Done()
+		l.run(ctx)
+	}()
+
+	// 启动死信重试任务
+	go func() {
+		defer l.wg.Done()
+		l.runRetryWorker(ctx)
+	}()
 
 	return nil
 }
 
-// Stop 停止事件监听
+// Stop 停止事件监听，等待正在进行的扫描和重试结束后返回
 func (l *EventListener) Stop() {
 	l.logger.Infof("Stopping event listener for %s", l.chainName)
 	close(l.stopChan)
+	l.wg.Wait()
+
+	// Stop 通常在外部 ctx 取消后调用，这里使用独立的上下文写入停止状态
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := l.syncRepo.UpdateSyncStatus(ctx, l.chainName, model.StatusStopped); err != nil {
+		l.logger.Errorf("Failed to mark %s listener as stopped: %v", l.chainName, err)
+	}
+
+	if l.client != nil {
+		l.client.Close()
+	}
 }
 
 // run 主循环
 func (l *EventListener) run(ctx context.Context) {
-	ticker := time.NewTicker(time.Duration(l.chainConfig.ScanInterval) * time.Second)
-	defer ticker.Stop()
+	timer := time.NewTimer(l.scanInterval())
+	defer timer.Stop()
 
 	for {
 		select {
@@ -101,21 +164,70 @@ func (l *EventListener) run(ctx context.Context) {
 		case <-l.stopChan:
 			l.logger.Infof("Stop signal received, stopping listener for %s", l.chainName)
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			if err := l.scanBlocks(ctx); err != nil {
-				l.logger.Errorf("Error scanning blocks for %s: %v", l.chainName, err)
+				l.handleScanError(ctx, err)
+			} else {
+				l.consecutiveFailures = 0
 			}
+			timer.Reset(l.nextScanDelay())
 		}
 	}
 }
 
+// scanInterval 正常扫描间隔
+func (l *EventListener) scanInterval() time.Duration {
+	return time.Duration(l.chainConfig.ScanInterval) * time.Second
+}
+
+// nextScanDelay 计算下一次扫描的等待时间（失败时指数退避）
+func (l *EventListener) nextScanDelay() time.Duration {
+	interval := l.scanInterval()
+	if l.consecutiveFailures == 0 {
+		return interval
+	}
+
+	maxBackoff := time.Duration(l.listenerConfig.MaxBackoff) * time.Second
+	delay := interval
+	for i := 0; i < l.consecutiveFailures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+// handleScanError 记录扫描失败，连续失败达到阈值后将状态标记为 error
+func (l *EventListener) handleScanError(ctx context.Context, scanErr error) {
+	if ctx.Err() != nil {
+		// 正在关闭，不记录为失败
+		return
+	}
+
+	l.consecutiveFailures++
+
+	status := model.StatusRunning
+	if l.consecutiveFailures >= l.listenerConfig.ErrorThreshold {
+		status = model.StatusError
+	}
+
+	l.logger.Errorf("Error scanning blocks for %s (consecutive failures: %d, next retry in %s): %v",
+		l.chainName, l.consecutiveFailures, l.nextScanDelay(), scanErr)
+
+	if err := l.syncRepo.RecordSyncError(ctx, l.chainName, status, scanErr.Error(), l.consecutiveFailures); err != nil {
+		l.logger.Errorf("Failed to record sync error for %s: %v", l.chainName, err)
+	}
+}
+
 // scanBlocks 扫描区块
 func (l *EventListener) scanBlocks(ctx context.Context) error {
 	// 获取当前链上最新区块
-	latestBlock, err := l.client.BlockNumber(ctx)
+	head, err := l.client.HeaderByNumber(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to get latest block: %w", err)
 	}
+	latestBlock := head.Number.Uint64()
 
 	// 获取上次同步的区块
 	syncState, err := l.syncRepo.GetSyncState(ctx, l.chainName)
@@ -131,8 +243,8 @@ func (l *EventListener) scanBlocks(ctx context.Context) error {
 	toBlock := int64(latestBlock) - l.confirmBlocks
 
 	if fromBlock > toBlock {
-		// 没有新区块需要扫描
-		return nil
+		// 没有新区块需要扫描，仅刷新健康状态
+		return l.recordScanSuccess(ctx, syncState, syncState.LastSyncedBlock, syncState.LastSyncedBlockTime, head, 0)
 	}
 
 	// 限制每次扫描的区块数量
@@ -152,19 +264,73 @@ func (l *EventListener) scanBlocks(ctx context.Context) error {
 
 	l.logger.Infof("Found %d events in blocks %d-%d on %s", len(logs), fromBlock, toBlock, l.chainName)
 
-	// 处理事件
+	// 归档并处理事件
+	processed, err := l.processLogs(ctx, logs, true)
+	if err != nil {
+		return err
+	}
+
+	// 记录已同步区块的时间，用于计算落后时长
+	syncedBlockTime, err := l.getBlockTime(ctx, uint64(toBlock))
+	if err != nil {
+		return fmt.Errorf("failed to get block time: %w", err)
+	}
+
+	// 更新同步状态
+	return l.recordScanSuccess(ctx, syncState, toBlock, &syncedBlockTime, head, processed)
+}
+
+// processLogs 按顺序处理一批日志
+// archive 为 true 时先将匹配的原始日志写入归档表；处理失败的日志写入死信表后继续处理其他事件，
+// 归档或死信写入失败则返回错误，调用方不应推进同步游标，避免事件丢失
+func (l *EventListener) processLogs(ctx context.Context, logs []types.Log, archive bool) (int, error) {
+	processed := 0
 	for _, vLog := range logs {
+		if archive {
+			if err := l.archiveLog(ctx, vLog); err != nil {
+				return processed, fmt.Errorf("failed to archive log %s: %w", vLog.TxHash.Hex(), err)
+			}
+		}
+
 		if err := l.processLog(ctx, vLog); err != nil {
 			l.logger.Errorf("Failed to process log %s: %v", vLog.TxHash.Hex(), err)
-			// 继续处理其他事件，不中断
+			if dlqErr := l.recordFailedLog(ctx, vLog, err); dlqErr != nil {
+				return processed, fmt.Errorf("failed to record failed log %s: %w", vLog.TxHash.Hex(), dlqErr)
+			}
+			continue
 		}
+		processed++
 	}
 
-	// 更新同步状态
-	syncState.LastSyncedBlock = toBlock
-	syncState.LastConfirmedBlock = toBlock
-	syncState.LastSyncAt = time.Now()
+	return processed, nil
+}
+
+// recordScanSuccess 记录一次成功的扫描
+func (l *EventListener) recordScanSuccess(
+	ctx context.Context,
+	syncState *model.SyncState,
+	syncedBlock int64,
+	syncedBlockTime *time.Time,
+	head *types.Header,
+	processed int,
+) error {
+	now := time.Now()
+	headBlock := head.Number.Int64()
+	headTime := time.Unix(int64(head.Time), 0).UTC()
+
+	syncState.LastSyncedBlock = syncedBlock
+	syncState.LastConfirmedBlock = syncedBlock
+	syncState.LastSyncAt = now
 	syncState.Status = model.StatusRunning
+	syncState.ErrorMessage = nil
+	syncState.ConsecutiveFailures = 0
+	syncState.LastSuccessAt = &now
+	syncState.ChainHeadBlock = headBlock
+	syncState.HeadLagBlocks = headBlock - syncedBlock
+	syncState.LastSyncedBlockTime = syncedBlockTime
+	syncState.ChainHeadTime = &headTime
+	syncState.EventsProcessed += int64(processed)
+
 	if err := l.syncRepo.UpdateSyncState(ctx, syncState); err != nil {
 		return fmt.Errorf("failed to update sync state: %w", err)
 	}
@@ -174,13 +340,11 @@ func (l *EventListener) scanBlocks(ctx context.Context) error {
 
 // queryLogs 查询事件日志
 func (l *EventListener) queryLogs(ctx context.Context, fromBlock, toBlock int64) ([]types.Log, error) {
-	contractAddress := common.HexToAddress(l.chainConfig.ContractAddress)
-
 	// 构建过滤器
 	query := ethereum.FilterQuery{
 		FromBlock: big.NewInt(fromBlock),
 		ToBlock:   big.NewInt(toBlock),
-		Addresses: []common.Address{contractAddress},
+		Addresses: l.registry.Addresses(),
 	}
 
 	return l.client.FilterLogs(ctx, query)
@@ -188,179 +352,55 @@ func (l *EventListener) queryLogs(ctx context.Context, fromBlock, toBlock int64)
 
 // processLog 处理单个事件日志
 func (l *EventListener) processLog(ctx context.Context, vLog types.Log) error {
-	// 解析事件
-	event, err := l.contractABI.EventByID(vLog.Topics[0])
-	if err != nil {
+	handler, ok := l.registry.Lookup(vLog)
+	if !ok {
 		// 不是我们关心的事件，忽略
 		return nil
 	}
 
-	l.logger.Debugf("Processing event %s in tx %s", event.Name, vLog.TxHash.Hex())
-
-	switch event.Name {
-	case "TokenMinted":
-		return l.handleTokenMinted(ctx, vLog)
-	case "TokenBurned":
-		return l.handleTokenBurned(ctx, vLog)
-	case "Transfer":
-		return l.handleTransfer(ctx, vLog)
-	default:
-		l.logger.Debugf("Ignoring event %s", event.Name)
-		return nil
-	}
-}
-
-// handleTokenMinted 处理 TokenMinted 事件
-func (l *EventListener) handleTokenMinted(ctx context.Context, vLog types.Log) error {
-	// 解析事件数据
-	var event struct {
-		To        common.Address
-		Amount    *big.Int
-		Timestamp *big.Int
-	}
+	l.logger.Debugf("Processing %s.%s in tx %s", handler.Contract, handler.Event.Name, vLog.TxHash.Hex())
 
-	err := l.contractABI.UnpackIntoInterface(&event, "TokenMinted", vLog.Data)
+	// 按事件映射解析余额变动
+	deltas, err := handler.Decode(vLog)
 	if err != nil {
-		return fmt.Errorf("failed to unpack TokenMinted event: %w", err)
+		return err
 	}
-
-	// indexed 参数在 Topics 中
-	if len(vLog.Topics) < 2 {
-		return fmt.Errorf("invalid TokenMinted event: not enough topics")
-	}
-	event.To = common.HexToAddress(vLog.Topics[1].Hex())
-
-	l.logger.Infof("TokenMinted: to=%s, amount=%s, block=%d",
-		event.To.Hex(), event.Amount.String(), vLog.BlockNumber)
-
-	// 获取区块时间
-	blockTime, err := l.getBlockTime(ctx, vLog.BlockNumber)
-	if err != nil {
-		return fmt.Errorf("failed to get block time: %w", err)
-	}
-
-	// 更新余额
-	return l.balanceService.UpdateBalance(ctx, &balance.BalanceUpdate{
-		ChainName:   l.chainName,
-		UserAddress: event.To.Hex(),
-		TxHash:      vLog.TxHash.Hex(),
-		BlockNumber: int64(vLog.BlockNumber),
-		BlockTime:   blockTime,
-		EventType:   model.EventTypeMint,
-		AmountDelta: event.Amount.String(),
-	})
-}
-
-// handleTokenBurned 处理 TokenBurned 事件
-func (l *EventListener) handleTokenBurned(ctx context.Context, vLog types.Log) error {
-	// 解析事件数据
-	var event struct {
-		From      common.Address
-		Amount    *big.Int
-		Timestamp *big.Int
-	}
-
-	err := l.contractABI.UnpackIntoInterface(&event, "TokenBurned", vLog.Data)
-	if err != nil {
-		return fmt.Errorf("failed to unpack TokenBurned event: %w", err)
-	}
-
-	// indexed 参数在 Topics 中
-	if len(vLog.Topics) < 2 {
-		return fmt.Errorf("invalid TokenBurned event: not enough topics")
-	}
-	event.From = common.HexToAddress(vLog.Topics[1].Hex())
-
-	l.logger.Infof("TokenBurned: from=%s, amount=%s, block=%d",
-		event.From.Hex(), event.Amount.String(), vLog.BlockNumber)
-
-	// 获取区块时间
-	blockTime, err := l.getBlockTime(ctx, vLog.BlockNumber)
-	if err != nil {
-		return fmt.Errorf("failed to get block time: %w", err)
-	}
-
-	// 更新余额（负数表示减少）
-	amountDelta := new(big.Int).Neg(event.Amount)
-
-	return l.balanceService.UpdateBalance(ctx, &balance.BalanceUpdate{
-		ChainName:   l.chainName,
-		UserAddress: event.From.Hex(),
-		TxHash:      vLog.TxHash.Hex(),
-		BlockNumber: int64(vLog.BlockNumber),
-		BlockTime:   blockTime,
-		EventType:   model.EventTypeBurn,
-		AmountDelta: amountDelta.String(),
-	})
-}
-
-// handleTransfer 处理 Transfer 事件
-func (l *EventListener) handleTransfer(ctx context.Context, vLog types.Log) error {
-	// 解析事件数据
-	var event struct {
-		From  common.Address
-		To    common.Address
-		Value *big.Int
-	}
-
-	// indexed 参数在 Topics 中
-	if len(vLog.Topics) < 3 {
-		return fmt.Errorf("invalid Transfer event: not enough topics")
-	}
-	event.From = common.HexToAddress(vLog.Topics[1].Hex())
-	event.To = common.HexToAddress(vLog.Topics[2].Hex())
-
-	err := l.contractABI.UnpackIntoInterface(&event, "Transfer", vLog.Data)
-	if err != nil {
-		return fmt.Errorf("failed to unpack Transfer event: %w", err)
+	if len(deltas) == 0 {
+		l.logger.Debugf("Ignoring %s.%s in tx %s", handler.Contract, handler.Event.Name, vLog.TxHash.Hex())
+		return nil
 	}
 
-	l.logger.Infof("Transfer: from=%s, to=%s, amount=%s, block=%d",
-		event.From.Hex(), event.To.Hex(), event.Value.String(), vLog.BlockNumber)
-
 	// 获取区块时间
 	blockTime, err := l.getBlockTime(ctx, vLog.BlockNumber)
 	if err != nil {
 		return fmt.Errorf("failed to get block time: %w", err)
 	}
 
-	zeroAddress := common.HexToAddress("0x0000000000000000000000000000000000000000")
-
-	// 如果 from 是 0 地址，这是 mint 事件（已由 TokenMinted 处理，忽略）
-	if event.From == zeroAddress {
-		return nil
+	// 同一条日志的全部变动在一个事务中更新，转账的扣减和入账不会只成功一半
+	updates := make([]*balance.BalanceUpdate, 0, len(deltas))
+	for _, delta := range deltas {
+		l.logger.Infof("%s.%s: user=%s, delta=%s, type=%s, balance=%s, block=%d",
+			handler.Contract, handler.Event.Name, delta.UserAddress.Hex(), delta.Amount.String(),
+			delta.EventType, delta.BalanceType, vLog.BlockNumber)
+
+		updates = append(updates, &balance.BalanceUpdate{
+			ChainName:       l.chainName,
+			UserAddress:     delta.UserAddress.Hex(),
+			TxHash:          vLog.TxHash.Hex(),
+			LogIndex:        int(vLog.Index),
+			ContractAddress: vLog.Address.Hex(),
+			BlockNumber:     int64(vLog.BlockNumber),
+			BlockTime:       blockTime,
+			EventType:       delta.EventType,
+			BalanceType:     delta.BalanceType,
+			AmountDelta:     delta.Amount.String(),
+			OnChain:         l.balances,
+		})
 	}
 
-	// 如果 to 是 0 地址，这是 burn 事件（已由 TokenBurned 处理，忽略）
-	if event.To == zeroAddress {
-		return nil
-	}
-
-	// 普通转账：减少 from 的余额
-	amountDelta := new(big.Int).Neg(event.Value)
-	if err := l.balanceService.UpdateBalance(ctx, &balance.BalanceUpdate{
-		ChainName:   l.chainName,
-		UserAddress: event.From.Hex(),
-		TxHash:      vLog.TxHash.Hex(),
-		BlockNumber: int64(vLog.BlockNumber),
-		BlockTime:   blockTime,
-		EventType:   model.EventTypeTransferOut,
-		AmountDelta: amountDelta.String(),
-	}); err != nil {
-		return fmt.Errorf("failed to update from balance: %w", err)
-	}
-
-	// 普通转账：增加 to 的余额
-	if err := l.balanceService.UpdateBalance(ctx, &balance.BalanceUpdate{
-		ChainName:   l.chainName,
-		UserAddress: event.To.Hex(),
-		TxHash:      vLog.TxHash.Hex(),
-		BlockNumber: int64(vLog.BlockNumber),
-		BlockTime:   blockTime,
-		EventType:   model.EventTypeTransferIn,
-		AmountDelta: event.Value.String(),
-	}); err != nil {
-		return fmt.Errorf("failed to update to balance: %w", err)
+	// 更新余额
+	if err := l.balanceService.UpdateBalances(ctx, updates); err != nil {
+		return fmt.Errorf("failed to update balances: %w", err)
 	}
 
 	return nil
@@ -368,10 +408,6 @@ func (l *EventListener) handleTransfer(ctx context.Context, vLog types.Log) erro
 
 // getBlockTime 获取区块时间
 func (l *EventListener) getBlockTime(ctx context.Context, blockNumber uint64) (time.Time, error) {
-	block, err := l.client.BlockByNumber(ctx, big.NewInt(int64(blockNumber)))
-	if err != nil {
-		return time.Time{}, err
-	}
-	return time.Unix(int64(block.Time()), 0), nil
+	return l.blockTimes.BlockTime(ctx, blockNumber)
 }
 
@@ -0,0 +1,119 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+
+	"my-token-points/config"
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/balance"
+)
+
+// testDB 连接 TEST_DATABASE_DSN 指定的数据库（需已执行全部迁移），未设置时跳过测试
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestProcessLogsRedeliveredLog(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	// 每次运行使用独立的链名，结束后清理
+	chainName := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		for _, table := range []string{"balance_changes", "balance_anomalies", "user_balances", "failed_events"} {
+			db.Exec(`DELETE FROM `+table+` WHERE chain_name = $1`, chainName)
+		}
+	})
+
+	chainConfig := testChainConfig()
+	chainConfig.Name = chainName
+	balanceRepo := repository.NewBalanceRepository(db)
+	balanceService := balance.NewBalanceService(balanceRepo, repository.NewAnomalyRepository(db),
+		config.NegativeBalanceReject, testLogger())
+
+	blockTime := time.Unix(1700000000, 0).UTC()
+	l, err := newEventListener(chainName, chainConfig, nil, StaticBlockTimes{10: blockTime},
+		repository.NewFailedEventRepository(db), repository.NewRawEventRepository(db), balanceService, testLogger())
+	if err != nil {
+		t.Fatalf("newEventListener: %v", err)
+	}
+	l.listenerConfig = &config.ListenerConfig{RetryInterval: 60, MaxRetryAttempts: 5}
+
+	from, to := common.HexToAddress("0xaa"), common.HexToAddress("0xbb")
+	if err := balanceService.UpdateBalance(ctx, &balance.BalanceUpdate{
+		ChainName:   chainName,
+		UserAddress: from.Hex(),
+		TxHash:      common.HexToHash("0xff").Hex(),
+		BlockNumber: 9,
+		BlockTime:   blockTime,
+		EventType:   model.EventTypeMint,
+		AmountDelta: "1000",
+	}); err != nil {
+		t.Fatalf("UpdateBalance: %v", err)
+	}
+
+	// 同步游标未推进时同一条日志会被再次扫描
+	logs := []types.Log{transferLog(t, from, to, 100, 10, 0)}
+	for i := 0; i < 2; i++ {
+		processed, err := l.processLogs(ctx, logs, false)
+		if err != nil {
+			t.Fatalf("processLogs #%d: %v", i+1, err)
+		}
+		if processed != 1 {
+			t.Errorf("processLogs #%d processed %d logs, want 1", i+1, processed)
+		}
+	}
+
+	for address, want := range map[common.Address]string{from: "900", to: "100"} {
+		user := strings.ToLower(address.Hex())
+
+		var changes int
+		if err := db.GetContext(ctx, &changes, `
+			SELECT COUNT(*) FROM balance_changes WHERE chain_name = $1 AND user_address = $2 AND tx_hash = $3
+		`, chainName, user, logs[0].TxHash.Hex()); err != nil {
+			t.Fatalf("failed to count balance changes: %v", err)
+		}
+		if changes != 1 {
+			t.Errorf("%s has %d balance changes for the log, want 1", user, changes)
+		}
+
+		current, err := balanceRepo.GetUserBalance(ctx, chainName, user)
+		if err != nil {
+			t.Fatalf("GetUserBalance: %v", err)
+		}
+		if current == nil || current.Balance != want {
+			t.Errorf("%s balance = %+v, want %s", user, current, want)
+		}
+	}
+
+	var failed int
+	if err := db.GetContext(ctx, &failed, `SELECT COUNT(*) FROM failed_events WHERE chain_name = $1`, chainName); err != nil {
+		t.Fatalf("failed to count failed events: %v", err)
+	}
+	if failed != 0 {
+		t.Errorf("recorded %d failed events, want none", failed)
+	}
+}
@@ -0,0 +1,207 @@
+package listener
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/config"
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/balance"
+)
+
+const (
+	// fileReplayBatchSize 从文件重放时每批处理的日志数
+	fileReplayBatchSize = 1000
+	// maxJSONLineSize 单行 JSON 的最大长度
+	maxJSONLineSize = 4 * 1024 * 1024
+)
+
+// blockTimestamp 区块时间文件中的一行，字段与 eth_getBlockByNumber 返回值一致
+type blockTimestamp struct {
+	Number    hexutil.Uint64 `json:"number"`
+	Timestamp hexutil.Uint64 `json:"timestamp"`
+}
+
+// FileReplayResult 文件重放结果
+type FileReplayResult struct {
+	Logs          int
+	Processed     int
+	LastBlock     uint64
+	LastBlockTime time.Time
+}
+
+// FileReplayer 从导出的日志文件重放事件，走与实时监听相同的处理流程，不访问 RPC
+type FileReplayer struct {
+	listener   *EventListener
+	blockTimes StaticBlockTimes
+	syncRepo   repository.SyncRepository
+	logger     *logrus.Logger
+}
+
+// NewFileReplayer 创建文件重放器，处理失败的事件按 listenerConfig 写入死信表
+func NewFileReplayer(
+	chainName string,
+	chainConfig *config.ChainConfig,
+	listenerConfig *config.ListenerConfig,
+	syncRepo repository.SyncRepository,
+	failedEventRepo repository.FailedEventRepository,
+	rawEventRepo repository.RawEventRepository,
+	balanceService *balance.BalanceService,
+	logger *logrus.Logger,
+) (*FileReplayer, error) {
+	blockTimes := StaticBlockTimes{}
+
+	l, err := newEventListener(chainName, chainConfig, nil, blockTimes,
+		failedEventRepo, rawEventRepo, balanceService, logger)
+	if err != nil {
+		return nil, err
+	}
+	l.listenerConfig = listenerConfig
+
+	return &FileReplayer{
+		listener:   l,
+		blockTimes: blockTimes,
+		syncRepo:   syncRepo,
+		logger:     logger,
+	}, nil
+}
+
+// LoadBlockTimes 读取区块时间文件（JSON-lines，每行 {"number": "0x..", "timestamp": "0x.."}）
+func (r *FileReplayer) LoadBlockTimes(reader io.Reader) (int, error) {
+	count := 0
+	err := scanJSONLines(reader, func(lineNo int, line []byte) error {
+		var block blockTimestamp
+		if err := json.Unmarshal(line, &block); err != nil {
+			return fmt.Errorf("invalid block timestamp at line %d: %w", lineNo, err)
+		}
+		r.blockTimes[uint64(block.Number)] = time.Unix(int64(block.Timestamp), 0).UTC()
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// Reset 清空链上余额数据，用于从文件完整重建数据库
+func (r *FileReplayer) Reset(ctx context.Context) error {
+	return r.listener.resetChainData(ctx)
+}
+
+// Replay 读取日志文件（JSON-lines，每行为 eth_getLogs 返回的一条日志）并按顺序处理
+// 日志必须按 (blockNumber, logIndex) 升序排列，以保证余额重建结果确定
+func (r *FileReplayer) Replay(ctx context.Context, reader io.Reader) (*FileReplayResult, error) {
+	result := &FileReplayResult{}
+	var batch []types.Log
+	var lastBlock uint64
+	lastIndex := -1
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		processed, err := r.listener.processLogs(ctx, batch, true)
+		result.Processed += processed
+		batch = batch[:0]
+		return err
+	}
+
+	err := scanJSONLines(reader, func(lineNo int, line []byte) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var vLog types.Log
+		if err := json.Unmarshal(line, &vLog); err != nil {
+			return fmt.Errorf("invalid log at line %d: %w", lineNo, err)
+		}
+		if vLog.Removed || !r.listener.registry.HasContract(vLog.Address) {
+			return nil
+		}
+
+		if vLog.BlockNumber < lastBlock || (vLog.BlockNumber == lastBlock && int(vLog.Index) <= lastIndex) {
+			return fmt.Errorf("log at line %d is out of order (block %d, index %d)", lineNo, vLog.BlockNumber, vLog.Index)
+		}
+		if _, ok := r.blockTimes[vLog.BlockNumber]; !ok {
+			return fmt.Errorf("missing block timestamp for block %d (line %d)", vLog.BlockNumber, lineNo)
+		}
+		lastBlock, lastIndex = vLog.BlockNumber, int(vLog.Index)
+
+		result.Logs++
+		result.LastBlock = vLog.BlockNumber
+		result.LastBlockTime = r.blockTimes[vLog.BlockNumber]
+
+		batch = append(batch, vLog)
+		if len(batch) >= fileReplayBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// UpdateSyncState 将同步游标推进到重放的最后一个区块，之后启动的监听器从下一个区块继续
+func (r *FileReplayer) UpdateSyncState(ctx context.Context, result *FileReplayResult) error {
+	chainName := r.listener.chainName
+
+	if err := r.syncRepo.InitSyncState(ctx, chainName, int64(r.listener.chainConfig.StartBlock)); err != nil {
+		return fmt.Errorf("failed to init sync state: %w", err)
+	}
+
+	state, err := r.syncRepo.GetSyncState(ctx, chainName)
+	if err != nil {
+		return fmt.Errorf("failed to get sync state: %w", err)
+	}
+	if state == nil {
+		return fmt.Errorf("sync state not initialized for %s", chainName)
+	}
+
+	state.LastSyncedBlock = int64(result.LastBlock)
+	state.LastConfirmedBlock = int64(result.LastBlock)
+	state.LastSyncAt = time.Now()
+	state.LastSyncedBlockTime = &result.LastBlockTime
+	state.EventsProcessed += int64(result.Processed)
+	state.Status = model.StatusStopped
+
+	if err := r.syncRepo.UpdateSyncState(ctx, state); err != nil {
+		return fmt.Errorf("failed to update sync state: %w", err)
+	}
+
+	return nil
+}
+
+// scanJSONLines 逐行读取 JSON-lines 内容，忽略空行
+func scanJSONLines(reader io.Reader, fn func(lineNo int, line []byte) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLineSize)
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := fn(lineNo, []byte(line)); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
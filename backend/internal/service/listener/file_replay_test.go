@@ -0,0 +1,53 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"my-token-points/config"
+)
+
+func TestFileReplayerRecordsFailedLog(t *testing.T) {
+	failedEvents := &memoryFailedEventRepo{}
+	rawEvents := &memoryRawEventRepo{}
+	anomalies := &memoryAnomalyRepo{}
+
+	replayer, err := NewFileReplayer("testnet", testChainConfig(), &config.ListenerConfig{RetryInterval: 60},
+		nil, failedEvents, rawEvents, rejectingBalanceService(anomalies), testLogger())
+	if err != nil {
+		t.Fatalf("NewFileReplayer: %v", err)
+	}
+
+	if _, err := replayer.LoadBlockTimes(strings.NewReader(`{"number": "0xa", "timestamp": "0x6553f100"}`)); err != nil {
+		t.Fatalf("LoadBlockTimes: %v", err)
+	}
+
+	line, err := json.Marshal(transferLog(t, common.HexToAddress("0xaa"), common.HexToAddress("0xbb"), 100, 10, 0))
+	if err != nil {
+		t.Fatalf("failed to encode log: %v", err)
+	}
+
+	result, err := replayer.Replay(context.Background(), strings.NewReader(string(line)))
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if result.Logs != 1 || result.Processed != 0 {
+		t.Errorf("logs = %d, processed = %d, want 1 and 0", result.Logs, result.Processed)
+	}
+
+	// 失败的事件已归档并写入死信表，等待重试
+	if len(rawEvents.events) != 1 {
+		t.Errorf("archived %d events, want 1", len(rawEvents.events))
+	}
+	if len(failedEvents.events) != 1 {
+		t.Fatalf("recorded %d failed events, want 1", len(failedEvents.events))
+	}
+	if next := failedEvents.events[0].NextRetryAt; next == nil || !next.After(time.Now()) {
+		t.Errorf("next_retry_at = %v, want a time in the future", next)
+	}
+}
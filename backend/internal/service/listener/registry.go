@@ -0,0 +1,363 @@
+package listener
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"my-token-points/config"
+	"my-token-points/internal/model"
+)
+
+// defaultContractName 未配置 contracts 时使用的合约名称
+const defaultContractName = "MyToken"
+
+// defaultEventMappings MyToken 合约的默认事件映射
+// mint/burn 由 TokenMinted/TokenBurned 处理，Transfer 中的零地址转账需要忽略，避免重复记账
+var defaultEventMappings = []config.EventMappingConfig{
+	{
+		Event: "TokenMinted",
+		Effects: []config.BalanceEffectConfig{
+			{Direction: config.DirectionCredit, Account: "to", Amount: "amount", EventType: string(model.EventTypeMint)},
+		},
+	},
+	{
+		Event: "TokenBurned",
+		Effects: []config.BalanceEffectConfig{
+			{Direction: config.DirectionDebit, Account: "from", Amount: "amount", EventType: string(model.EventTypeBurn)},
+		},
+	},
+	{
+		Event:             "Transfer",
+		IgnoreZeroAddress: []string{"from", "to"},
+		Effects: []config.BalanceEffectConfig{
+			{Direction: config.DirectionDebit, Account: "from", Amount: "value", EventType: string(model.EventTypeTransferOut)},
+			{Direction: config.DirectionCredit, Account: "to", Amount: "value", EventType: string(model.EventTypeTransferIn)},
+		},
+	},
+}
+
+// balanceEventTypes balance_changes 表允许的事件类型
+var balanceEventTypes = map[model.EventType]bool{
+	model.EventTypeMint:        true,
+	model.EventTypeBurn:        true,
+	model.EventTypeTransferIn:  true,
+	model.EventTypeTransferOut: true,
+	model.EventTypeStake:       true,
+	model.EventTypeUnstake:     true,
+}
+
+// handlerKey 按合约地址和事件签名定位处理器
+type handlerKey struct {
+	address common.Address
+	topic   common.Hash
+}
+
+// balanceEffect 事件产生的单个余额变动
+type balanceEffect struct {
+	credit      bool
+	account     string
+	amount      string
+	eventType   model.EventType
+	balanceType model.BalanceType
+}
+
+// EventHandler 单个合约事件的处理规则
+type EventHandler struct {
+	Contract   string
+	Address    common.Address
+	Event      abi.Event
+	ignoreZero []string
+	effects    []balanceEffect
+}
+
+// BalanceDelta 事件解析出的余额变动
+type BalanceDelta struct {
+	UserAddress common.Address
+	Amount      *big.Int // 正数为增加，负数为减少
+	EventType   model.EventType
+	BalanceType model.BalanceType
+}
+
+// HandlerRegistry 事件处理器注册表，根据配置的 ABI 和事件映射生成
+type HandlerRegistry struct {
+	handlers  map[handlerKey]*EventHandler
+	addresses []common.Address
+}
+
+// NewHandlerRegistry 根据链配置创建事件处理器注册表
+func NewHandlerRegistry(chainConfig *config.ChainConfig) (*HandlerRegistry, error) {
+	contracts := chainConfig.Contracts
+	if len(contracts) == 0 {
+		// 兼容旧配置：只有 contract_address 时按 MyToken 合约处理
+		contracts = []config.ContractConfig{{
+			Name:    defaultContractName,
+			Address: chainConfig.ContractAddress,
+			Events:  defaultEventMappings,
+		}}
+	}
+
+	registry := &HandlerRegistry{
+		handlers: make(map[handlerKey]*EventHandler),
+	}
+
+	for _, contract := range contracts {
+		if err := registry.register(contract); err != nil {
+			return nil, fmt.Errorf("invalid contract %s on %s: %w", contract.Name, chainConfig.Name, err)
+		}
+	}
+
+	return registry, nil
+}
+
+// register 注册一个合约的所有事件映射
+func (r *HandlerRegistry) register(contract config.ContractConfig) error {
+	if !common.IsHexAddress(contract.Address) {
+		return fmt.Errorf("invalid address %q", contract.Address)
+	}
+	address := common.HexToAddress(contract.Address)
+
+	contractABI, err := loadABI(contract.ABIPath)
+	if err != nil {
+		return err
+	}
+
+	for _, mapping := range contract.Events {
+		event, ok := contractABI.Events[mapping.Event]
+		if !ok {
+			return fmt.Errorf("event %s not found in ABI", mapping.Event)
+		}
+
+		handler := &EventHandler{
+			Contract:   contract.Name,
+			Address:    address,
+			Event:      event,
+			ignoreZero: mapping.IgnoreZeroAddress,
+		}
+
+		for _, name := range mapping.IgnoreZeroAddress {
+			if err := checkArgument(event, name, abi.AddressTy); err != nil {
+				return err
+			}
+		}
+
+		for _, effect := range mapping.Effects {
+			if err := checkArgument(event, effect.Account, abi.AddressTy); err != nil {
+				return err
+			}
+			if err := checkArgument(event, effect.Amount, abi.UintTy, abi.IntTy); err != nil {
+				return err
+			}
+
+			eventType := model.EventType(effect.EventType)
+			if !balanceEventTypes[eventType] {
+				return fmt.Errorf("unsupported event_type %q for event %s", effect.EventType, event.Name)
+			}
+
+			// 托管合约的存取事件记入质押余额
+			balanceType := model.BalanceTypeWallet
+			if effect.Balance == config.BalanceStaked {
+				balanceType = model.BalanceTypeStaked
+			}
+
+			handler.effects = append(handler.effects, balanceEffect{
+				credit:      effect.Direction == config.DirectionCredit,
+				account:     effect.Account,
+				amount:      effect.Amount,
+				eventType:   eventType,
+				balanceType: balanceType,
+			})
+		}
+
+		key := handlerKey{address: address, topic: event.ID}
+		if _, exists := r.handlers[key]; exists {
+			return fmt.Errorf("duplicate mapping for event %s", event.Name)
+		}
+		r.handlers[key] = handler
+	}
+
+	for _, existing := range r.addresses {
+		if existing == address {
+			return nil
+		}
+	}
+	r.addresses = append(r.addresses, address)
+
+	return nil
+}
+
+// Addresses 返回需要查询日志的合约地址
+func (r *HandlerRegistry) Addresses() []common.Address {
+	return r.addresses
+}
+
+// HasContract 判断地址是否为被索引的合约
+func (r *HandlerRegistry) HasContract(address common.Address) bool {
+	for _, existing := range r.addresses {
+		if existing == address {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup 查找日志对应的处理器，不是我们关心的事件返回 false
+func (r *HandlerRegistry) Lookup(vLog types.Log) (*EventHandler, bool) {
+	if len(vLog.Topics) == 0 {
+		// 匿名事件
+		return nil, false
+	}
+
+	handler, ok := r.handlers[handlerKey{address: vLog.Address, topic: vLog.Topics[0]}]
+	return handler, ok
+}
+
+// Decode 解析日志并按映射计算余额变动，需要忽略的事件返回空
+func (h *EventHandler) Decode(vLog types.Log) ([]BalanceDelta, error) {
+	args := make(map[string]interface{})
+
+	// indexed 参数在 Topics 中
+	var indexed abi.Arguments
+	for _, input := range h.Event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(vLog.Topics) != len(indexed)+1 {
+		return nil, fmt.Errorf("invalid %s event: expected %d topics, got %d", h.Event.Name, len(indexed)+1, len(vLog.Topics))
+	}
+	if err := abi.ParseTopicsIntoMap(args, indexed, vLog.Topics[1:]); err != nil {
+		return nil, fmt.Errorf("failed to parse %s topics: %w", h.Event.Name, err)
+	}
+
+	if err := h.Event.Inputs.NonIndexed().UnpackIntoMap(args, vLog.Data); err != nil {
+		return nil, fmt.Errorf("failed to unpack %s event: %w", h.Event.Name, err)
+	}
+
+	for _, name := range h.ignoreZero {
+		if address, ok := args[name].(common.Address); ok && address == (common.Address{}) {
+			return nil, nil
+		}
+	}
+
+	deltas := make([]BalanceDelta, 0, len(h.effects))
+	for _, effect := range h.effects {
+		account, ok := args[effect.account].(common.Address)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s event: argument %s is not an address", h.Event.Name, effect.account)
+		}
+
+		amount, err := toBigInt(args[effect.amount])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s event: argument %s: %w", h.Event.Name, effect.amount, err)
+		}
+		if !effect.credit {
+			amount.Neg(amount)
+		}
+
+		deltas = append(deltas, BalanceDelta{
+			UserAddress: account,
+			Amount:      amount,
+			EventType:   effect.eventType,
+			BalanceType: effect.balanceType,
+		})
+	}
+
+	return netDeltas(deltas), nil
+}
+
+// netDeltas 合并同一账户同一余额类型的变动，抵消为零的丢弃
+// 例如自转账 Transfer(A, A, x) 的扣减和入账相互抵消，不产生余额变动
+func netDeltas(deltas []BalanceDelta) []BalanceDelta {
+	type deltaKey struct {
+		account     common.Address
+		balanceType model.BalanceType
+	}
+
+	netted := make([]BalanceDelta, 0, len(deltas))
+	positions := make(map[deltaKey]int, len(deltas))
+	for _, delta := range deltas {
+		key := deltaKey{account: delta.UserAddress, balanceType: delta.BalanceType}
+		i, ok := positions[key]
+		if !ok {
+			positions[key] = len(netted)
+			netted = append(netted, delta)
+			continue
+		}
+
+		// 合并后的变动类型取与净额方向相同的那个效果
+		merged := &netted[i]
+		merged.Amount = new(big.Int).Add(merged.Amount, delta.Amount)
+		if merged.Amount.Sign() == delta.Amount.Sign() {
+			merged.EventType = delta.EventType
+		}
+	}
+
+	result := netted[:0]
+	for _, delta := range netted {
+		if delta.Amount.Sign() != 0 {
+			result = append(result, delta)
+		}
+	}
+	return result
+}
+
+// loadABI 加载 ABI 文件，路径为空时使用内置的 MyToken ABI
+func loadABI(path string) (abi.ABI, error) {
+	definition := MyTokenABI
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return abi.ABI{}, fmt.Errorf("failed to read ABI file %s: %w", path, err)
+		}
+		definition = string(content)
+	}
+
+	contractABI, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("failed to parse contract ABI: %w", err)
+	}
+	return contractABI, nil
+}
+
+// checkArgument 检查事件参数是否存在且类型正确
+func checkArgument(event abi.Event, name string, kinds ...byte) error {
+	for _, input := range event.Inputs {
+		if input.Name != name {
+			continue
+		}
+		for _, kind := range kinds {
+			if input.Type.T == kind {
+				return nil
+			}
+		}
+		return fmt.Errorf("argument %s of event %s has unsupported type %s", name, event.Name, input.Type.String())
+	}
+	return fmt.Errorf("argument %s not found in event %s", name, event.Name)
+}
+
+// toBigInt 将解析出的整数参数转换为 big.Int（小于 64 位的整数解析为 Go 原生类型）
+func toBigInt(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return new(big.Int).Set(v), nil
+	case nil:
+		return nil, fmt.Errorf("missing value")
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	default:
+		return nil, fmt.Errorf("unsupported amount type %T", value)
+	}
+}
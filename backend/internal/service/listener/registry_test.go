@@ -0,0 +1,54 @@
+package listener
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDecodeSelfTransferNetsToZero(t *testing.T) {
+	registry, err := NewHandlerRegistry(testChainConfig())
+	if err != nil {
+		t.Fatalf("NewHandlerRegistry: %v", err)
+	}
+
+	account := common.HexToAddress("0xaa")
+	vLog := transferLog(t, account, account, 100, 10, 0)
+	handler, ok := registry.Lookup(vLog)
+	if !ok {
+		t.Fatal("no handler for Transfer")
+	}
+
+	deltas, err := handler.Decode(vLog)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(deltas) != 0 {
+		t.Errorf("self-transfer produced %d deltas, want none: %+v", len(deltas), deltas)
+	}
+}
+
+func TestDecodeTransferKeepsBothSides(t *testing.T) {
+	registry, err := NewHandlerRegistry(testChainConfig())
+	if err != nil {
+		t.Fatalf("NewHandlerRegistry: %v", err)
+	}
+
+	from, to := common.HexToAddress("0xaa"), common.HexToAddress("0xbb")
+	vLog := transferLog(t, from, to, 100, 10, 0)
+	handler, _ := registry.Lookup(vLog)
+
+	deltas, err := handler.Decode(vLog)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(deltas) != 2 {
+		t.Fatalf("got %d deltas, want 2", len(deltas))
+	}
+	if deltas[0].UserAddress != from || deltas[0].Amount.Int64() != -100 {
+		t.Errorf("debit = %s %s, want %s -100", deltas[0].UserAddress.Hex(), deltas[0].Amount, from.Hex())
+	}
+	if deltas[1].UserAddress != to || deltas[1].Amount.Int64() != 100 {
+		t.Errorf("credit = %s %s, want %s 100", deltas[1].UserAddress.Hex(), deltas[1].Amount, to.Hex())
+	}
+}
@@ -2,8 +2,10 @@ package points
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,32 +13,66 @@ import (
 
 	"my-token-points/internal/model"
 	"my-token-points/internal/repository"
+	"my-token-points/internal/service/exclusion"
+	"my-token-points/internal/service/sybil"
 )
 
+// ErrPeriodNotIndexed 监听器尚未索引到计算周期结束之后的区块
+var ErrPeriodNotIndexed = errors.New("period is not fully indexed yet")
+
 // PointsConfig 积分配置
 type PointsConfig struct {
 	// 积分利率（每小时每token的积分）
 	HourlyRate float64
-	// 计算间隔（小时）
+	// 计算周期长度（默认 1 小时）
 	CalcInterval time.Duration
+	// 各链的计算周期长度（未配置的链使用 CalcInterval）
+	ChainIntervals map[string]time.Duration
 	// 是否启用回溯计算
 	EnableBackfill bool
 	// 回溯开始时间
 	BackfillStartTime *time.Time
+	// 质押余额的积分倍数
+	StakedMultiplier float64
+	// 各链托管合约地址（合约自身的钱包余额不计积分）
+	CustodyAddresses map[string][]string
+	// 推荐人获得被推荐人每期积分的比例（0 表示关闭）
+	ReferralRate float64
+	// 积分有效期（从计算周期结束起算，0 表示不过期）
+	ExpiryPeriod time.Duration
+	// 即将过期积分的统计窗口
+	ExpiringSoonWindow time.Duration
+	// 持有时长倍数（nil 表示不启用）
+	HoldingStreak *StreakConfig
+	// 按日/周/月汇总积分使用的时区（默认 UTC）
+	ReportingLocation *time.Location
 }
 
 // PointsService 积分服务
 type PointsService struct {
-	pointsRepo  repository.PointsRepository
-	balanceRepo repository.BalanceRepository
-	logger      *logrus.Logger
-	config      *PointsConfig
+	pointsRepo        repository.PointsRepository
+	balanceRepo       repository.BalanceRepository
+	exclusionService  *exclusion.ExclusionService
+	referralRepo      repository.ReferralRepository
+	recalculationRepo repository.RecalculationRepository
+	syncRepo          repository.SyncRepository
+	sybilService      *sybil.SybilService
+	logger            *logrus.Logger
+	config            *PointsConfig
+
+	// 模拟和重算时的持有状态（非 nil 表示只保存在内存中，不写入 holding_streaks）
+	memStreaks map[string]*model.HoldingStreak
 }
 
 // NewPointsService 创建积分服务
 func NewPointsService(
 	pointsRepo repository.PointsRepository,
 	balanceRepo repository.BalanceRepository,
+	exclusionService *exclusion.ExclusionService,
+	referralRepo repository.ReferralRepository,
+	recalculationRepo repository.RecalculationRepository,
+	syncRepo repository.SyncRepository,
+	sybilService *sybil.SybilService,
 	logger *logrus.Logger,
 	config *PointsConfig,
 ) *PointsService {
@@ -47,16 +83,65 @@ func NewPointsService(
 	if config.CalcInterval == 0 {
 		config.CalcInterval = time.Hour // 默认每小时计算一次
 	}
+	if config.StakedMultiplier == 0 {
+		config.StakedMultiplier = 1 // 默认质押余额与钱包余额等同
+	}
+	if config.ExpiringSoonWindow == 0 {
+		config.ExpiringSoonWindow = 7 * 24 * time.Hour // 默认统计 7 天内过期的积分
+	}
+	if config.ReportingLocation == nil {
+		config.ReportingLocation = time.UTC
+	}
 
 	return &PointsService{
-		pointsRepo:  pointsRepo,
-		balanceRepo: balanceRepo,
-		logger:      logger,
-		config:      config,
+		pointsRepo:        pointsRepo,
+		balanceRepo:       balanceRepo,
+		exclusionService:  exclusionService,
+		referralRepo:      referralRepo,
+		recalculationRepo: recalculationRepo,
+		syncRepo:          syncRepo,
+		sybilService:      sybilService,
+		logger:            logger,
+		config:            config,
+	}
+}
+
+// CalcInterval 链的计算周期长度
+func (s *PointsService) CalcInterval(chainName string) time.Duration {
+	if interval := s.config.ChainIntervals[chainName]; interval > 0 {
+		return interval
 	}
+	return s.config.CalcInterval
+}
+
+// LastPeriod 返回 now 之前最近一个已结束的计算周期 [start, end)，周期按 UTC 对齐
+func (s *PointsService) LastPeriod(chainName string, now time.Time) (time.Time, time.Time) {
+	interval := s.CalcInterval(chainName)
+	end := now.UTC().Truncate(interval)
+	return end.Add(-interval), end
+}
+
+// PeriodCount 时间段 [start, end) 内的计算周期数，与回溯相同按周期长度对齐
+func (s *PointsService) PeriodCount(chainName string, start, end time.Time) int {
+	interval := s.CalcInterval(chainName)
+	start, end = start.UTC().Truncate(interval), end.UTC().Truncate(interval)
+	if !end.After(start) {
+		return 0
+	}
+	return int(end.Sub(start) / interval)
+}
+
+// detached 返回使用指定配置、持有状态只保存在内存中的服务副本，用于模拟和重算
+func (s *PointsService) detached(config *PointsConfig) *PointsService {
+	copied := *s
+	copied.config = config
+	copied.memStreaks = make(map[string]*model.HoldingStreak)
+	return &copied
 }
 
 // CalculatePointsForPeriod 计算指定时间段的积分
+// 钱包余额和质押余额分别按时间加权计算，质押部分乘以 StakedMultiplier，
+// 启用持有时长倍数时每段再乘以该段的连续持有倍数，最后乘以风险降权倍数
 func (s *PointsService) CalculatePointsForPeriod(
 	ctx context.Context,
 	chainName string,
@@ -64,51 +149,131 @@ func (s *PointsService) CalculatePointsForPeriod(
 	periodStart time.Time,
 	periodEnd time.Time,
 	calculationType string,
+	riskMultiplier float64,
+	basedOnBlock *int64,
 ) (float64, error) {
 	userAddress = strings.ToLower(userAddress)
 
+	result, err := s.computePointsForPeriod(ctx, chainName, userAddress, periodStart, periodEnd, riskMultiplier)
+	if err != nil || result == nil {
+		return 0, err
+	}
+
+	// 积分历史、总积分和 earn 流水在一个事务中写入，失败时都不写入
+	history := s.newPointsHistory(chainName, userAddress, periodStart, periodEnd, result.snapshots, result.points, calculationType, riskMultiplier, basedOnBlock)
+	recorded, err := s.pointsRepo.RecordEarnedPoints(ctx, history)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record earned points: %w", err)
+	}
+	if !recorded {
+		// 其他实例或回溯任务已计算该周期，不重复计入（也不重复发放推荐奖励）
+		s.logger.Infof("Points for %s on %s (%s to %s) already recorded, skipped",
+			userAddress, chainName, periodStart.Format(time.RFC3339), periodEnd.Format(time.RFC3339))
+		return 0, nil
+	}
+
+	s.logger.Infof("Calculated points for %s on %s (%s to %s): %.6f",
+		userAddress, chainName, periodStart.Format(time.RFC3339), periodEnd.Format(time.RFC3339), result.points)
+
+	return result.points, nil
+}
+
+// periodPoints 一个计算周期的积分结果
+type periodPoints struct {
+	points    float64
+	snapshots model.BalanceSnapshots
+}
+
+// computePointsForPeriod 计算用户在时间段内的积分，不写入积分历史
+// 时间段内没有余额时返回 nil
+func (s *PointsService) computePointsForPeriod(
+	ctx context.Context,
+	chainName string,
+	userAddress string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	riskMultiplier float64,
+) (*periodPoints, error) {
 	// 获取该时间段内的所有余额变动
 	changes, err := s.balanceRepo.GetBalanceChanges(ctx, chainName, userAddress, periodStart, periodEnd)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get balance changes: %w", err)
+		return nil, fmt.Errorf("failed to get balance changes: %w", err)
 	}
 
-	// 如果没有变动，获取该时间段开始前的最后余额
-	if len(changes) == 0 {
-		// 尝试获取更早的变动来确定初始余额
-		earlierChanges, err := s.balanceRepo.GetBalanceChanges(
-			ctx, chainName, userAddress,
-			periodStart.Add(-24*time.Hour), periodStart,
-		)
-		if err == nil && len(earlierChanges) > 0 {
-			// 使用最后一个变动的 BalanceAfter 作为初始余额
-			lastChange := earlierChanges[len(earlierChanges)-1]
-			balance := new(big.Int)
-			if _, ok := balance.SetString(lastChange.BalanceAfter, 10); ok {
-				// 整个周期保持这个余额
-				points := s.calculatePointsForBalance(balance, periodStart, periodEnd)
-				
-				// 记录积分历史
-				snapshot := model.BalanceSnapshots{
-					{
-						Balance:   lastChange.BalanceAfter,
-						StartTime: periodStart,
-						EndTime:   periodEnd,
-					},
-				}
-				if err := s.recordPointsHistory(ctx, chainName, userAddress, periodStart, periodEnd, snapshot, points, calculationType); err != nil {
-					s.logger.Warnf("Failed to record points history: %v", err)
+	// 持有时长倍数（未启用时为 nil）
+	timeline, err := s.buildHoldingTimeline(ctx, chainName, userAddress, periodStart, periodEnd, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalPoints float64
+	var snapshots model.BalanceSnapshots
+	var earlierChanges []*model.BalanceChange
+	earlierLoaded := false
+
+	for _, balanceType := range []model.BalanceType{model.BalanceTypeWallet, model.BalanceTypeStaked} {
+		multiplier := 1.0
+		if balanceType == model.BalanceTypeStaked {
+			multiplier = s.config.StakedMultiplier
+		}
+
+		typeChanges := filterChanges(changes, balanceType)
+
+		// 如果没有变动，获取该时间段开始前的最后余额
+		if len(typeChanges) == 0 {
+			if !earlierLoaded {
+				// 尝试获取更早的变动来确定初始余额
+				earlierChanges, err = s.balanceRepo.GetBalanceChanges(
+					ctx, chainName, userAddress,
+					periodStart.Add(-24*time.Hour), periodStart,
+				)
+				if err != nil {
+					earlierChanges = nil
 				}
-				
-				return points, nil
+				earlierLoaded = true
+			}
+
+			typeEarlier := filterChanges(earlierChanges, balanceType)
+			if len(typeEarlier) == 0 {
+				// 如果找不到历史余额，说明该时间段内余额为0
+				continue
 			}
+
+			// 使用最后一个变动的 BalanceAfter 作为初始余额，整个周期保持这个余额
+			lastChange := typeEarlier[len(typeEarlier)-1]
+			balance := new(big.Int)
+			if _, ok := balance.SetString(lastChange.BalanceAfter, 10); !ok || balance.Sign() <= 0 {
+				continue
+			}
+
+			totalPoints += s.calculatePointsForBalance(balance, periodStart, periodEnd, timeline) * multiplier
+			snapshots = append(snapshots, newSnapshot(balance, balanceType, periodStart, periodEnd))
+			continue
 		}
-		
-		// 如果找不到历史余额，说明该时间段内余额为0
-		return 0, nil
+
+		points, typeSnapshots, err := s.calculateTimeWeightedPoints(typeChanges, balanceType, periodStart, periodEnd, timeline)
+		if err != nil {
+			return nil, err
+		}
+		totalPoints += points * multiplier
+		snapshots = append(snapshots, typeSnapshots...)
 	}
 
-	// 计算时间加权积分
+	if len(changes) == 0 && len(snapshots) == 0 {
+		return nil, nil
+	}
+
+	return &periodPoints{points: totalPoints * riskMultiplier, snapshots: snapshots}, nil
+}
+
+// calculateTimeWeightedPoints 根据一种余额的变动计算时间加权积分（未乘倍数）
+func (s *PointsService) calculateTimeWeightedPoints(
+	changes []*model.BalanceChange,
+	balanceType model.BalanceType,
+	periodStart time.Time,
+	periodEnd time.Time,
+	timeline *holdingTimeline,
+) (float64, model.BalanceSnapshots, error) {
 	var totalPoints float64
 	var snapshots model.BalanceSnapshots
 
@@ -121,7 +286,7 @@ func (s *PointsService) CalculatePointsForPeriod(
 		// 使用第一个变动的 BalanceBefore
 		currentBalance = new(big.Int)
 		if _, ok := currentBalance.SetString(changes[0].BalanceBefore, 10); !ok {
-			return 0, fmt.Errorf("invalid balance before: %s", changes[0].BalanceBefore)
+			return 0, nil, fmt.Errorf("invalid balance before: %s", changes[0].BalanceBefore)
 		}
 	} else {
 		currentBalance = big.NewInt(0)
@@ -136,7 +301,7 @@ func (s *PointsService) CalculatePointsForPeriod(
 			// 更新初始余额
 			currentBalance = new(big.Int)
 			if _, ok := currentBalance.SetString(change.BalanceAfter, 10); !ok {
-				return 0, fmt.Errorf("invalid balance after: %s", change.BalanceAfter)
+				return 0, nil, fmt.Errorf("invalid balance after: %s", change.BalanceAfter)
 			}
 			continue
 		}
@@ -147,67 +312,79 @@ func (s *PointsService) CalculatePointsForPeriod(
 
 		// 计算从 currentTime 到 changeTime 期间的积分
 		if changeTime.After(currentTime) {
-			points := s.calculatePointsForBalance(currentBalance, currentTime, changeTime)
+			points := s.calculatePointsForBalance(currentBalance, currentTime, changeTime, timeline)
 			totalPoints += points
 
 			// 记录快照
 			if currentBalance.Sign() > 0 {
-				snapshots = append(snapshots, model.BalanceSnapshot{
-					Balance:   currentBalance.String(),
-					StartTime: currentTime,
-					EndTime:   changeTime,
-				})
+				snapshots = append(snapshots, newSnapshot(currentBalance, balanceType, currentTime, changeTime))
 			}
 
-			s.logger.Debugf("Period: %s to %s, Balance: %s, Points: %.6f",
+			s.logger.Debugf("Period: %s to %s, %s balance: %s, Points: %.6f",
 				currentTime.Format(time.RFC3339), changeTime.Format(time.RFC3339),
-				currentBalance.String(), points)
+				balanceType, currentBalance.String(), points)
 		}
 
 		// 更新余额和时间
 		currentBalance = new(big.Int)
 		if _, ok := currentBalance.SetString(change.BalanceAfter, 10); !ok {
-			return 0, fmt.Errorf("invalid balance after: %s", change.BalanceAfter)
+			return 0, nil, fmt.Errorf("invalid balance after: %s", change.BalanceAfter)
 		}
 		currentTime = changeTime
 	}
 
 	// 处理最后一个时间段（从最后一个变动到period结束）
 	if currentTime.Before(periodEnd) && currentBalance.Sign() > 0 {
-		points := s.calculatePointsForBalance(currentBalance, currentTime, periodEnd)
+		points := s.calculatePointsForBalance(currentBalance, currentTime, periodEnd, timeline)
 		totalPoints += points
 
-		snapshots = append(snapshots, model.BalanceSnapshot{
-			Balance:   currentBalance.String(),
-			StartTime: currentTime,
-			EndTime:   periodEnd,
-		})
+		snapshots = append(snapshots, newSnapshot(currentBalance, balanceType, currentTime, periodEnd))
 
-		s.logger.Debugf("Final period: %s to %s, Balance: %s, Points: %.6f",
+		s.logger.Debugf("Final period: %s to %s, %s balance: %s, Points: %.6f",
 			currentTime.Format(time.RFC3339), periodEnd.Format(time.RFC3339),
-			currentBalance.String(), points)
+			balanceType, currentBalance.String(), points)
 	}
 
-	// 记录积分历史
-	if err := s.recordPointsHistory(ctx, chainName, userAddress, periodStart, periodEnd, snapshots, totalPoints, calculationType); err != nil {
-		s.logger.Warnf("Failed to record points history: %v", err)
-	}
+	return totalPoints, snapshots, nil
+}
 
-	s.logger.Infof("Calculated points for %s on %s (%s to %s): %.6f",
-		userAddress, chainName, periodStart.Format(time.RFC3339), periodEnd.Format(time.RFC3339), totalPoints)
+// filterChanges 过滤出指定余额类型的变动
+func filterChanges(changes []*model.BalanceChange, balanceType model.BalanceType) []*model.BalanceChange {
+	var filtered []*model.BalanceChange
+	for _, change := range changes {
+		changeType := change.BalanceType
+		if changeType == "" {
+			changeType = model.BalanceTypeWallet
+		}
+		if changeType == balanceType {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
 
-	return totalPoints, nil
+// newSnapshot 创建余额快照，钱包余额不记录类型以兼容历史数据
+func newSnapshot(balance *big.Int, balanceType model.BalanceType, startTime, endTime time.Time) model.BalanceSnapshot {
+	snapshot := model.BalanceSnapshot{
+		Balance:   balance.String(),
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+	if balanceType == model.BalanceTypeStaked {
+		snapshot.BalanceType = balanceType
+	}
+	return snapshot
 }
 
 // calculatePointsForBalance 计算单个余额在指定时间段的积分
-func (s *PointsService) calculatePointsForBalance(balance *big.Int, startTime, endTime time.Time) float64 {
+// 启用持有时长倍数时，持有时间按各段倍数加权
+func (s *PointsService) calculatePointsForBalance(balance *big.Int, startTime, endTime time.Time, timeline *holdingTimeline) float64 {
 	if balance.Sign() <= 0 {
 		return 0
 	}
 
 	// 计算持有时间（小时）
-	duration := endTime.Sub(startTime)
-	hours := duration.Hours()
+	hours := timeline.weightedHours(startTime, endTime)
 
 	// 转换余额为 float64 (考虑到 ERC20 的 18 位小数)
 	balanceFloat := new(big.Float).SetInt(balance)
@@ -220,7 +397,31 @@ func (s *PointsService) calculatePointsForBalance(balance *big.Int, startTime, e
 	return points
 }
 
+// IndexedBlock 检查监听器是否已索引到 periodEnd 之后的区块，返回当前已同步的区块高度
+// 未索引完成时返回 ErrPeriodNotIndexed；未配置同步状态仓储时不检查，返回 nil
+func (s *PointsService) IndexedBlock(ctx context.Context, chainName string, periodEnd time.Time) (*int64, error) {
+	if s.syncRepo == nil {
+		return nil, nil
+	}
+
+	state, err := s.syncRepo.GetSyncState(ctx, chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync state: %w", err)
+	}
+	if state == nil || state.LastSyncedBlockTime == nil {
+		return nil, fmt.Errorf("%w: no synced blocks on %s", ErrPeriodNotIndexed, chainName)
+	}
+	if state.LastSyncedBlockTime.Before(periodEnd) {
+		return nil, fmt.Errorf("%w: %s synced to block %d at %s, period ends at %s", ErrPeriodNotIndexed,
+			chainName, state.LastSyncedBlock, state.LastSyncedBlockTime.Format(time.RFC3339), periodEnd.Format(time.RFC3339))
+	}
+
+	block := state.LastSyncedBlock
+	return &block, nil
+}
+
 // CalculatePointsForAllUsers 计算所有用户在指定时间段的积分
+// 监听器尚未索引到周期结束之后的区块时返回 ErrPeriodNotIndexed，不写入任何积分
 func (s *PointsService) CalculatePointsForAllUsers(
 	ctx context.Context,
 	chainName string,
@@ -228,84 +429,223 @@ func (s *PointsService) CalculatePointsForAllUsers(
 	periodEnd time.Time,
 	calculationType string,
 ) error {
-	// 获取所有有余额的用户
-	balances, err := s.balanceRepo.GetUserBalances(ctx, chainName, 0, 10000) // TODO: 分页处理
+	basedOnBlock, err := s.IndexedBlock(ctx, chainName, periodEnd)
 	if err != nil {
-		return fmt.Errorf("failed to get user balances: %w", err)
+		return err
 	}
 
+	targets, err := s.calculationTargets(ctx, chainName)
+	if err != nil {
+		return err
+	}
+	targets.basedOnBlock = basedOnBlock
+
 	s.logger.Infof("Calculating points for %d users on %s (period: %s to %s)",
-		len(balances), chainName, periodStart.Format(time.RFC3339), periodEnd.Format(time.RFC3339))
+		len(targets.users), chainName, periodStart.Format(time.RFC3339), periodEnd.Format(time.RFC3339))
 
 	successCount := 0
 	errorCount := 0
+	earned := make(map[string]float64) // 本期各用户获得的积分，用于计算推荐奖励
 
-	for _, balance := range balances {
+	for _, userAddress := range targets.users {
 		// 计算该用户的积分
 		earnedPoints, err := s.CalculatePointsForPeriod(
-			ctx, chainName, balance.UserAddress,
-			periodStart, periodEnd, calculationType,
+			ctx, chainName, userAddress,
+			periodStart, periodEnd, calculationType, targets.riskMultiplier(userAddress), targets.basedOnBlock,
 		)
 		if err != nil {
-			s.logger.Errorf("Failed to calculate points for user %s: %v", balance.UserAddress, err)
+			s.logger.Errorf("Failed to calculate points for user %s: %v", userAddress, err)
 			errorCount++
 			continue
 		}
 
-		// 更新用户总积分
-		if err := s.updateUserTotalPoints(ctx, chainName, balance.UserAddress, earnedPoints, periodEnd); err != nil {
-			s.logger.Errorf("Failed to update total points for user %s: %v", balance.UserAddress, err)
-			errorCount++
-			continue
+		if earnedPoints > 0 {
+			earned[userAddress] = earnedPoints
 		}
-
 		successCount++
 	}
 
-	s.logger.Infof("Points calculation completed: %d succeeded, %d failed", successCount, errorCount)
+	s.logger.Infof("Points calculation completed: %d succeeded, %d failed, %d excluded", successCount, errorCount, targets.skipped)
 
 	if errorCount > 0 && successCount == 0 {
 		return fmt.Errorf("all user points calculations failed")
 	}
 
+	if err := s.grantReferralBonuses(ctx, chainName, periodStart, periodEnd, earned, targets); err != nil {
+		return fmt.Errorf("failed to grant referral bonuses: %w", err)
+	}
+
 	return nil
 }
 
-// updateUserTotalPoints 更新用户总积分
-func (s *PointsService) updateUserTotalPoints(
+// calculationTargets 一次积分计算的用户范围
+type calculationTargets struct {
+	users           []string           // 需要计算积分的用户
+	skipped         int                // 托管合约、排除地址和被女巫检测排除的用户数
+	excluded        map[string]bool    // 不计积分的地址（含被女巫检测排除的地址）
+	riskMultipliers map[string]float64 // 女巫检测标记地址的积分倍数
+	basedOnBlock    *int64             // 计算时已同步的区块高度
+}
+
+// riskMultiplier 返回用户的风险降权倍数（未标记为 1）
+func (t *calculationTargets) riskMultiplier(userAddress string) float64 {
+	if multiplier, flagged := t.riskMultipliers[userAddress]; flagged {
+		return multiplier
+	}
+	return 1
+}
+
+// calculationTargets 查询链上需要计算积分的用户，并去掉托管合约和排除地址
+func (s *PointsService) calculationTargets(ctx context.Context, chainName string) (*calculationTargets, error) {
+	// 获取所有有余额的用户
+	balances, err := s.balanceRepo.GetUserBalances(ctx, chainName, 0, 10000) // TODO: 分页处理
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user balances: %w", err)
+	}
+
+	// 排除地址（国库、多签、DEX 池等）不计算积分
+	excluded, err := s.exclusionService.ExcludedSet(ctx, chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get excluded addresses: %w", err)
+	}
+
+	// 女巫检测标记的地址降权，倍数为 0 的按排除处理
+	riskMultipliers, err := s.riskMultipliers(ctx, chainName)
+	if err != nil {
+		return nil, err
+	}
+	for address, multiplier := range riskMultipliers {
+		if multiplier <= 0 {
+			excluded[address] = true
+		}
+	}
+
+	targets := &calculationTargets{excluded: excluded, riskMultipliers: riskMultipliers}
+	for _, balance := range balances {
+		// 托管合约持有的是用户质押的代币，已按质押余额计入用户积分
+		if s.isCustodyAddress(chainName, balance.UserAddress) || excluded[balance.UserAddress] {
+			targets.skipped++
+			continue
+		}
+		targets.users = append(targets.users, balance.UserAddress)
+	}
+	return targets, nil
+}
+
+// grantReferralBonuses 按比例给推荐人发放被推荐人本期所得积分的奖励
+// 每个被推荐人对应一条 referral 类型的积分历史，奖励不再向上级传递
+func (s *PointsService) grantReferralBonuses(
 	ctx context.Context,
 	chainName string,
-	userAddress string,
-	earnedPoints float64,
-	calcTime time.Time,
+	periodStart time.Time,
+	periodEnd time.Time,
+	earned map[string]float64,
+	targets *calculationTargets,
 ) error {
-	// 获取当前积分
-	currentPoints, err := s.pointsRepo.GetUserPoints(ctx, chainName, userAddress)
+	bonuses, err := s.referralBonusHistories(ctx, chainName, periodStart, periodEnd, earned, targets)
 	if err != nil {
 		return err
 	}
 
-	var newTotalPoints float64
-	if currentPoints != nil {
-		newTotalPoints = currentPoints.TotalPoints + earnedPoints
-	} else {
-		newTotalPoints = earnedPoints
+	grantedCount := 0
+	for _, history := range bonuses {
+		recorded, err := s.pointsRepo.RecordEarnedPoints(ctx, history)
+		if err != nil {
+			s.logger.Errorf("Failed to record referral bonus for %s (referee %s): %v", history.UserAddress, *history.SourceAddress, err)
+			continue
+		}
+		if recorded {
+			grantedCount++
+		}
 	}
 
-	// 更新积分
-	updatedPoints := &model.UserPoints{
-		ChainName:   chainName,
-		UserAddress: userAddress,
-		TotalPoints: newTotalPoints,
-		LastCalcAt:  &calcTime,
+	if grantedCount > 0 {
+		s.logger.Infof("Granted %d referral bonuses on %s (rate: %.2f)", grantedCount, chainName, s.config.ReferralRate)
 	}
-
-	return s.pointsRepo.UpsertUserPoints(ctx, updatedPoints)
+	return nil
 }
 
-// recordPointsHistory 记录积分历史
-func (s *PointsService) recordPointsHistory(
+// referralBonusHistories 生成本期的推荐奖励积分历史（不写入）
+// 被女巫检测降权的推荐人，奖励同样乘以降权倍数
+func (s *PointsService) referralBonusHistories(
 	ctx context.Context,
+	chainName string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	earned map[string]float64,
+	targets *calculationTargets,
+) ([]*model.PointsHistory, error) {
+	if s.config.ReferralRate <= 0 || s.referralRepo == nil || len(earned) == 0 {
+		return nil, nil
+	}
+
+	referees := make([]string, 0, len(earned))
+	for address := range earned {
+		referees = append(referees, address)
+	}
+	sort.Strings(referees)
+
+	referrers, err := s.referralRepo.GetReferrers(ctx, referees)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referrers: %w", err)
+	}
+
+	var bonuses []*model.PointsHistory
+	for _, referee := range referees {
+		referrer, ok := referrers[referee]
+		if !ok || !s.earnsReferralBonus(chainName, referrer, targets) {
+			continue
+		}
+
+		riskMultiplier := targets.riskMultiplier(referrer)
+		source := referee
+		bonuses = append(bonuses, &model.PointsHistory{
+			ChainName:       chainName,
+			UserAddress:     referrer,
+			CalcPeriodStart: periodStart,
+			CalcPeriodEnd:   periodEnd,
+			BalanceSnapshot: model.BalanceSnapshots{},
+			PointsEarned:    earned[referee] * s.config.ReferralRate * riskMultiplier,
+			CalculationType: model.CalcTypeReferral,
+			SourceAddress:   &source,
+			ExpiresAt:       s.lotExpiry(periodEnd),
+			RiskMultiplier:  riskMultiplier,
+			BasedOnBlock:    targets.basedOnBlock,
+		})
+	}
+	return bonuses, nil
+}
+
+// earnsReferralBonus 判断推荐人能否获得推荐奖励（托管合约和排除地址不能）
+func (s *PointsService) earnsReferralBonus(chainName, referrer string, targets *calculationTargets) bool {
+	return !targets.excluded[referrer] && !s.isCustodyAddress(chainName, referrer)
+}
+
+// riskMultipliers 返回女巫检测标记地址的积分倍数（未启用检测时为空）
+func (s *PointsService) riskMultipliers(ctx context.Context, chainName string) (map[string]float64, error) {
+	if s.sybilService == nil {
+		return map[string]float64{}, nil
+	}
+
+	multipliers, err := s.sybilService.RiskMultipliers(ctx, chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get risk multipliers: %w", err)
+	}
+	return multipliers, nil
+}
+
+// isCustodyAddress 判断地址是否为链上的托管合约
+func (s *PointsService) isCustodyAddress(chainName, address string) bool {
+	for _, custody := range s.config.CustodyAddresses[chainName] {
+		if strings.EqualFold(custody, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// newPointsHistory 生成一个计算周期的积分历史（积分批次）
+func (s *PointsService) newPointsHistory(
 	chainName string,
 	userAddress string,
 	periodStart time.Time,
@@ -313,8 +653,10 @@ func (s *PointsService) recordPointsHistory(
 	snapshots model.BalanceSnapshots,
 	pointsEarned float64,
 	calculationType string,
-) error {
-	history := &model.PointsHistory{
+	riskMultiplier float64,
+	basedOnBlock *int64,
+) *model.PointsHistory {
+	return &model.PointsHistory{
 		ChainName:       chainName,
 		UserAddress:     userAddress,
 		CalcPeriodStart: periodStart,
@@ -322,15 +664,77 @@ func (s *PointsService) recordPointsHistory(
 		BalanceSnapshot: snapshots,
 		PointsEarned:    pointsEarned,
 		CalculationType: calculationType,
+		ExpiresAt:       s.lotExpiry(periodEnd),
+		RiskMultiplier:  riskMultiplier,
+		BasedOnBlock:    basedOnBlock,
+	}
+}
+
+// lotExpiry 计算周期结束时间对应的批次过期时间，未启用过期时返回 nil
+func (s *PointsService) lotExpiry(periodEnd time.Time) *time.Time {
+	if s.config.ExpiryPeriod <= 0 {
+		return nil
+	}
+	expiresAt := periodEnd.Add(s.config.ExpiryPeriod)
+	return &expiresAt
+}
+
+// ExpirePoints 按先进先出过期链上所有在 asOf 之前到期的积分批次
+func (s *PointsService) ExpirePoints(ctx context.Context, chainName string, asOf time.Time) error {
+	users, err := s.pointsRepo.ListUsersWithExpiredLots(ctx, chainName, asOf)
+	if err != nil {
+		return fmt.Errorf("failed to list users with expired lots: %w", err)
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	var totalExpired float64
+	errorCount := 0
+	for _, userAddress := range users {
+		expired, err := s.pointsRepo.ExpireUserLots(ctx, chainName, userAddress, asOf)
+		if err != nil {
+			s.logger.Errorf("Failed to expire points for user %s: %v", userAddress, err)
+			errorCount++
+			continue
+		}
+		totalExpired += expired
 	}
 
-	return s.pointsRepo.RecordPointsHistory(ctx, history)
+	s.logger.Infof("Points expiry completed on %s: %.4f points expired for %d users, %d failed",
+		chainName, totalExpired, len(users)-errorCount, errorCount)
+
+	if errorCount > 0 && errorCount == len(users) {
+		return fmt.Errorf("all user points expirations failed")
+	}
+	return nil
 }
 
-// GetUserPoints 查询用户积分
+// GetUserPoints 查询用户积分（包含连续持有倍数和即将过期的积分）
 func (s *PointsService) GetUserPoints(ctx context.Context, chainName, userAddress string) (*model.UserPoints, error) {
 	userAddress = strings.ToLower(userAddress)
-	return s.pointsRepo.GetUserPoints(ctx, chainName, userAddress)
+	points, err := s.pointsRepo.GetUserPoints(ctx, chainName, userAddress)
+	if err != nil || points == nil {
+		return points, err
+	}
+
+	if err := s.fillHoldingStreak(ctx, points); err != nil {
+		return nil, err
+	}
+
+	if s.config.ExpiryPeriod <= 0 {
+		return points, nil
+	}
+
+	// 统计即将过期的积分
+	now := time.Now()
+	points.ExpiringSoon, points.NextExpiryAt, err = s.pointsRepo.GetExpiringPoints(
+		ctx, chainName, userAddress, now, now.Add(s.config.ExpiringSoonWindow),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiring points: %w", err)
+	}
+	return points, nil
 }
 
 // GetUserPointsHistory 查询用户积分历史
@@ -343,23 +747,48 @@ func (s *PointsService) GetUserPointsHistory(
 	return s.pointsRepo.GetPointsHistory(ctx, chainName, userAddress, startTime, endTime)
 }
 
-// GetTopUsers 获取积分排行榜
+// GetUserTransactions 分页查询用户积分流水
+func (s *PointsService) GetUserTransactions(
+	ctx context.Context,
+	chainName, userAddress, txType string,
+	offset, limit int,
+) ([]*model.PointsTransaction, error) {
+	userAddress = strings.ToLower(userAddress)
+	return s.pointsRepo.ListTransactions(ctx, chainName, userAddress, txType, offset, limit)
+}
+
+// GetTopUsers 获取积分排行榜（不包含排除地址）
 func (s *PointsService) GetTopUsers(ctx context.Context, chainName string, limit int) ([]*model.UserPoints, error) {
-	return s.pointsRepo.GetUserPointsList(ctx, chainName, 0, limit)
+	return s.ListUserPoints(ctx, chainName, 0, limit)
+}
+
+// ListUserPoints 按积分降序分页查询用户积分（不包含排除地址），用于排行榜和导出
+func (s *PointsService) ListUserPoints(ctx context.Context, chainName string, offset, limit int) ([]*model.UserPoints, error) {
+	excluded, err := s.exclusionService.ExcludedList(ctx, chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get excluded addresses: %w", err)
+	}
+	return s.pointsRepo.GetUserPointsList(ctx, chainName, excluded, offset, limit)
 }
 
 // BackfillPoints 回溯计算积分
+// ctx 被取消时在当前周期写完后停止，未计算的周期可以再次回溯；onProgress 可以为 nil
+// 单个周期失败时继续计算其余周期，最后返回列出失败周期的错误；
+// 周期尚未索引时报告进度后停止，返回 ErrPeriodNotIndexed
 func (s *PointsService) BackfillPoints(
 	ctx context.Context,
 	chainName string,
 	startTime time.Time,
 	endTime time.Time,
+	onProgress ProgressFunc,
 ) error {
+	startTime, endTime = startTime.UTC(), endTime.UTC()
 	s.logger.Infof("Starting points backfill for %s from %s to %s",
 		chainName, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
 
 	// 获取未计算的时间段
-	uncalculatedPeriods, err := s.pointsRepo.GetUncalculatedPeriods(ctx, chainName, startTime, endTime)
+	interval := s.CalcInterval(chainName)
+	uncalculatedPeriods, err := s.pointsRepo.GetUncalculatedPeriods(ctx, chainName, startTime, endTime, interval)
 	if err != nil {
 		return fmt.Errorf("failed to get uncalculated periods: %w", err)
 	}
@@ -371,9 +800,18 @@ func (s *PointsService) BackfillPoints(
 
 	s.logger.Infof("Found %d uncalculated periods", len(uncalculatedPeriods))
 
-	// 逐个计算每个小时的积分
-	for _, periodStart := range uncalculatedPeriods {
-		periodEnd := periodStart.Add(time.Hour)
+	// 进度游标只推进到第一个失败的周期，续跑时从该周期重新开始
+	var failed []string
+	cursor := startTime
+
+	// 逐个计算每个周期的积分
+	for i, periodStart := range uncalculatedPeriods {
+		if err := ctx.Err(); err != nil {
+			s.logger.Warnf("Points backfill interrupted after %d of %d periods", i, len(uncalculatedPeriods))
+			return err
+		}
+
+		periodEnd := periodStart.Add(interval)
 
 		// 确保不超过结束时间
 		if periodEnd.After(endTime) {
@@ -382,15 +820,40 @@ func (s *PointsService) BackfillPoints(
 
 		s.logger.Infof("Backfilling period: %s to %s", periodStart.Format(time.RFC3339), periodEnd.Format(time.RFC3339))
 
-		// 计算所有用户的积分
-		if err := s.CalculatePointsForAllUsers(ctx, chainName, periodStart, periodEnd, model.CalcTypeBackfill); err != nil {
+		// 计算所有用户的积分（一个周期内不中断，避免只写入部分用户）
+		err := s.CalculatePointsForAllUsers(context.WithoutCancel(ctx), chainName, periodStart, periodEnd, model.CalcTypeBackfill)
+		if errors.Is(err, ErrPeriodNotIndexed) {
+			// 之后的周期同样未索引，等待监听器追上后再回溯
+			s.logger.Warnf("Points backfill stopped at period %s: %v", periodStart.Format(time.RFC3339), err)
+			if len(failed) == 0 {
+				cursor = periodStart
+			}
+			if onProgress != nil {
+				onProgress(i, len(uncalculatedPeriods), cursor)
+			}
+			return fmt.Errorf("failed to backfill period %s: %w", periodStart.Format(time.RFC3339), err)
+		}
+		if err != nil {
 			s.logger.Errorf("Failed to calculate points for period %s: %v", periodStart.Format(time.RFC3339), err)
+			if len(failed) == 0 {
+				cursor = periodStart
+			}
+			failed = append(failed, periodStart.Format(time.RFC3339))
 			// 继续处理下一个时间段
-			continue
+		} else if len(failed) == 0 {
+			cursor = periodEnd
+		}
+
+		if onProgress != nil {
+			onProgress(i+1, len(uncalculatedPeriods), cursor)
 		}
 	}
 
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to backfill %d of %d periods: %s",
+			len(failed), len(uncalculatedPeriods), strings.Join(failed, ", "))
+	}
+
 	s.logger.Info("Points backfill completed")
 	return nil
 }
-
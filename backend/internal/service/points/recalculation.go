@@ -0,0 +1,288 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"my-token-points/internal/model"
+)
+
+var (
+	// ErrInvalidRecalculation 重算参数错误
+	ErrInvalidRecalculation = errors.New("invalid recalculation")
+	// ErrRecalculationNotFound 重算记录不存在
+	ErrRecalculationNotFound = errors.New("recalculation not found")
+	// ErrRecalculationUnavailable 未配置重算仓储
+	ErrRecalculationUnavailable = errors.New("recalculation is not available")
+)
+
+// recalculationEpsilon 小于该值的积分变化视为没有变化（浮点误差）
+const recalculationEpsilon = 1e-9
+
+// ProgressFunc 回溯和重算的进度回调，cursor 为最后完成的周期结束时间
+type ProgressFunc func(done, total int, cursor time.Time)
+
+// NewRecalculation 校验重算参数（不写入），时间段按链的计算周期对齐，结束时间不能晚于当前周期的开始
+func (s *PointsService) NewRecalculation(chainName string, start, end time.Time, reason, operator string) (*model.PointsRecalculation, error) {
+	interval := s.CalcInterval(chainName)
+	start = start.UTC().Truncate(interval)
+	end = end.UTC().Truncate(interval)
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end must be at least one period (%s) after start", ErrInvalidRecalculation, interval)
+	}
+	if end.After(time.Now().UTC().Truncate(interval)) {
+		return nil, fmt.Errorf("%w: end must not be later than the start of the current period", ErrInvalidRecalculation)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidRecalculation)
+	}
+	if operator == "" {
+		return nil, fmt.Errorf("%w: operator is required", ErrInvalidRecalculation)
+	}
+
+	return &model.PointsRecalculation{
+		ChainName:   chainName,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Reason:      reason,
+		Operator:    operator,
+	}, nil
+}
+
+// StartRecalculation 校验参数并创建重算记录，随后调用 RunRecalculation 执行
+func (s *PointsService) StartRecalculation(
+	ctx context.Context,
+	chainName string,
+	start time.Time,
+	end time.Time,
+	reason string,
+	operator string,
+) (*model.PointsRecalculation, error) {
+	recalculation, err := s.NewRecalculation(chainName, start, end, reason, operator)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CreateRecalculation(ctx, recalculation); err != nil {
+		return nil, err
+	}
+	return recalculation, nil
+}
+
+// CreateRecalculation 创建重算记录
+func (s *PointsService) CreateRecalculation(ctx context.Context, recalculation *model.PointsRecalculation) error {
+	if s.recalculationRepo == nil {
+		return ErrRecalculationUnavailable
+	}
+	if err := s.recalculationRepo.CreateRecalculation(ctx, recalculation); err != nil {
+		return fmt.Errorf("failed to create recalculation: %w", err)
+	}
+
+	s.logger.Infof("Points recalculation %d created for %s (%s to %s) by %s: %s",
+		recalculation.ID, recalculation.ChainName,
+		recalculation.PeriodStart.Format(time.RFC3339), recalculation.PeriodEnd.Format(time.RFC3339),
+		recalculation.Operator, recalculation.Reason)
+	return nil
+}
+
+// RunRecalculation 执行重算：逐周期用余额变动重新计算积分并取代原有积分历史，
+// 然后按积分历史重建受影响用户的总积分，记录每个用户重算前后的差异
+// 重算使用当前的积分配置、排除地址和风险降权，每个周期单独提交，从 PeriodsDone 之后的周期继续
+// ctx 被取消时不结束重算记录，可以再次调用续跑或调用 CancelRecalculation 取消
+func (s *PointsService) RunRecalculation(ctx context.Context, recalculation *model.PointsRecalculation, onProgress ProgressFunc) error {
+	err := s.runRecalculation(ctx, recalculation, onProgress)
+	if err != nil && ctx.Err() != nil {
+		s.logger.Warnf("Points recalculation %d interrupted after %d periods: %v",
+			recalculation.ID, recalculation.PeriodsDone, err)
+		return err
+	}
+
+	if err != nil {
+		message := err.Error()
+		recalculation.Status = model.RecalculationStatusFailed
+		recalculation.ErrorMessage = &message
+		s.logger.Errorf("Points recalculation %d failed: %v", recalculation.ID, err)
+	} else {
+		recalculation.Status = model.RecalculationStatusCompleted
+	}
+
+	if finishErr := s.recalculationRepo.FinishRecalculation(ctx, recalculation); finishErr != nil {
+		s.logger.Errorf("Failed to finish recalculation %d: %v", recalculation.ID, finishErr)
+		if err == nil {
+			err = fmt.Errorf("failed to finish recalculation: %w", finishErr)
+		}
+	}
+	return err
+}
+
+// CancelRecalculation 把未完成的重算标记为已取消，已重算的周期和已重建的用户保持不变
+func (s *PointsService) CancelRecalculation(ctx context.Context, recalculation *model.PointsRecalculation) error {
+	if s.recalculationRepo == nil {
+		return ErrRecalculationUnavailable
+	}
+
+	recalculation.Status = model.RecalculationStatusCancelled
+	if err := s.recalculationRepo.FinishRecalculation(ctx, recalculation); err != nil {
+		return fmt.Errorf("failed to cancel recalculation: %w", err)
+	}
+
+	s.logger.Infof("Points recalculation %d cancelled after %d periods", recalculation.ID, recalculation.PeriodsDone)
+	return nil
+}
+
+// runRecalculation 执行重算的各个步骤
+func (s *PointsService) runRecalculation(ctx context.Context, recalculation *model.PointsRecalculation, onProgress ProgressFunc) error {
+	chainName := recalculation.ChainName
+	interval := s.CalcInterval(chainName)
+	totalPeriods := int(recalculation.PeriodEnd.Sub(recalculation.PeriodStart) / interval)
+
+	// 第一个周期重算之前记录原有积分，续跑时已记录
+	if recalculation.PeriodsDone == 0 {
+		if err := s.recalculationRepo.SaveBaseline(ctx, recalculation.ID, chainName, recalculation.PeriodStart, recalculation.PeriodEnd); err != nil {
+			return fmt.Errorf("failed to save points before recalculation: %w", err)
+		}
+	}
+
+	basedOnBlock, err := s.IndexedBlock(ctx, chainName, recalculation.PeriodEnd)
+	if err != nil {
+		return err
+	}
+
+	// 持有状态从头推导并只保存在内存中，不影响定时计算使用的状态
+	recalc := s.detached(s.config)
+	targets, err := recalc.calculationTargets(ctx, chainName)
+	if err != nil {
+		return err
+	}
+	targets.basedOnBlock = basedOnBlock
+
+	resumeFrom := recalculation.PeriodStart.Add(time.Duration(recalculation.PeriodsDone) * interval)
+	s.logger.Infof("Recalculating points for %d users on %s (%s to %s, %d/%d periods done)",
+		len(targets.users), chainName,
+		resumeFrom.Format(time.RFC3339), recalculation.PeriodEnd.Format(time.RFC3339),
+		recalculation.PeriodsDone, totalPeriods)
+
+	for periodStart := resumeFrom; periodStart.Before(recalculation.PeriodEnd); periodStart = periodStart.Add(interval) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		periodEnd := periodStart.Add(interval)
+		histories, err := recalc.recalculatePeriod(ctx, chainName, periodStart, periodEnd, targets)
+		if err != nil {
+			return fmt.Errorf("failed to recalculate period %s: %w", periodStart.Format(time.RFC3339), err)
+		}
+		if err := s.recalculationRepo.ReplacePeriodHistory(ctx, recalculation.ID, chainName, periodStart, periodEnd, histories); err != nil {
+			return fmt.Errorf("failed to replace history for period %s: %w", periodStart.Format(time.RFC3339), err)
+		}
+		recalculation.PeriodsDone++
+		if onProgress != nil {
+			onProgress(recalculation.PeriodsDone, totalPeriods, periodEnd)
+		}
+	}
+
+	// 重建所有在重算前后有积分的用户，已重建的用户重复重建结果不变
+	users, err := s.recalculationRepo.ListRecalculationUsers(ctx, recalculation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list recalculated users: %w", err)
+	}
+
+	changed := 0
+	for _, address := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		diff, err := s.recalculationRepo.RebuildUserPoints(ctx, recalculation.ID, chainName, address)
+		if err != nil {
+			return fmt.Errorf("failed to rebuild points for %s: %w", address, err)
+		}
+		if math.Abs(diff.TotalAfter-diff.TotalBefore) > recalculationEpsilon {
+			changed++
+		}
+	}
+
+	s.logger.Infof("Points recalculation %d completed: %d periods, %d users, %d changed",
+		recalculation.ID, recalculation.PeriodsDone, len(users), changed)
+	return nil
+}
+
+// recalculatePeriod 重新计算一个周期内所有用户的积分和推荐奖励（不写入）
+func (s *PointsService) recalculatePeriod(
+	ctx context.Context,
+	chainName string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	targets *calculationTargets,
+) ([]*model.PointsHistory, error) {
+	var histories []*model.PointsHistory
+	earned := make(map[string]float64)
+
+	for _, userAddress := range targets.users {
+		riskMultiplier := targets.riskMultiplier(userAddress)
+		result, err := s.computePointsForPeriod(ctx, chainName, userAddress, periodStart, periodEnd, riskMultiplier)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate points for %s: %w", userAddress, err)
+		}
+		if result == nil {
+			continue
+		}
+
+		histories = append(histories, &model.PointsHistory{
+			ChainName:       chainName,
+			UserAddress:     userAddress,
+			CalcPeriodStart: periodStart,
+			CalcPeriodEnd:   periodEnd,
+			BalanceSnapshot: result.snapshots,
+			PointsEarned:    result.points,
+			CalculationType: model.CalcTypeRecalculation,
+			ExpiresAt:       s.lotExpiry(periodEnd),
+			RiskMultiplier:  riskMultiplier,
+			BasedOnBlock:    targets.basedOnBlock,
+		})
+		if result.points > 0 {
+			earned[userAddress] = result.points
+		}
+	}
+
+	bonuses, err := s.referralBonusHistories(ctx, chainName, periodStart, periodEnd, earned, targets)
+	if err != nil {
+		return nil, err
+	}
+	return append(histories, bonuses...), nil
+}
+
+// GetRecalculation 按 ID 查询重算记录
+func (s *PointsService) GetRecalculation(ctx context.Context, id int64) (*model.PointsRecalculation, error) {
+	if s.recalculationRepo == nil {
+		return nil, ErrRecalculationUnavailable
+	}
+
+	recalculation, err := s.recalculationRepo.GetRecalculation(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recalculation: %w", err)
+	}
+	if recalculation == nil {
+		return nil, ErrRecalculationNotFound
+	}
+	return recalculation, nil
+}
+
+// ListRecalculations 分页查询重算记录
+func (s *PointsService) ListRecalculations(ctx context.Context, chainName string, offset, limit int) ([]*model.PointsRecalculation, error) {
+	if s.recalculationRepo == nil {
+		return nil, ErrRecalculationUnavailable
+	}
+	return s.recalculationRepo.ListRecalculations(ctx, chainName, offset, limit)
+}
+
+// ListRecalculationDiffs 分页查询重算差异（按总积分变化绝对值降序）
+func (s *PointsService) ListRecalculationDiffs(ctx context.Context, id int64, offset, limit int) ([]*model.RecalculationDiff, error) {
+	if _, err := s.GetRecalculation(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.recalculationRepo.ListRecalculationDiffs(ctx, id, offset, limit)
+}
@@ -0,0 +1,125 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"my-token-points/internal/model"
+)
+
+// MaxRollupBuckets 单次汇总最多返回的周期数
+const MaxRollupBuckets = 366
+
+// defaultRollupBuckets 未指定开始时间时汇总的周期数（包含当前周期）
+const defaultRollupBuckets = 30
+
+// ErrInvalidRollup 汇总参数错误
+var ErrInvalidRollup = errors.New("invalid rollup")
+
+// RollupReport 用户积分按日/周/月的汇总
+type RollupReport struct {
+	ChainName   string                `json:"chain_name"`
+	UserAddress string                `json:"user_address"`
+	Granularity string                `json:"granularity"`
+	Timezone    string                `json:"timezone"`
+	TotalPoints float64               `json:"total_points"`
+	Periods     []*model.PointsRollup `json:"periods"`
+}
+
+// rollupPeriod 报表时区中的汇总周期划分
+type rollupPeriod struct {
+	granularity string
+	location    *time.Location
+}
+
+// start 返回 t 所在汇总周期的开始时间（周从周一开始，与 PostgreSQL date_trunc 一致）
+func (p rollupPeriod) start(t time.Time) time.Time {
+	t = t.In(p.location)
+	year, month, day := t.Date()
+	switch p.granularity {
+	case model.RollupWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, p.location)
+	case model.RollupMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, p.location)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, p.location)
+	}
+}
+
+// add 返回 n 个汇总周期之后的开始时间（按日历计算，夏令时切换当天不是 24 小时）
+func (p rollupPeriod) add(t time.Time, n int) time.Time {
+	switch p.granularity {
+	case model.RollupWeek:
+		return t.AddDate(0, 0, 7*n)
+	case model.RollupMonth:
+		return t.AddDate(0, n, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
+
+// GetUserPointsRollup 在报表时区按日/周/月汇总用户积分
+// 时间范围扩展到完整的汇总周期，没有积分的周期也会返回（积分为 0）
+// 未指定结束时间时汇总到当前周期，未指定开始时间时汇总最近 defaultRollupBuckets 个周期
+func (s *PointsService) GetUserPointsRollup(
+	ctx context.Context,
+	chainName, userAddress, granularity string,
+	startTime, endTime time.Time,
+) (*RollupReport, error) {
+	switch granularity {
+	case model.RollupDay, model.RollupWeek, model.RollupMonth:
+	default:
+		return nil, fmt.Errorf("%w: granularity must be day, week or month", ErrInvalidRollup)
+	}
+
+	period := rollupPeriod{granularity: granularity, location: s.config.ReportingLocation}
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	end := period.start(endTime)
+	if !end.Equal(endTime) {
+		end = period.add(end, 1)
+	}
+	start := period.add(end, -defaultRollupBuckets)
+	if !startTime.IsZero() {
+		start = period.start(startTime)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end_time must be after start_time", ErrInvalidRollup)
+	}
+	if period.add(start, MaxRollupBuckets).Before(end) {
+		return nil, fmt.Errorf("%w: at most %d periods per request", ErrInvalidRollup, MaxRollupBuckets)
+	}
+
+	userAddress = strings.ToLower(userAddress)
+	rows, err := s.pointsRepo.GetPointsRollup(ctx, chainName, userAddress, granularity, period.location.String(), start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get points rollup: %w", err)
+	}
+	earned := make(map[int64]*model.PointsRollup, len(rows))
+	for _, row := range rows {
+		earned[row.PeriodStart.Unix()] = row
+	}
+
+	report := &RollupReport{
+		ChainName:   chainName,
+		UserAddress: userAddress,
+		Granularity: granularity,
+		Timezone:    period.location.String(),
+		Periods:     make([]*model.PointsRollup, 0),
+	}
+	for current := start; current.Before(end); current = period.add(current, 1) {
+		rollup := &model.PointsRollup{PeriodStart: current, PeriodEnd: period.add(current, 1)}
+		if row, ok := earned[current.Unix()]; ok {
+			rollup.PointsEarned = row.PointsEarned
+			rollup.Records = row.Records
+		}
+		report.TotalPoints += rollup.PointsEarned
+		report.Periods = append(report.Periods, rollup)
+	}
+	return report, nil
+}
@@ -0,0 +1,350 @@
+package points
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// MaxSimulationWindow 单次模拟最长的时间范围
+const MaxSimulationWindow = 31 * 24 * time.Hour
+
+// defaultSimulationTopN 默认对比的排行榜人数
+const defaultSimulationTopN = 20
+
+// ErrInvalidSimulation 模拟参数错误
+var ErrInvalidSimulation = errors.New("invalid simulation")
+
+// SimulationOverrides 模拟使用的替代配置，为空的字段沿用当前配置
+type SimulationOverrides struct {
+	HourlyRate       *float64
+	StakedMultiplier *float64
+	ReferralRate     *float64
+	// 替代的持有时长倍数
+	HoldingStreak *StreakConfig
+	// 关闭持有时长倍数（优先于 HoldingStreak）
+	DisableHoldingStreak bool
+}
+
+// UserPointsDelta 单个用户的模拟积分与实际积分对比
+type UserPointsDelta struct {
+	UserAddress     string   `json:"user_address"`
+	LivePoints      float64  `json:"live_points"`
+	SimulatedPoints float64  `json:"simulated_points"`
+	Delta           float64  `json:"delta"`
+	DeltaPercent    *float64 `json:"delta_percent,omitempty"` // 实际积分为 0 时为空
+	LiveRank        int      `json:"live_rank,omitempty"`     // 0 表示没有积分
+	SimulatedRank   int      `json:"simulated_rank,omitempty"`
+}
+
+// PointsDistribution 积分分布统计（只统计积分大于 0 的用户）
+type PointsDistribution struct {
+	Users  int     `json:"users"`
+	Total  float64 `json:"total"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+	P99    float64 `json:"p99"`
+	Max    float64 `json:"max"`
+	Gini   float64 `json:"gini"` // 基尼系数，越接近 1 越集中
+}
+
+// SimulationReport 模拟结果
+type SimulationReport struct {
+	ChainName   string    `json:"chain_name"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Periods     int       `json:"periods"`
+
+	Live      PointsDistribution `json:"live"`
+	Simulated PointsDistribution `json:"simulated"`
+
+	// 前 N 名对比
+	TopN         int                `json:"top_n"`
+	TopLive      []*UserPointsDelta `json:"top_live"`
+	TopSimulated []*UserPointsDelta `json:"top_simulated"`
+	TopOverlap   int                `json:"top_overlap"` // 同时在两个前 N 名中的用户数
+
+	// 所有用户的变化（按变化绝对值降序）
+	Users []*UserPointsDelta `json:"users"`
+}
+
+// Simulate 用替代配置模拟计算 [start, end) 内的积分，并与实际结果对比
+// 模拟使用与定时计算相同的规则（排除地址、女巫降权、推荐奖励），不写入任何积分数据
+func (s *PointsService) Simulate(
+	ctx context.Context,
+	chainName string,
+	start time.Time,
+	end time.Time,
+	overrides *SimulationOverrides,
+	topN int,
+) (*SimulationReport, error) {
+	interval := s.CalcInterval(chainName)
+	start = start.UTC().Truncate(interval)
+	end = end.UTC().Truncate(interval)
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end must be at least one period (%s) after start", ErrInvalidSimulation, interval)
+	}
+	if end.Sub(start) > MaxSimulationWindow {
+		return nil, fmt.Errorf("%w: window must not exceed %s", ErrInvalidSimulation, MaxSimulationWindow)
+	}
+	if topN <= 0 {
+		topN = defaultSimulationTopN
+	}
+
+	config, err := s.simulationConfig(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用替代配置的服务副本，持有状态只保存在内存中
+	sim := s.detached(config)
+
+	targets, err := sim.calculationTargets(ctx, chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infof("Simulating points for %d users on %s (%s to %s)",
+		len(targets.users), chainName, start.Format(time.RFC3339), end.Format(time.RFC3339))
+
+	simulated := make(map[string]float64)
+	periods := 0
+	for periodStart := start; periodStart.Before(end); periodStart = periodStart.Add(interval) {
+		periodEnd := periodStart.Add(interval)
+		for _, userAddress := range targets.users {
+			result, err := sim.computePointsForPeriod(ctx, chainName, userAddress, periodStart, periodEnd, targets.riskMultiplier(userAddress))
+			if err != nil {
+				return nil, fmt.Errorf("failed to simulate points for %s: %w", userAddress, err)
+			}
+			if result != nil {
+				simulated[userAddress] += result.points
+			}
+		}
+		periods++
+	}
+
+	if err := sim.simulateReferralBonuses(ctx, chainName, simulated, targets); err != nil {
+		return nil, err
+	}
+
+	live, err := s.pointsRepo.SumCalculatedPoints(ctx, chainName, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum live points: %w", err)
+	}
+
+	report := buildSimulationReport(live, simulated, topN)
+	report.ChainName = chainName
+	report.PeriodStart = start
+	report.PeriodEnd = end
+	report.Periods = periods
+	return report, nil
+}
+
+// simulationConfig 在当前配置上应用替代配置
+func (s *PointsService) simulationConfig(overrides *SimulationOverrides) (*PointsConfig, error) {
+	config := *s.config
+	if overrides == nil {
+		return &config, nil
+	}
+
+	if overrides.HourlyRate != nil {
+		if *overrides.HourlyRate < 0 {
+			return nil, fmt.Errorf("%w: hourly_rate must not be negative", ErrInvalidSimulation)
+		}
+		config.HourlyRate = *overrides.HourlyRate
+	}
+	if overrides.StakedMultiplier != nil {
+		if *overrides.StakedMultiplier < 0 {
+			return nil, fmt.Errorf("%w: staked_multiplier must not be negative", ErrInvalidSimulation)
+		}
+		config.StakedMultiplier = *overrides.StakedMultiplier
+	}
+	if overrides.ReferralRate != nil {
+		if *overrides.ReferralRate < 0 || *overrides.ReferralRate > 1 {
+			return nil, fmt.Errorf("%w: referral_rate must be between 0 and 1", ErrInvalidSimulation)
+		}
+		config.ReferralRate = *overrides.ReferralRate
+	}
+	if overrides.HoldingStreak != nil {
+		if err := overrides.HoldingStreak.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSimulation, err)
+		}
+		config.HoldingStreak = overrides.HoldingStreak
+	}
+	if overrides.DisableHoldingStreak {
+		config.HoldingStreak = nil
+	}
+	return &config, nil
+}
+
+// simulateReferralBonuses 把推荐奖励计入模拟积分
+// 奖励与被推荐人的积分成正比，按整个时间段汇总计算与逐期计算结果相同
+func (s *PointsService) simulateReferralBonuses(
+	ctx context.Context,
+	chainName string,
+	simulated map[string]float64,
+	targets *calculationTargets,
+) error {
+	if s.config.ReferralRate <= 0 || s.referralRepo == nil || len(simulated) == 0 {
+		return nil
+	}
+
+	referees := make([]string, 0, len(simulated))
+	for address, points := range simulated {
+		if points > 0 {
+			referees = append(referees, address)
+		}
+	}
+	sort.Strings(referees)
+
+	referrers, err := s.referralRepo.GetReferrers(ctx, referees)
+	if err != nil {
+		return fmt.Errorf("failed to get referrers: %w", err)
+	}
+
+	bonuses := make(map[string]float64)
+	for _, referee := range referees {
+		referrer, ok := referrers[referee]
+		if !ok || !s.earnsReferralBonus(chainName, referrer, targets) {
+			continue
+		}
+		bonuses[referrer] += simulated[referee] * s.config.ReferralRate * targets.riskMultiplier(referrer)
+	}
+	for referrer, bonus := range bonuses {
+		simulated[referrer] += bonus
+	}
+	return nil
+}
+
+// buildSimulationReport 对比实际积分和模拟积分
+func buildSimulationReport(live, simulated map[string]float64, topN int) *SimulationReport {
+	liveRanks := rankUsers(live)
+	simulatedRanks := rankUsers(simulated)
+
+	deltas := make(map[string]*UserPointsDelta)
+	for _, totals := range []map[string]float64{live, simulated} {
+		for address := range totals {
+			if _, ok := deltas[address]; ok {
+				continue
+			}
+			delta := &UserPointsDelta{
+				UserAddress:     address,
+				LivePoints:      live[address],
+				SimulatedPoints: simulated[address],
+				Delta:           simulated[address] - live[address],
+				LiveRank:        liveRanks[address],
+				SimulatedRank:   simulatedRanks[address],
+			}
+			if delta.LivePoints != 0 {
+				percent := delta.Delta / delta.LivePoints * 100
+				delta.DeltaPercent = &percent
+			}
+			deltas[address] = delta
+		}
+	}
+
+	report := &SimulationReport{
+		Live:      distribution(live),
+		Simulated: distribution(simulated),
+		TopN:      topN,
+		Users:     make([]*UserPointsDelta, 0, len(deltas)),
+	}
+	for _, delta := range deltas {
+		report.Users = append(report.Users, delta)
+	}
+	sort.Slice(report.Users, func(i, j int) bool {
+		a, b := math.Abs(report.Users[i].Delta), math.Abs(report.Users[j].Delta)
+		if a != b {
+			return a > b
+		}
+		return report.Users[i].UserAddress < report.Users[j].UserAddress
+	})
+
+	report.TopLive = topUsers(deltas, liveRanks, topN)
+	report.TopSimulated = topUsers(deltas, simulatedRanks, topN)
+	for _, delta := range report.TopLive {
+		if delta.SimulatedRank > 0 && delta.SimulatedRank <= topN {
+			report.TopOverlap++
+		}
+	}
+	return report
+}
+
+// rankUsers 按积分降序排名（从 1 开始，积分相同按地址排序，积分不大于 0 的不排名）
+func rankUsers(totals map[string]float64) map[string]int {
+	addresses := make([]string, 0, len(totals))
+	for address, points := range totals {
+		if points > 0 {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if totals[addresses[i]] != totals[addresses[j]] {
+			return totals[addresses[i]] > totals[addresses[j]]
+		}
+		return addresses[i] < addresses[j]
+	})
+
+	ranks := make(map[string]int, len(addresses))
+	for i, address := range addresses {
+		ranks[address] = i + 1
+	}
+	return ranks
+}
+
+// topUsers 返回排名前 n 的用户
+func topUsers(deltas map[string]*UserPointsDelta, ranks map[string]int, n int) []*UserPointsDelta {
+	top := make([]*UserPointsDelta, 0, n)
+	for address, rank := range ranks {
+		if rank <= n {
+			top = append(top, deltas[address])
+		}
+	}
+	sort.Slice(top, func(i, j int) bool { return ranks[top[i].UserAddress] < ranks[top[j].UserAddress] })
+	return top
+}
+
+// distribution 计算积分分布
+func distribution(totals map[string]float64) PointsDistribution {
+	values := make([]float64, 0, len(totals))
+	for _, points := range totals {
+		if points > 0 {
+			values = append(values, points)
+		}
+	}
+	if len(values) == 0 {
+		return PointsDistribution{}
+	}
+	sort.Float64s(values)
+
+	var total, weighted float64
+	for i, value := range values {
+		total += value
+		weighted += float64(i+1) * value
+	}
+	n := float64(len(values))
+
+	return PointsDistribution{
+		Users:  len(values),
+		Total:  total,
+		Mean:   total / n,
+		Median: percentile(values, 0.5),
+		P90:    percentile(values, 0.9),
+		P99:    percentile(values, 0.99),
+		Max:    values[len(values)-1],
+		Gini:   (2*weighted)/(n*total) - (n+1)/n,
+	}
+}
+
+// percentile 返回已排序数据的分位数（最近秩法）
+func percentile(sorted []float64, p float64) float64 {
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
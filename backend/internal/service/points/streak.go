@@ -0,0 +1,295 @@
+package points
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"my-token-points/internal/model"
+)
+
+// 连续持有起点的处理方式
+const (
+	// StreakModeReset 转出/销毁后从当前时间重新计算持有时长
+	StreakModeReset = "reset"
+	// StreakModeProrate 转出/销毁后按剩余持有比例折算持有时长
+	StreakModeProrate = "prorate"
+)
+
+// StreakTier 持有时长倍数档位
+type StreakTier struct {
+	// 达到该档位所需的连续持有时长
+	MinHolding time.Duration
+	// 积分倍数
+	Multiplier float64
+}
+
+// StreakConfig 持有时长倍数配置
+type StreakConfig struct {
+	// 转出/销毁时的处理方式：reset 或 prorate
+	Mode string
+	// 倍数档位（按 MinHolding 升序），未达到第一档时倍数为 1
+	Tiers []StreakTier
+}
+
+// Validate 检查处理方式和档位（档位需按持有时长严格递增）
+func (c *StreakConfig) Validate() error {
+	if c.Mode != StreakModeReset && c.Mode != StreakModeProrate {
+		return fmt.Errorf("invalid holding streak mode %q (expected reset or prorate)", c.Mode)
+	}
+	if len(c.Tiers) == 0 {
+		return fmt.Errorf("holding streak requires at least one tier")
+	}
+	for i, tier := range c.Tiers {
+		if tier.MinHolding <= 0 || tier.Multiplier <= 0 {
+			return fmt.Errorf("holding streak tier %d: min holding and multiplier must be positive", i)
+		}
+		if i > 0 && tier.MinHolding <= c.Tiers[i-1].MinHolding {
+			return fmt.Errorf("holding streak tiers must be sorted by min holding ascending")
+		}
+	}
+	return nil
+}
+
+// multiplierAt 返回持有起点为 since 时 at 时刻的倍数，以及下一档开始生效的时间
+func (c *StreakConfig) multiplierAt(since *time.Time, at time.Time) (float64, *time.Time) {
+	if since == nil {
+		return 1, nil
+	}
+
+	held := at.Sub(*since)
+	multiplier := 1.0
+	for _, tier := range c.Tiers {
+		if held < tier.MinHolding {
+			next := since.Add(tier.MinHolding)
+			return multiplier, &next
+		}
+		multiplier = tier.Multiplier
+	}
+	return multiplier, nil
+}
+
+// streakPoint 持有起点在 At 时刻变为 Since
+type streakPoint struct {
+	At    time.Time
+	Since *time.Time
+}
+
+// holdingTimeline 计算周期内的持有起点变化，用于按段计算倍数
+type holdingTimeline struct {
+	config *StreakConfig
+	points []streakPoint
+}
+
+// weightedHours 返回 [start, end) 按持有时长倍数加权后的小时数
+// timeline 为空（未启用）时返回实际小时数
+func (t *holdingTimeline) weightedHours(start, end time.Time) float64 {
+	if t == nil {
+		return end.Sub(start).Hours()
+	}
+
+	var total float64
+	cursor := start
+	for cursor.Before(end) {
+		since, next := t.sinceAt(cursor)
+		segmentEnd := end
+		if next != nil && next.Before(segmentEnd) {
+			segmentEnd = *next
+		}
+
+		// 段内跨过档位时在档位处切分
+		multiplier, tierStart := t.config.multiplierAt(since, cursor)
+		if tierStart != nil && tierStart.Before(segmentEnd) {
+			segmentEnd = *tierStart
+		}
+
+		total += segmentEnd.Sub(cursor).Hours() * multiplier
+		cursor = segmentEnd
+	}
+	return total
+}
+
+// sinceAt 返回 at 时刻生效的持有起点，以及下一次变化的时间
+func (t *holdingTimeline) sinceAt(at time.Time) (*time.Time, *time.Time) {
+	i := sort.Search(len(t.points), func(i int) bool {
+		return t.points[i].At.After(at)
+	})
+
+	var since *time.Time
+	if i > 0 {
+		since = t.points[i-1].Since
+	}
+	if i < len(t.points) {
+		return since, &t.points[i].At
+	}
+	return since, nil
+}
+
+// buildHoldingTimeline 推导周期内的持有起点变化，并把持有状态推进到周期结束
+// 状态晚于周期开始（回溯或重算）时从头重建
+func (s *PointsService) buildHoldingTimeline(
+	ctx context.Context,
+	chainName string,
+	userAddress string,
+	periodStart time.Time,
+	periodEnd time.Time,
+	periodChanges []*model.BalanceChange,
+) (*holdingTimeline, error) {
+	if s.config.HoldingStreak == nil {
+		return nil, nil
+	}
+
+	streak, err := s.holdingStreakAt(ctx, chainName, userAddress, periodStart)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &holdingTimeline{
+		config: s.config.HoldingStreak,
+		points: []streakPoint{{At: periodStart, Since: streak.HoldingSince}},
+	}
+	for _, txChanges := range groupChangesByTx(periodChanges) {
+		if err := s.advanceHoldingStreak(streak, txChanges); err != nil {
+			return nil, err
+		}
+		timeline.points = append(timeline.points, streakPoint{At: txChanges[0].BlockTime, Since: streak.HoldingSince})
+	}
+
+	streak.AsOf = periodEnd
+	if err := s.saveHoldingStreak(ctx, streak); err != nil {
+		return nil, fmt.Errorf("failed to save holding streak: %w", err)
+	}
+
+	return timeline, nil
+}
+
+// holdingStreakAt 返回 at 时刻的持有状态（不保存）
+func (s *PointsService) holdingStreakAt(ctx context.Context, chainName, userAddress string, at time.Time) (*model.HoldingStreak, error) {
+	streak, err := s.loadHoldingStreak(ctx, chainName, userAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holding streak: %w", err)
+	}
+	if streak == nil || streak.AsOf.After(at) {
+		streak = &model.HoldingStreak{
+			ChainName:   chainName,
+			UserAddress: userAddress,
+			HeldBalance: "0",
+			AsOf:        time.Unix(0, 0).UTC(),
+		}
+	}
+
+	if streak.AsOf.Before(at) {
+		changes, err := s.balanceRepo.GetBalanceChanges(ctx, chainName, userAddress, streak.AsOf, at)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get balance changes: %w", err)
+		}
+		for _, txChanges := range groupChangesByTx(changes) {
+			if err := s.advanceHoldingStreak(streak, txChanges); err != nil {
+				return nil, err
+			}
+		}
+		streak.AsOf = at
+	}
+
+	return streak, nil
+}
+
+// loadHoldingStreak 读取持有状态，模拟运行时优先使用内存中的状态
+func (s *PointsService) loadHoldingStreak(ctx context.Context, chainName, userAddress string) (*model.HoldingStreak, error) {
+	if streak, ok := s.memStreaks[chainName+":"+userAddress]; ok {
+		return streak, nil
+	}
+	return s.pointsRepo.GetHoldingStreak(ctx, chainName, userAddress)
+}
+
+// saveHoldingStreak 保存持有状态，模拟运行时只保存在内存中
+func (s *PointsService) saveHoldingStreak(ctx context.Context, streak *model.HoldingStreak) error {
+	if s.memStreaks != nil {
+		s.memStreaks[streak.ChainName+":"+streak.UserAddress] = streak
+		return nil
+	}
+	return s.pointsRepo.UpsertHoldingStreak(ctx, streak)
+}
+
+// groupChangesByTx 把按链上顺序排列的余额变动按交易分组
+func groupChangesByTx(changes []*model.BalanceChange) [][]*model.BalanceChange {
+	var groups [][]*model.BalanceChange
+	for i, change := range changes {
+		if i > 0 && change.TxHash == changes[i-1].TxHash {
+			groups[len(groups)-1] = append(groups[len(groups)-1], change)
+			continue
+		}
+		groups = append(groups, []*model.BalanceChange{change})
+	}
+	return groups
+}
+
+// advanceHoldingStreak 把一笔交易的余额变动计入持有状态
+// 持有余额为钱包余额与质押余额之和，并按交易合并变动：存入托管合约时钱包转出和质押入账相互抵消，
+// 质押/解押只是在钱包和托管合约之间移动，不影响持有时长
+func (s *PointsService) advanceHoldingStreak(streak *model.HoldingStreak, txChanges []*model.BalanceChange) error {
+	delta := new(big.Int)
+	for _, change := range txChanges {
+		amount, ok := new(big.Int).SetString(change.AmountDelta, 10)
+		if !ok {
+			return fmt.Errorf("invalid amount delta in change %d: %s", change.ID, change.AmountDelta)
+		}
+		delta.Add(delta, amount)
+	}
+	if delta.Sign() == 0 {
+		return nil
+	}
+
+	before, ok := new(big.Int).SetString(streak.HeldBalance, 10)
+	if !ok {
+		return fmt.Errorf("invalid held balance: %s", streak.HeldBalance)
+	}
+	after := new(big.Int).Add(before, delta)
+	at := txChanges[0].BlockTime
+
+	switch {
+	case after.Sign() <= 0:
+		// 全部转出，持有中断
+		after.SetInt64(0)
+		streak.HoldingSince = nil
+	case delta.Sign() > 0:
+		if streak.HoldingSince == nil {
+			streak.HoldingSince = &at
+		}
+	case delta.Sign() < 0 && streak.HoldingSince != nil:
+		if s.config.HoldingStreak.Mode == StreakModeProrate {
+			// 已持有时长按剩余比例折算
+			ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(after), new(big.Float).SetInt(before)).Float64()
+			held := time.Duration(float64(at.Sub(*streak.HoldingSince)) * ratio)
+			since := at.Add(-held)
+			streak.HoldingSince = &since
+		} else {
+			streak.HoldingSince = &at
+		}
+	}
+
+	streak.HeldBalance = after.String()
+	return nil
+}
+
+// fillHoldingStreak 填充用户当前的连续持有天数和倍数
+func (s *PointsService) fillHoldingStreak(ctx context.Context, points *model.UserPoints) error {
+	if s.config.HoldingStreak == nil {
+		return nil
+	}
+
+	now := time.Now()
+	streak, err := s.holdingStreakAt(ctx, points.ChainName, points.UserAddress, now)
+	if err != nil {
+		return err
+	}
+
+	points.HoldingSince = streak.HoldingSince
+	points.StreakMultiplier, _ = s.config.HoldingStreak.multiplierAt(streak.HoldingSince, now)
+	if streak.HoldingSince != nil {
+		points.StreakDays = now.Sub(*streak.HoldingSince).Hours() / 24
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package points
+
+import (
+	"testing"
+	"time"
+
+	"my-token-points/internal/model"
+)
+
+func TestAdvanceHoldingStreakIgnoresVaultDeposit(t *testing.T) {
+	for _, mode := range []string{StreakModeReset, StreakModeProrate} {
+		t.Run(mode, func(t *testing.T) {
+			s := &PointsService{config: &PointsConfig{HoldingStreak: &StreakConfig{Mode: mode}}}
+
+			since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			streak := &model.HoldingStreak{HeldBalance: "100", HoldingSince: &since}
+
+			// 存入托管合约：同一交易中钱包转出、质押入账
+			depositAt := since.Add(30 * 24 * time.Hour)
+			changes := []*model.BalanceChange{
+				{TxHash: "0x01", BlockTime: depositAt, EventType: model.EventTypeTransferOut, BalanceType: model.BalanceTypeWallet, AmountDelta: "-60"},
+				{TxHash: "0x01", BlockTime: depositAt, EventType: model.EventTypeStake, BalanceType: model.BalanceTypeStaked, AmountDelta: "60"},
+			}
+			for _, txChanges := range groupChangesByTx(changes) {
+				if err := s.advanceHoldingStreak(streak, txChanges); err != nil {
+					t.Fatalf("advanceHoldingStreak: %v", err)
+				}
+			}
+
+			if streak.HoldingSince == nil || !streak.HoldingSince.Equal(since) {
+				t.Errorf("holding since = %v, want %v", streak.HoldingSince, since)
+			}
+			if streak.HeldBalance != "100" {
+				t.Errorf("held balance = %s, want 100", streak.HeldBalance)
+			}
+		})
+	}
+}
+
+func TestAdvanceHoldingStreakResetsOnTransferOut(t *testing.T) {
+	s := &PointsService{config: &PointsConfig{HoldingStreak: &StreakConfig{Mode: StreakModeReset}}}
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	streak := &model.HoldingStreak{HeldBalance: "100", HoldingSince: &since}
+
+	at := since.Add(24 * time.Hour)
+	err := s.advanceHoldingStreak(streak, []*model.BalanceChange{
+		{TxHash: "0x02", BlockTime: at, EventType: model.EventTypeTransferOut, BalanceType: model.BalanceTypeWallet, AmountDelta: "-40"},
+	})
+	if err != nil {
+		t.Fatalf("advanceHoldingStreak: %v", err)
+	}
+
+	if streak.HoldingSince == nil || !streak.HoldingSince.Equal(at) {
+		t.Errorf("holding since = %v, want %v", streak.HoldingSince, at)
+	}
+	if streak.HeldBalance != "60" {
+		t.Errorf("held balance = %s, want 60", streak.HeldBalance)
+	}
+}
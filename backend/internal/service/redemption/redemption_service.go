@@ -0,0 +1,144 @@
+package redemption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/internal/model"
+	"my-token-points/internal/pkg/signature"
+	"my-token-points/internal/repository"
+)
+
+// maxIdempotencyKeyLength 幂等键最大长度（与表结构一致）
+const maxIdempotencyKeyLength = 100
+
+var (
+	// ErrInvalidAddress 地址格式错误
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrUnknownChain 链未配置
+	ErrUnknownChain = errors.New("unknown chain")
+	// ErrInvalidAmount 兑换积分必须大于 0
+	ErrInvalidAmount = errors.New("amount must be positive")
+	// ErrPerkRequired 缺少兑换项目
+	ErrPerkRequired = errors.New("perk is required")
+	// ErrInvalidIdempotencyKey 幂等键为空或过长
+	ErrInvalidIdempotencyKey = errors.New("idempotency key is required and must be at most 100 characters")
+	// ErrInvalidSignature 签名无效
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInsufficientPoints 可用积分不足
+	ErrInsufficientPoints = errors.New("insufficient available points")
+	// ErrIdempotencyConflict 幂等键已用于不同的兑换请求
+	ErrIdempotencyConflict = errors.New("idempotency key was already used for a different redemption")
+)
+
+// RedeemRequest 兑换请求
+type RedeemRequest struct {
+	ChainName      string
+	UserAddress    string
+	Amount         float64
+	Perk           string
+	IdempotencyKey string
+	Signature      string
+}
+
+// RedemptionService 积分兑换服务
+type RedemptionService struct {
+	redemptionRepo repository.RedemptionRepository
+	chains         map[string]bool
+	logger         *logrus.Logger
+}
+
+// NewRedemptionService 创建积分兑换服务
+func NewRedemptionService(
+	redemptionRepo repository.RedemptionRepository,
+	chainNames []string,
+	logger *logrus.Logger,
+) *RedemptionService {
+	chains := make(map[string]bool, len(chainNames))
+	for _, name := range chainNames {
+		chains[name] = true
+	}
+
+	return &RedemptionService{
+		redemptionRepo: redemptionRepo,
+		chains:         chains,
+		logger:         logger,
+	}
+}
+
+// RedemptionMessage 用户需要用 personal_sign 签名的兑换原文
+func RedemptionMessage(chainName, userAddress string, amount float64, perk, idempotencyKey string) string {
+	return fmt.Sprintf("Redeem %s points for %s on %s\nAddress: %s\nRequest: %s",
+		strconv.FormatFloat(amount, 'f', -1, 64), perk, chainName, strings.ToLower(userAddress), idempotencyKey)
+}
+
+// Redeem 验证签名后兑换积分
+// 同一幂等键的重复请求返回已有记录（replayed 为 true），不会重复扣减
+func (s *RedemptionService) Redeem(ctx context.Context, req *RedeemRequest) (redemption *model.PointsRedemption, replayed bool, err error) {
+	if !s.chains[req.ChainName] {
+		return nil, false, fmt.Errorf("%w: %s", ErrUnknownChain, req.ChainName)
+	}
+	if !common.IsHexAddress(req.UserAddress) {
+		return nil, false, fmt.Errorf("%w: %s", ErrInvalidAddress, req.UserAddress)
+	}
+	if req.Amount <= 0 {
+		return nil, false, ErrInvalidAmount
+	}
+	perk := strings.TrimSpace(req.Perk)
+	if perk == "" {
+		return nil, false, ErrPerkRequired
+	}
+	if req.IdempotencyKey == "" || len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+		return nil, false, ErrInvalidIdempotencyKey
+	}
+
+	userAddress := strings.ToLower(req.UserAddress)
+	message := RedemptionMessage(req.ChainName, userAddress, req.Amount, perk, req.IdempotencyKey)
+	if err := signature.VerifyPersonalSign(userAddress, message, req.Signature); err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	redemption = &model.PointsRedemption{
+		ChainName:      req.ChainName,
+		UserAddress:    userAddress,
+		Amount:         req.Amount,
+		Perk:           perk,
+		IdempotencyKey: req.IdempotencyKey,
+		Message:        message,
+		Signature:      req.Signature,
+	}
+
+	result, err := s.redemptionRepo.Redeem(ctx, redemption)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to redeem points: %w", err)
+	}
+
+	switch result {
+	case repository.RedeemInsufficient:
+		return nil, false, ErrInsufficientPoints
+	case repository.RedeemDuplicate:
+		if redemption.Message != message {
+			return nil, false, ErrIdempotencyConflict
+		}
+		return redemption, true, nil
+	}
+
+	s.logger.Infof("Redeemed %.4f points for %s on %s: %s (redemption #%d)",
+		redemption.Amount, userAddress, req.ChainName, perk, redemption.ID)
+	return redemption, false, nil
+}
+
+// ListRedemptions 分页查询用户兑换记录
+func (s *RedemptionService) ListRedemptions(ctx context.Context, chainName, userAddress string, offset, limit int) ([]*model.PointsRedemption, error) {
+	redemptions, err := s.redemptionRepo.ListRedemptions(ctx, chainName, strings.ToLower(userAddress), offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list redemptions: %w", err)
+	}
+	return redemptions, nil
+}
@@ -0,0 +1,217 @@
+package referral
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/internal/model"
+	"my-token-points/internal/pkg/signature"
+	"my-token-points/internal/repository"
+)
+
+const (
+	// codeLength 推荐码长度
+	codeLength = 8
+	// codeAlphabet 推荐码字符集（去掉容易混淆的 0/O/1/I）
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// maxCodeAttempts 生成推荐码的最大尝试次数
+	maxCodeAttempts = 5
+)
+
+var (
+	// ErrInvalidAddress 地址格式错误
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrCodeNotFound 推荐码不存在
+	ErrCodeNotFound = errors.New("referral code not found")
+	// ErrSelfReferral 不能绑定自己的推荐码
+	ErrSelfReferral = errors.New("cannot bind own referral code")
+	// ErrReferralCycle 推荐人是自己邀请的地址
+	ErrReferralCycle = errors.New("referrer was referred by this address")
+	// ErrAlreadyBound 地址已绑定推荐人
+	ErrAlreadyBound = errors.New("address already has a referrer")
+	// ErrInvalidSignature 签名无效
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// ReferralInfo 地址的推荐信息
+type ReferralInfo struct {
+	Address  string            `json:"address"`
+	Code     string            `json:"code,omitempty"`
+	Referrer string            `json:"referrer,omitempty"`
+	Referees []*model.Referral `json:"referees"`
+}
+
+// ReferralService 推荐服务
+type ReferralService struct {
+	referralRepo repository.ReferralRepository
+	logger       *logrus.Logger
+}
+
+// NewReferralService 创建推荐服务
+func NewReferralService(
+	referralRepo repository.ReferralRepository,
+	logger *logrus.Logger,
+) *ReferralService {
+	return &ReferralService{
+		referralRepo: referralRepo,
+		logger:       logger,
+	}
+}
+
+// BindingMessage 被推荐人需要用 personal_sign 签名的原文
+func BindingMessage(code, refereeAddress string) string {
+	return fmt.Sprintf("Bind referral code %s to address %s", strings.ToUpper(code), strings.ToLower(refereeAddress))
+}
+
+// GetOrCreateCode 查询地址的推荐码，不存在时生成一个
+func (s *ReferralService) GetOrCreateCode(ctx context.Context, ownerAddress string) (*model.ReferralCode, error) {
+	ownerAddress, err := normalizeAddress(ownerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < maxCodeAttempts; attempt++ {
+		existing, err := s.referralRepo.GetReferralCodeByOwner(ctx, ownerAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get referral code: %w", err)
+		}
+		if existing != nil {
+			return existing, nil
+		}
+
+		code, err := generateCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate referral code: %w", err)
+		}
+
+		referralCode := &model.ReferralCode{Code: code, OwnerAddress: ownerAddress}
+		created, err := s.referralRepo.CreateReferralCode(ctx, referralCode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create referral code: %w", err)
+		}
+		if created {
+			s.logger.Infof("Created referral code %s for %s", code, ownerAddress)
+			return referralCode, nil
+		}
+		// 推荐码冲突或并发创建，重新查询后再试
+	}
+
+	return nil, fmt.Errorf("failed to create referral code for %s after %d attempts", ownerAddress, maxCodeAttempts)
+}
+
+// BindReferral 验证被推荐人签名后绑定推荐关系
+func (s *ReferralService) BindReferral(ctx context.Context, code, refereeAddress, sig string) (*model.Referral, error) {
+	refereeAddress, err := normalizeAddress(refereeAddress)
+	if err != nil {
+		return nil, err
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	referralCode, err := s.referralRepo.GetReferralCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral code: %w", err)
+	}
+	if referralCode == nil {
+		return nil, ErrCodeNotFound
+	}
+	if referralCode.OwnerAddress == refereeAddress {
+		return nil, ErrSelfReferral
+	}
+
+	// 签名证明被推荐人拥有该地址
+	message := BindingMessage(code, refereeAddress)
+	if err := signature.VerifyPersonalSign(refereeAddress, message, sig); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	// 不允许互相推荐
+	upstream, err := s.referralRepo.GetReferralByReferee(ctx, referralCode.OwnerAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referrer's referral: %w", err)
+	}
+	if upstream != nil && upstream.ReferrerAddress == refereeAddress {
+		return nil, ErrReferralCycle
+	}
+
+	referral := &model.Referral{
+		ReferrerAddress: referralCode.OwnerAddress,
+		RefereeAddress:  refereeAddress,
+		Code:            code,
+		Message:         message,
+		Signature:       sig,
+	}
+
+	created, err := s.referralRepo.CreateReferral(ctx, referral)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create referral: %w", err)
+	}
+	if !created {
+		return nil, ErrAlreadyBound
+	}
+
+	s.logger.Infof("Bound referral: %s referred by %s (code %s)", refereeAddress, referral.ReferrerAddress, code)
+	return referral, nil
+}
+
+// GetReferralInfo 查询地址的推荐码、推荐人和邀请的地址
+func (s *ReferralService) GetReferralInfo(ctx context.Context, address string, offset, limit int) (*ReferralInfo, error) {
+	address, err := normalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ReferralInfo{Address: address}
+
+	code, err := s.referralRepo.GetReferralCodeByOwner(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral code: %w", err)
+	}
+	if code != nil {
+		info.Code = code.Code
+	}
+
+	referral, err := s.referralRepo.GetReferralByReferee(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral: %w", err)
+	}
+	if referral != nil {
+		info.Referrer = referral.ReferrerAddress
+	}
+
+	info.Referees, err = s.referralRepo.ListReferees(ctx, address, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referees: %w", err)
+	}
+
+	return info, nil
+}
+
+// generateCode 生成随机推荐码
+func generateCode() (string, error) {
+	max := big.NewInt(int64(len(codeAlphabet)))
+	code := make([]byte, codeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// normalizeAddress 校验并标准化地址（转小写）
+func normalizeAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if !common.IsHexAddress(address) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	return strings.ToLower(address), nil
+}
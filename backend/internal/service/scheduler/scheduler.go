@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -11,14 +12,30 @@ import (
 
 	"my-token-points/internal/model"
 	"my-token-points/internal/service/points"
+	"my-token-points/internal/service/sybil"
+)
+
+const (
+	// indexRetryInterval 监听器未索引完计算周期时，重新检查的最长间隔
+	indexRetryInterval = time.Minute
 )
 
 // SchedulerConfig 调度器配置
 type SchedulerConfig struct {
 	// 启用定时积分计算
 	EnableCalculation bool
-	// Cron 表达式 (例如: "0 * * * *" 表示每小时执行一次)
+	// 默认 Cron 表达式 (例如: "0 * * * *" 表示每小时执行一次)，链未单独配置时使用
 	CronExpression string
+	// 积分过期任务的 Cron 表达式（为空表示不执行过期任务）
+	ExpiryCronExpression string
+	// 女巫检测任务的 Cron 表达式（为空表示不执行检测）
+	SybilCronExpression string
+	// 发现错过的定时计算时自动回溯（等待索引超时或计算失败而推迟的周期不受此开关影响，总会补算）
+	EnableBackfill bool
+	// 启动时回溯未计算的周期，完成后再开始定时计算
+	BackfillOnStartup bool
+	// 最多回溯天数
+	BackfillMaxDays int
 	// 支持的链配置
 	Chains []ChainConfig
 }
@@ -28,23 +45,37 @@ type ChainConfig struct {
 	Name string
 	// 是否启用该链的积分计算
 	Enabled bool
+	// 该链定时计算的 Cron 表达式（为空时使用 SchedulerConfig.CronExpression）
+	// 计算周期长度由积分服务按链配置决定，Cron 触发时计算最近一个已结束的周期
+	CronExpression string
 }
 
 // Scheduler 定时任务调度器
 type Scheduler struct {
 	cron          *cron.Cron
 	pointsService *points.PointsService
+	sybilService  *sybil.SybilService
 	config        *SchedulerConfig
 	logger        *logrus.Logger
 	
 	mu      sync.Mutex
 	running bool
 	stopCh  chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	// 每条链定时计算已完成到的时间（周期结束时间），用于发现错过的定时计算
+	calcMu          sync.Mutex
+	calculatedUntil map[string]time.Time
+	// 每条链因等待索引超时或计算失败而推迟的最早周期开始时间，未开启回溯时也会在下次定时计算时补算
+	deferredFrom map[string]time.Time
 }
 
 // NewScheduler 创建调度器
 func NewScheduler(
 	pointsService *points.PointsService,
+	sybilService *sybil.SybilService,
 	config *SchedulerConfig,
 	logger *logrus.Logger,
 ) *Scheduler {
@@ -52,13 +83,20 @@ func NewScheduler(
 	if config.CronExpression == "" {
 		config.CronExpression = "0 0 * * * *" // 默认每小时执行一次（秒 分 时 日 月 周）
 	}
+	if config.BackfillMaxDays <= 0 {
+		config.BackfillMaxDays = 30
+	}
 
 	return &Scheduler{
-		cron:          cron.New(cron.WithSeconds()), // 支持秒级精度
+		cron:          newCron(logger),
 		pointsService: pointsService,
+		sybilService:  sybilService,
 		config:        config,
 		logger:        logger,
 		stopCh:        make(chan struct{}),
+
+		calculatedUntil: make(map[string]time.Time),
+		deferredFrom:    make(map[string]time.Time),
 	}
 }
 
@@ -76,96 +114,333 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		return nil
 	}
 
-	s.logger.Infof("Starting points calculation scheduler with cron: %s", s.config.CronExpression)
+	s.logger.Info("Starting points calculation scheduler")
+	s.ctx, s.cancel = context.WithCancel(ctx)
 
-	// 添加定时任务
-	_, err := s.cron.AddFunc(s.config.CronExpression, func() {
-		s.runPointsCalculation()
-	})
-	if err != nil {
-		return fmt.Errorf("failed to add cron job: %w", err)
+	// 失去领导权后可能再次启动，每次使用新的 cron
+	s.cron = newCron(s.logger)
+	s.stopCh = make(chan struct{})
+
+	// 每条链按各自的 Cron 表达式添加定时任务
+	for _, chainConfig := range s.config.Chains {
+		if !chainConfig.Enabled {
+			s.logger.Infof("Points calculation is disabled for chain %s", chainConfig.Name)
+			continue
+		}
+
+		chainName := chainConfig.Name
+		cronExpression := s.chainCron(chainConfig)
+		s.logger.Infof("Scheduling points calculation for chain %s with cron: %s (period: %s)",
+			chainName, cronExpression, s.pointsService.CalcInterval(chainName))
+		_, err := s.cron.AddFunc(cronExpression, func() {
+			s.runPointsCalculation(chainName)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add cron job for chain %s: %w", chainName, err)
+		}
+	}
+
+	if s.config.ExpiryCronExpression != "" {
+		s.logger.Infof("Scheduling points expiry with cron: %s", s.config.ExpiryCronExpression)
+		_, err := s.cron.AddFunc(s.config.ExpiryCronExpression, func() {
+			s.runPointsExpiry()
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add expiry cron job: %w", err)
+		}
+	}
+
+	if s.config.SybilCronExpression != "" && s.sybilService != nil {
+		s.logger.Infof("Scheduling sybil analysis with cron: %s", s.config.SybilCronExpression)
+		_, err := s.cron.AddFunc(s.config.SybilCronExpression, func() {
+			s.runSybilAnalysis()
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add sybil analysis cron job: %w", err)
+		}
+	}
+
+	s.running = true
+
+	// 启动时先按顺序回溯停机期间错过的周期，再开始定时计算
+	if s.config.BackfillOnStartup {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.backfillOnStartup(s.ctx)
+			s.startCron()
+		}()
+		return nil
 	}
 
 	// 启动 cron
 	s.cron.Start()
-	s.running = true
 
 	s.logger.Info("Points calculation scheduler started successfully")
 
 	// 可选：启动时立即执行一次
-	// go s.runPointsCalculation()
+	// go s.runPointsCalculation(chainName)
 
 	return nil
 }
 
-// Stop 停止调度器
-func (s *Scheduler) Stop() error {
+// startCron 启动回溯完成后开始定时计算（调度器已停止时不启动）
+func (s *Scheduler) startCron() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if !s.running || s.ctx.Err() != nil {
+		return
+	}
+
+	s.cron.Start()
+	s.logger.Info("Points calculation scheduler started successfully")
+}
+
+// Stop 停止调度器
+func (s *Scheduler) Stop() error {
+	s.mu.Lock()
 	if !s.running {
+		s.mu.Unlock()
 		return nil
 	}
 
 	s.logger.Info("Stopping points calculation scheduler...")
+	s.running = false
+	s.cancel()
+	s.mu.Unlock()
+
+	// 等待启动时的回溯在当前周期完成后退出
+	s.wg.Wait()
 
 	// 停止 cron
 	ctx := s.cron.Stop()
 	<-ctx.Done()
 
 	close(s.stopCh)
-	s.running = false
 
 	s.logger.Info("Points calculation scheduler stopped")
 	return nil
 }
 
-// runPointsCalculation 执行积分计算
-func (s *Scheduler) runPointsCalculation() {
-	s.logger.Info("Starting scheduled points calculation")
-
+// runPointsCalculation 计算链上最近一个已结束周期的积分
+func (s *Scheduler) runPointsCalculation(chainName string) {
 	ctx := context.Background()
+	periodStart, periodEnd := s.pointsService.LastPeriod(chainName, time.Now())
+
+	// Cron 比计算周期更频繁时，同一周期只计算一次
+	// 首次运行时从本周期开始记录，本周期未完成时下次运行可以发现并补算
+	s.calcMu.Lock()
+	last, ok := s.calculatedUntil[chainName]
+	if !ok {
+		s.calculatedUntil[chainName] = periodStart
+	}
+	s.calcMu.Unlock()
+	if ok && !last.Before(periodEnd) {
+		s.logger.Debugf("Points for chain %s already calculated until %s, skipping", chainName, last.Format(time.RFC3339))
+		return
+	}
 
-	// 计算上一个小时的积分
-	now := time.Now()
-	periodEnd := now.Truncate(time.Hour) // 当前小时的开始时间
-	periodStart := periodEnd.Add(-time.Hour) // 上一个小时的开始时间
+	s.logger.Infof("Starting scheduled points calculation for chain %s, period: %s to %s",
+		chainName, periodStart.Format(time.RFC3339), periodEnd.Format(time.RFC3339))
 
-	s.logger.Infof("Calculating points for period: %s to %s",
-		periodStart.Format(time.RFC3339), periodEnd.Format(time.RFC3339))
+	s.backfillMissedTicks(chainName, periodStart)
 
-	// 为每条链计算积分
-	var wg sync.WaitGroup
+	// 监听器落后时推迟计算，避免用不完整的余额变动计算并标记为已计算
+	block, ok := s.waitForIndexing(chainName, periodEnd)
+	if !ok {
+		s.deferPeriod(chainName, periodStart)
+		return
+	}
+
+	s.logger.Infof("Calculating points for chain: %s (based on block %d)", chainName, block)
+
+	err := s.pointsService.CalculatePointsForAllUsers(
+		ctx,
+		chainName,
+		periodStart,
+		periodEnd,
+		model.CalcTypeNormal,
+	)
+	if err != nil {
+		s.logger.Errorf("Failed to calculate points for chain %s, the period is deferred to the next scheduled run: %v", chainName, err)
+		s.deferPeriod(chainName, periodStart)
+		return
+	}
+
+	s.markCalculated(chainName, periodEnd)
+	s.logger.Infof("Successfully calculated points for chain: %s", chainName)
+}
+
+// backfillOnStartup 回溯每条链最近 BackfillMaxDays 天内未计算的周期
+func (s *Scheduler) backfillOnStartup(ctx context.Context) {
 	for _, chainConfig := range s.config.Chains {
 		if !chainConfig.Enabled {
 			continue
 		}
+		if ctx.Err() != nil {
+			return
+		}
 
-		wg.Add(1)
-		go func(chainName string) {
-			defer wg.Done()
-
-			s.logger.Infof("Calculating points for chain: %s", chainName)
-
-			err := s.pointsService.CalculatePointsForAllUsers(
-				ctx,
-				chainName,
-				periodStart,
-				periodEnd,
-				model.CalcTypeNormal,
-			)
-			if err != nil {
-				s.logger.Errorf("Failed to calculate points for chain %s: %v", chainName, err)
-				return
+		_, end := s.pointsService.LastPeriod(chainConfig.Name, time.Now())
+		start := end.Add(-time.Duration(s.config.BackfillMaxDays) * 24 * time.Hour)
+
+		s.logger.Infof("Checking uncalculated periods for chain %s since %s", chainConfig.Name, start.Format(time.RFC3339))
+		// 回溯失败时只标记到最后一个连续完成的周期，之后的周期由定时计算继续回溯
+		var calculatedUntil time.Time
+		err := s.pointsService.BackfillPoints(ctx, chainConfig.Name, start, end, func(_, _ int, cursor time.Time) {
+			calculatedUntil = cursor
+		})
+		if err != nil {
+			s.logger.Errorf("Failed to backfill points on startup for chain %s: %v", chainConfig.Name, err)
+			if !calculatedUntil.IsZero() {
+				s.markCalculated(chainConfig.Name, calculatedUntil)
 			}
+			continue
+		}
+		s.markCalculated(chainConfig.Name, end)
+	}
+}
 
-			s.logger.Infof("Successfully calculated points for chain: %s", chainName)
-		}(chainConfig.Name)
+// backfillMissedTicks 补算本周期之前推迟的周期，开启回溯时还回溯上次定时计算之后错过的周期（进程暂停等）
+func (s *Scheduler) backfillMissedTicks(chainName string, periodStart time.Time) {
+	s.calcMu.Lock()
+	last, ok := s.calculatedUntil[chainName]
+	deferred, hasDeferred := s.deferredFrom[chainName]
+	s.calcMu.Unlock()
+
+	var from time.Time
+	if s.config.EnableBackfill && ok && last.Before(periodStart) {
+		from = last
+	}
+	if hasDeferred && deferred.Before(periodStart) && (from.IsZero() || deferred.Before(from)) {
+		from = deferred
+	}
+	if from.IsZero() {
+		return
+	}
+
+	if earliest := periodStart.Add(-time.Duration(s.config.BackfillMaxDays) * 24 * time.Hour); from.Before(earliest) {
+		from = earliest
+	}
+
+	s.logger.Warnf("Detected missed points calculations for chain %s from %s to %s, backfilling",
+		chainName, from.Format(time.RFC3339), periodStart.Format(time.RFC3339))
+
+	var calculatedUntil time.Time
+	err := s.pointsService.BackfillPoints(s.ctx, chainName, from, periodStart, func(_, _ int, cursor time.Time) {
+		calculatedUntil = cursor
+	})
+
+	// 失败时从最后一个连续完成的周期之后推迟，下次定时计算继续补算
+	s.calcMu.Lock()
+	defer s.calcMu.Unlock()
+	if err == nil {
+		delete(s.deferredFrom, chainName)
+		return
+	}
+
+	s.logger.Errorf("Failed to backfill missed periods for chain %s, deferred to the next scheduled run: %v", chainName, err)
+	if calculatedUntil.After(from) {
+		from = calculatedUntil
+	}
+	s.deferredFrom[chainName] = from
+}
+
+// deferPeriod 记录推迟的周期，保留最早的开始时间
+func (s *Scheduler) deferPeriod(chainName string, periodStart time.Time) {
+	s.calcMu.Lock()
+	defer s.calcMu.Unlock()
+
+	if deferred, ok := s.deferredFrom[chainName]; !ok || periodStart.Before(deferred) {
+		s.deferredFrom[chainName] = periodStart
+	}
+}
+
+// waitForIndexing 等待监听器索引到周期结束之后的区块，返回已同步的区块高度
+// 超时或调度器停止时返回 false
+func (s *Scheduler) waitForIndexing(chainName string, periodEnd time.Time) (int64, bool) {
+	timeout, retryInterval := s.indexWait(chainName)
+	deadline := time.Now().Add(timeout)
+	for {
+		block, err := s.pointsService.IndexedBlock(s.ctx, chainName, periodEnd)
+		if err == nil {
+			if block == nil {
+				return 0, true
+			}
+			return *block, true
+		}
+
+		if errors.Is(err, points.ErrPeriodNotIndexed) {
+			s.logger.Warnf("Deferring points calculation for chain %s: %v", chainName, err)
+		} else {
+			s.logger.Errorf("Failed to check indexing progress for chain %s: %v", chainName, err)
+		}
+
+		if time.Now().After(deadline) {
+			s.logger.Errorf("Gave up waiting for chain %s to index blocks past %s, the period is deferred to the next scheduled run",
+				chainName, periodEnd.Format(time.RFC3339))
+			return 0, false
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return 0, false
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// indexWait 等待监听器索引的最长时间和重新检查的间隔
+// 最多等待链计算周期的 5/6（小时周期为 50 分钟），在下一个周期开始前放弃，超时的周期推迟到下次定时计算时补算
+func (s *Scheduler) indexWait(chainName string) (time.Duration, time.Duration) {
+	timeout := s.pointsService.CalcInterval(chainName) * 5 / 6
+	retryInterval := indexRetryInterval
+	if retryInterval > timeout/5 {
+		retryInterval = timeout / 5
+	}
+	return timeout, retryInterval
+}
+
+// markCalculated 记录链上定时计算已完成到的时间
+func (s *Scheduler) markCalculated(chainName string, until time.Time) {
+	s.calcMu.Lock()
+	defer s.calcMu.Unlock()
+
+	if until.After(s.calculatedUntil[chainName]) {
+		s.calculatedUntil[chainName] = until
 	}
+}
 
-	wg.Wait()
+// runPointsExpiry 执行积分过期
+func (s *Scheduler) runPointsExpiry() {
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, chainConfig := range s.config.Chains {
+		if !chainConfig.Enabled {
+			continue
+		}
 
-	s.logger.Info("Scheduled points calculation completed")
+		if err := s.pointsService.ExpirePoints(ctx, chainConfig.Name, now); err != nil {
+			s.logger.Errorf("Failed to expire points for chain %s: %v", chainConfig.Name, err)
+		}
+	}
+}
+
+// runSybilAnalysis 执行女巫检测
+func (s *Scheduler) runSybilAnalysis() {
+	ctx := context.Background()
+
+	for _, chainConfig := range s.config.Chains {
+		if !chainConfig.Enabled {
+			continue
+		}
+
+		if _, err := s.sybilService.Analyze(ctx, chainConfig.Name); err != nil {
+			s.logger.Errorf("Failed to run sybil analysis for chain %s: %v", chainConfig.Name, err)
+		}
+	}
 }
 
 // RunBackfill 执行回溯计算
@@ -173,17 +448,15 @@ func (s *Scheduler) RunBackfill(ctx context.Context, chainName string, startTime
 	s.logger.Infof("Starting backfill for chain %s from %s to %s",
 		chainName, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
 
-	return s.pointsService.BackfillPoints(ctx, chainName, startTime, endTime)
+	return s.pointsService.BackfillPoints(ctx, chainName, startTime, endTime, nil)
 }
 
 // TriggerCalculation 手动触发积分计算
 func (s *Scheduler) TriggerCalculation(ctx context.Context, chainName string) error {
 	s.logger.Infof("Manually triggering points calculation for chain: %s", chainName)
 
-	// 计算上一个小时的积分
-	now := time.Now()
-	periodEnd := now.Truncate(time.Hour)
-	periodStart := periodEnd.Add(-time.Hour)
+	// 计算最近一个已结束周期的积分
+	periodStart, periodEnd := s.pointsService.LastPeriod(chainName, time.Now())
 
 	return s.pointsService.CalculatePointsForAllUsers(
 		ctx,
@@ -194,6 +467,24 @@ func (s *Scheduler) TriggerCalculation(ctx context.Context, chainName string) er
 	)
 }
 
+// chainCron 链的定时计算 Cron 表达式
+func (s *Scheduler) chainCron(chainConfig ChainConfig) string {
+	if chainConfig.CronExpression != "" {
+		return chainConfig.CronExpression
+	}
+	return s.config.CronExpression
+}
+
+// newCron 创建支持秒级精度的 cron，上一次执行未结束时跳过本次（例如等待监听器索引时）
+// Cron 表达式按 UTC 解释，与计算周期的对齐方式一致，不受服务器时区和夏令时影响
+func newCron(logger *logrus.Logger) *cron.Cron {
+	return cron.New(
+		cron.WithSeconds(),
+		cron.WithLocation(time.UTC),
+		cron.WithChain(cron.SkipIfStillRunning(cron.PrintfLogger(logger))),
+	)
+}
+
 // IsRunning 检查调度器是否运行中
 func (s *Scheduler) IsRunning() bool {
 	s.mu.Lock()
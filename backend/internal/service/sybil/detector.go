@@ -0,0 +1,257 @@
+package sybil
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"my-token-points/internal/model"
+)
+
+// 各信号的评分参数
+const (
+	// 参与一个循环转账的基础分，每多一个循环加 circularStepScore
+	circularBaseScore = 0.6
+	circularStepScore = 0.1
+	// 单个地址最多统计的循环数，避免稠密子图下搜索爆炸
+	maxCyclesPerAddress = 20
+
+	// 转出金额不低于转入金额的该比例时视为一次快进快出
+	rapidAmountRatio = 0.9
+
+	// 同源资金集群的基础分（单独命中不足以标记，需要叠加其他信号）
+	clusterBaseScore = 0.4
+	clusterStepScore = 0.02
+	clusterMaxScore  = 0.7
+)
+
+// detector 一次检测的输入
+type detector struct {
+	config  *SybilConfig
+	ignored map[string]bool // 排除地址和托管合约，视为外部地址
+
+	edges   []*model.TransferEdge // 检测窗口内的转账
+	funding []*model.TransferEdge // 每个地址的首笔转入
+}
+
+// detect 执行检测，返回命中信号的地址（按地址排序）
+func (d *detector) detect() []*model.AddressRisk {
+	risks := make(map[string]*model.AddressRisk)
+	addSignal := func(address string, signal model.RiskSignal) {
+		risk, ok := risks[address]
+		if !ok {
+			risk = &model.AddressRisk{Address: address}
+			risks[address] = risk
+		}
+		risk.Signals = append(risk.Signals, signal)
+	}
+
+	edges := d.filterEdges(d.edges)
+
+	for address, cycles := range d.findCycles(edges) {
+		score := circularBaseScore + circularStepScore*float64(cycles-1)
+		addSignal(address, model.RiskSignal{
+			Type:   model.RiskSignalCircular,
+			Score:  capScore(score),
+			Detail: fmt.Sprintf("part of %d circular transfer path(s) of length <= %d", cycles, d.config.MaxCycleLength),
+		})
+	}
+
+	for address, roundTrips := range d.findRapidRoundTrips(edges) {
+		if roundTrips < d.config.RapidMinRoundTrips {
+			continue
+		}
+		score := 0.5 * float64(roundTrips) / float64(d.config.RapidMinRoundTrips)
+		addSignal(address, model.RiskSignal{
+			Type:   model.RiskSignalRapid,
+			Score:  capScore(score),
+			Detail: fmt.Sprintf("%d in/out round trips within %s", roundTrips, d.config.RapidWindow),
+		})
+	}
+
+	funders := make(map[string]string)
+	for funder, members := range d.findClusters() {
+		score := clusterBaseScore + clusterStepScore*float64(len(members)-d.config.MinClusterSize)
+		if score > clusterMaxScore {
+			score = clusterMaxScore
+		}
+		for _, member := range members {
+			funders[member] = funder
+			addSignal(member, model.RiskSignal{
+				Type:   model.RiskSignalCluster,
+				Score:  score,
+				Detail: fmt.Sprintf("one of %d addresses first funded by %s", len(members), funder),
+			})
+		}
+	}
+
+	result := make([]*model.AddressRisk, 0, len(risks))
+	for address, risk := range risks {
+		risk.Score = combineScores(risk.Signals)
+		if funder, ok := funders[address]; ok {
+			risk.FunderAddress = &funder
+		}
+		result = append(result, risk)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Address < result[j].Address })
+	return result
+}
+
+// filterEdges 去掉涉及外部地址和自转的转账
+func (d *detector) filterEdges(edges []*model.TransferEdge) []*model.TransferEdge {
+	filtered := make([]*model.TransferEdge, 0, len(edges))
+	for _, edge := range edges {
+		if edge.From == edge.To || d.ignored[edge.From] || d.ignored[edge.To] {
+			continue
+		}
+		filtered = append(filtered, edge)
+	}
+	return filtered
+}
+
+// findCycles 统计每个地址参与的简单循环数（长度 2 到 MaxCycleLength）
+// 只从环上最小的地址开始搜索，每个循环只统计一次
+func (d *detector) findCycles(edges []*model.TransferEdge) map[string]int {
+	// 邻接表按地址排序，保证达到上限时结果稳定
+	seen := make(map[[2]string]bool)
+	graph := make(map[string][]string)
+	for _, edge := range edges {
+		key := [2]string{edge.From, edge.To}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		graph[edge.From] = append(graph[edge.From], edge.To)
+	}
+
+	nodes := make([]string, 0, len(graph))
+	for node, next := range graph {
+		sort.Strings(next)
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	counts := make(map[string]int)
+	for _, start := range nodes {
+		if counts[start] >= maxCyclesPerAddress {
+			continue
+		}
+
+		path := []string{start}
+		onPath := map[string]bool{start: true}
+		var walk func(node string)
+		walk = func(node string) {
+			for _, next := range graph[node] {
+				if counts[start] >= maxCyclesPerAddress {
+					return
+				}
+				if next == start && len(path) >= 2 {
+					for _, member := range path {
+						counts[member]++
+					}
+					continue
+				}
+				if next <= start || onPath[next] || len(path) >= d.config.MaxCycleLength {
+					continue
+				}
+				path = append(path, next)
+				onPath[next] = true
+				walk(next)
+				onPath[next] = false
+				path = path[:len(path)-1]
+			}
+		}
+		walk(start)
+	}
+	return counts
+}
+
+// findRapidRoundTrips 统计每个地址的快进快出次数
+// 转入后 RapidWindow 内转出不少于转入金额 rapidAmountRatio 的代币记为一次，每笔转出只匹配一次
+func (d *detector) findRapidRoundTrips(edges []*model.TransferEdge) map[string]int {
+	inbound := make(map[string][]*model.TransferEdge)
+	outbound := make(map[string][]*model.TransferEdge)
+	for _, edge := range edges {
+		inbound[edge.To] = append(inbound[edge.To], edge)
+		outbound[edge.From] = append(outbound[edge.From], edge)
+	}
+
+	counts := make(map[string]int)
+	for address, ins := range inbound {
+		outs := outbound[address]
+		if len(outs) == 0 {
+			continue
+		}
+
+		used := make([]bool, len(outs))
+		for _, in := range ins {
+			inAmount, ok := new(big.Int).SetString(in.Amount, 10)
+			if !ok || inAmount.Sign() <= 0 {
+				continue
+			}
+			minOut := ratioOf(inAmount, rapidAmountRatio)
+			deadline := in.BlockTime.Add(d.config.RapidWindow)
+
+			for i, out := range outs {
+				if used[i] || out.BlockNumber < in.BlockNumber || out.BlockTime.After(deadline) {
+					continue
+				}
+				outAmount, ok := new(big.Int).SetString(strings.TrimPrefix(out.Amount, "-"), 10)
+				if !ok || outAmount.Cmp(minOut) < 0 {
+					continue
+				}
+				used[i] = true
+				counts[address]++
+				break
+			}
+		}
+	}
+	return counts
+}
+
+// findClusters 按首笔资金来源分组，返回成员数达到 MinClusterSize 的集群
+func (d *detector) findClusters() map[string][]string {
+	members := make(map[string][]string)
+	for _, edge := range d.funding {
+		if d.ignored[edge.From] || d.ignored[edge.To] {
+			continue
+		}
+		members[edge.From] = append(members[edge.From], edge.To)
+	}
+
+	clusters := make(map[string][]string)
+	for funder, addresses := range members {
+		if len(addresses) >= d.config.MinClusterSize {
+			sort.Strings(addresses)
+			clusters[funder] = addresses
+		}
+	}
+	return clusters
+}
+
+// combineScores 合并多个信号：1 - Π(1 - s)
+func combineScores(signals model.RiskSignals) float64 {
+	clean := 1.0
+	for _, signal := range signals {
+		clean *= 1 - signal.Score
+	}
+	return capScore(1 - clean)
+}
+
+// capScore 限制风险分在 [0, 1]，保留 4 位小数（与表结构一致）
+func capScore(score float64) float64 {
+	if score > 1 {
+		score = 1
+	}
+	if score < 0 {
+		score = 0
+	}
+	return float64(int64(score*10000+0.5)) / 10000
+}
+
+// ratioOf 返回 amount × ratio（向下取整）
+func ratioOf(amount *big.Int, ratio float64) *big.Int {
+	result, _ := new(big.Float).Mul(new(big.Float).SetInt(amount), big.NewFloat(ratio)).Int(nil)
+	return result
+}
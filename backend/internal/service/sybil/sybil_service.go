@@ -0,0 +1,285 @@
+package sybil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+	"my-token-points/internal/service/exclusion"
+)
+
+// riskPageSize 加载风险地址时的分页大小
+const riskPageSize = 1000
+
+var (
+	// ErrUnknownChain 链未配置
+	ErrUnknownChain = errors.New("unknown chain")
+	// ErrInvalidAddress 地址格式错误
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrInvalidOverride 覆盖类型错误
+	ErrInvalidOverride = errors.New("override must be one of none, clear, flag, exclude")
+	// ErrReasonRequired 缺少覆盖原因
+	ErrReasonRequired = errors.New("reason is required")
+	// ErrOperatorRequired 缺少操作人
+	ErrOperatorRequired = errors.New("operator is required")
+	// ErrRiskNotFound 地址没有风险记录
+	ErrRiskNotFound = errors.New("address has no risk record")
+)
+
+// SybilConfig 女巫检测配置
+type SybilConfig struct {
+	// 检测窗口
+	Lookback time.Duration
+	// 循环转账的最大地址数
+	MaxCycleLength int
+	// 快进快出的时间窗口
+	RapidWindow time.Duration
+	// 快进快出达到多少次才计入
+	RapidMinRoundTrips int
+	// 同源资金集群的最少地址数
+	MinClusterSize int
+	// 风险分达到该值时降权
+	FlagThreshold float64
+	// 风险分达到该值时排除
+	ExcludeThreshold float64
+	// 降权地址的积分倍数
+	FlaggedMultiplier float64
+}
+
+// AnalysisResult 一次检测的结果汇总
+type AnalysisResult struct {
+	ChainName  string    `json:"chain_name"`
+	AnalyzedAt time.Time `json:"analyzed_at"`
+	Transfers  int       `json:"transfers"`
+	Scored     int       `json:"scored"`
+	Flagged    int       `json:"flagged"`
+	Excluded   int       `json:"excluded"`
+}
+
+// SybilService 女巫/刷量检测服务
+// 检测任务按地址写入风险分，积分计算按风险分和人工覆盖对地址降权或排除
+type SybilService struct {
+	riskRepo         repository.RiskRepository
+	exclusionService *exclusion.ExclusionService
+	custody          map[string][]string
+	chains           map[string]bool
+	config           *SybilConfig
+	logger           *logrus.Logger
+}
+
+// NewSybilService 创建女巫检测服务
+func NewSybilService(
+	riskRepo repository.RiskRepository,
+	exclusionService *exclusion.ExclusionService,
+	custodyAddresses map[string][]string,
+	chainNames []string,
+	config *SybilConfig,
+	logger *logrus.Logger,
+) *SybilService {
+	chains := make(map[string]bool, len(chainNames))
+	for _, name := range chainNames {
+		chains[name] = true
+	}
+
+	return &SybilService{
+		riskRepo:         riskRepo,
+		exclusionService: exclusionService,
+		custody:          custodyAddresses,
+		chains:           chains,
+		config:           config,
+		logger:           logger,
+	}
+}
+
+// Analyze 检测链上最近 Lookback 内的转账并更新风险分
+// 排除地址（交易所、DEX 池等）和托管合约视为外部地址，不参与检测
+func (s *SybilService) Analyze(ctx context.Context, chainName string) (*AnalysisResult, error) {
+	if !s.chains[chainName] {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChain, chainName)
+	}
+
+	now := time.Now()
+	edges, err := s.riskRepo.GetTransferEdges(ctx, chainName, now.Add(-s.config.Lookback), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfers: %w", err)
+	}
+	funding, err := s.riskRepo.GetFirstFundingEdges(ctx, chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funding transfers: %w", err)
+	}
+
+	ignored, err := s.exclusionService.ExcludedSet(ctx, chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get excluded addresses: %w", err)
+	}
+	for _, custody := range s.custody[chainName] {
+		ignored[strings.ToLower(custody)] = true
+	}
+
+	d := &detector{config: s.config, ignored: ignored, edges: edges, funding: funding}
+	risks := d.detect()
+
+	if err := s.riskRepo.ReplaceRiskScores(ctx, chainName, risks, now); err != nil {
+		return nil, fmt.Errorf("failed to save risk scores: %w", err)
+	}
+
+	result := &AnalysisResult{ChainName: chainName, AnalyzedAt: now, Transfers: len(edges), Scored: len(risks)}
+	for _, risk := range risks {
+		// 只统计按风险分得出的结果，人工覆盖不计入
+		risk.Override = model.RiskOverrideNone
+		s.evaluate(risk)
+		switch risk.Action {
+		case model.RiskActionDownweight:
+			result.Flagged++
+		case model.RiskActionExclude:
+			result.Excluded++
+		}
+	}
+
+	s.logger.Infof("Sybil analysis on %s: %d transfers, %d addresses scored, %d flagged, %d excluded by score",
+		chainName, result.Transfers, result.Scored, result.Flagged, result.Excluded)
+	return result, nil
+}
+
+// RiskMultipliers 返回链上需要降权或排除的地址及其积分倍数（0 表示排除）
+func (s *SybilService) RiskMultipliers(ctx context.Context, chainName string) (map[string]float64, error) {
+	multipliers := make(map[string]float64)
+	for offset := 0; ; offset += riskPageSize {
+		risks, err := s.riskRepo.ListRiskScores(ctx, chainName, s.config.FlagThreshold, offset, riskPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list risk scores: %w", err)
+		}
+
+		for _, risk := range risks {
+			s.evaluate(risk)
+			if risk.Action != model.RiskActionNone {
+				multipliers[risk.Address] = risk.Multiplier
+			}
+		}
+		if len(risks) < riskPageSize {
+			return multipliers, nil
+		}
+	}
+}
+
+// ListRisks 分页查询风险分不低于 minScore 或有人工覆盖的地址
+func (s *SybilService) ListRisks(ctx context.Context, chainName string, minScore float64, offset, limit int) ([]*model.AddressRisk, error) {
+	risks, err := s.riskRepo.ListRiskScores(ctx, chainName, minScore, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list risk scores: %w", err)
+	}
+	for _, risk := range risks {
+		s.evaluate(risk)
+	}
+	return risks, nil
+}
+
+// GetRisk 查询单个地址的风险分和命中的信号
+func (s *SybilService) GetRisk(ctx context.Context, chainName, address string) (*model.AddressRisk, error) {
+	address, err := normalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	risk, err := s.riskRepo.GetRiskScore(ctx, chainName, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get risk score: %w", err)
+	}
+	if risk == nil {
+		return nil, ErrRiskNotFound
+	}
+
+	s.evaluate(risk)
+	return risk, nil
+}
+
+// SetOverride 人工覆盖地址的处理结果
+// clear 表示确认不是女巫（不降权），flag/exclude 强制降权/排除，none 恢复按风险分处理
+func (s *SybilService) SetOverride(ctx context.Context, chainName, address, override, reason, operator string) (*model.AddressRisk, error) {
+	if !s.chains[chainName] {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChain, chainName)
+	}
+	address, err := normalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	switch override {
+	case model.RiskOverrideNone, model.RiskOverrideClear, model.RiskOverrideFlag, model.RiskOverrideExclude:
+	default:
+		return nil, ErrInvalidOverride
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+	if operator == "" {
+		return nil, ErrOperatorRequired
+	}
+
+	risk, err := s.riskRepo.SetRiskOverride(ctx, chainName, address, override, reason, operator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set risk override: %w", err)
+	}
+
+	s.evaluate(risk)
+	s.logger.Infof("Risk override for %s on %s set to %s by %s: %s", address, chainName, override, operator, reason)
+	return risk, nil
+}
+
+// ListAudit 分页查询风险覆盖审计记录
+func (s *SybilService) ListAudit(ctx context.Context, chainName, address string, offset, limit int) ([]*model.AddressRiskAudit, error) {
+	audits, err := s.riskRepo.ListRiskAudit(ctx, chainName, strings.ToLower(address), offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list risk audit: %w", err)
+	}
+	return audits, nil
+}
+
+// FlagThreshold 降权阈值
+func (s *SybilService) FlagThreshold() float64 {
+	return s.config.FlagThreshold
+}
+
+// evaluate 按人工覆盖和风险分确定处理结果
+func (s *SybilService) evaluate(risk *model.AddressRisk) {
+	action := model.RiskActionNone
+	switch risk.Override {
+	case model.RiskOverrideClear:
+	case model.RiskOverrideFlag:
+		action = model.RiskActionDownweight
+	case model.RiskOverrideExclude:
+		action = model.RiskActionExclude
+	default:
+		if risk.Score >= s.config.ExcludeThreshold {
+			action = model.RiskActionExclude
+		} else if risk.Score >= s.config.FlagThreshold {
+			action = model.RiskActionDownweight
+		}
+	}
+
+	risk.Action = action
+	switch action {
+	case model.RiskActionExclude:
+		risk.Multiplier = 0
+	case model.RiskActionDownweight:
+		risk.Multiplier = s.config.FlaggedMultiplier
+	default:
+		risk.Multiplier = 1
+	}
+}
+
+// normalizeAddress 校验并标准化地址（转小写）
+func normalizeAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if !common.IsHexAddress(address) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	return strings.ToLower(address), nil
+}
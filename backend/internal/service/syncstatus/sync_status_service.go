@@ -0,0 +1,98 @@
+package syncstatus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"my-token-points/internal/model"
+	"my-token-points/internal/repository"
+)
+
+// ChainSyncStatus 链的索引进度
+type ChainSyncStatus struct {
+	*model.SyncState
+	// 落后链上最新区块的时长（秒），缺少区块时间时为空
+	LagSeconds *int64 `json:"lag_seconds"`
+}
+
+// SyncStatusService 同步状态查询服务
+type SyncStatusService struct {
+	syncRepo repository.SyncRepository
+	logger   *logrus.Logger
+}
+
+// NewSyncStatusService 创建同步状态查询服务
+func NewSyncStatusService(
+	syncRepo repository.SyncRepository,
+	logger *logrus.Logger,
+) *SyncStatusService {
+	return &SyncStatusService{
+		syncRepo: syncRepo,
+		logger:   logger,
+	}
+}
+
+// GetChainStatus 查询单条链的同步状态
+func (s *SyncStatusService) GetChainStatus(ctx context.Context, chainName string) (*ChainSyncStatus, error) {
+	state, err := s.syncRepo.GetSyncState(ctx, chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync state: %w", err)
+	}
+	if state == nil {
+		return nil, nil
+	}
+	return newChainSyncStatus(state), nil
+}
+
+// ListChainStatuses 查询所有链的同步状态
+func (s *SyncStatusService) ListChainStatuses(ctx context.Context) ([]*ChainSyncStatus, error) {
+	states, err := s.syncRepo.ListSyncStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync states: %w", err)
+	}
+
+	statuses := make([]*ChainSyncStatus, 0, len(states))
+	for _, state := range states {
+		statuses = append(statuses, newChainSyncStatus(state))
+	}
+	return statuses, nil
+}
+
+// GetAsOfBlock 查询链当前已索引到的区块号，余额和积分数据反映的就是该区块的状态
+func (s *SyncStatusService) GetAsOfBlock(ctx context.Context, chainName string) (*int64, error) {
+	state, err := s.syncRepo.GetSyncState(ctx, chainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync state: %w", err)
+	}
+	if state == nil {
+		return nil, nil
+	}
+	return &state.LastSyncedBlock, nil
+}
+
+// IsHealthy 所有链都没有处于 error 状态时返回 true
+func (s *SyncStatusService) IsHealthy(statuses []*ChainSyncStatus) bool {
+	for _, status := range statuses {
+		if status.Status == model.StatusError {
+			return false
+		}
+	}
+	return true
+}
+
+// newChainSyncStatus 根据同步状态计算落后时长
+func newChainSyncStatus(state *model.SyncState) *ChainSyncStatus {
+	status := &ChainSyncStatus{SyncState: state}
+
+	if state.ChainHeadTime != nil && state.LastSyncedBlockTime != nil {
+		lag := int64(state.ChainHeadTime.Sub(*state.LastSyncedBlockTime).Seconds())
+		if lag < 0 {
+			lag = 0
+		}
+		status.LagSeconds = &lag
+	}
+
+	return status
+}
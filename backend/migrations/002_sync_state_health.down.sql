@@ -0,0 +1,10 @@
+-- ==========================================
+-- 回滚同步状态健康状况字段
+-- ==========================================
+
+ALTER TABLE sync_state
+    DROP COLUMN IF EXISTS head_lag_blocks,
+    DROP COLUMN IF EXISTS chain_head_block,
+    DROP COLUMN IF EXISTS last_success_at,
+    DROP COLUMN IF EXISTS last_error_at,
+    DROP COLUMN IF EXISTS consecutive_failures;
@@ -0,0 +1,16 @@
+-- ==========================================
+-- 同步状态表增加健康状况字段
+-- ==========================================
+
+ALTER TABLE sync_state
+    ADD COLUMN IF NOT EXISTS consecutive_failures INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_error_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS last_success_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS chain_head_block BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS head_lag_blocks BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN sync_state.consecutive_failures IS '连续扫描失败次数 (成功后清零)';
+COMMENT ON COLUMN sync_state.last_error_at IS '最后一次扫描失败时间';
+COMMENT ON COLUMN sync_state.last_success_at IS '最后一次扫描成功时间';
+COMMENT ON COLUMN sync_state.chain_head_block IS '最后观察到的链上最新区块号';
+COMMENT ON COLUMN sync_state.head_lag_blocks IS '落后链上最新区块的数量 (chain_head_block - last_synced_block)';
@@ -0,0 +1,8 @@
+-- ==========================================
+-- 回滚同步状态索引进度字段
+-- ==========================================
+
+ALTER TABLE sync_state
+    DROP COLUMN IF EXISTS events_processed,
+    DROP COLUMN IF EXISTS chain_head_time,
+    DROP COLUMN IF EXISTS last_synced_block_time;
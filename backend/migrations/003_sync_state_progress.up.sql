@@ -0,0 +1,12 @@
+-- ==========================================
+-- 同步状态表增加索引进度字段
+-- ==========================================
+
+ALTER TABLE sync_state
+    ADD COLUMN IF NOT EXISTS last_synced_block_time TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS chain_head_time TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS events_processed BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN sync_state.last_synced_block_time IS '最后同步区块的出块时间';
+COMMENT ON COLUMN sync_state.chain_head_time IS '最后观察到的链上最新区块的出块时间';
+COMMENT ON COLUMN sync_state.events_processed IS '累计处理的事件数';
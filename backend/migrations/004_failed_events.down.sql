@@ -0,0 +1,5 @@
+-- ==========================================
+-- 回滚死信表
+-- ==========================================
+
+DROP TABLE IF EXISTS failed_events;
@@ -0,0 +1,35 @@
+-- ==========================================
+-- 死信表：处理失败的事件日志
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS failed_events (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    contract_address VARCHAR(42) NOT NULL,
+    tx_hash VARCHAR(66) NOT NULL,
+    tx_index INT NOT NULL,
+    block_number BIGINT NOT NULL,
+    block_hash VARCHAR(66) NOT NULL,
+    log_index INT NOT NULL,
+    topics JSONB NOT NULL,
+    data TEXT NOT NULL,
+    error_message TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    next_retry_at TIMESTAMP,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_failed_events_log UNIQUE (chain_name, tx_hash, log_index),
+    CONSTRAINT ck_failed_event_status CHECK (status IN ('pending', 'exhausted', 'resolved', 'discarded'))
+);
+
+-- 索引
+CREATE INDEX idx_failed_events_retry ON failed_events(chain_name, status, next_retry_at);
+CREATE INDEX idx_failed_events_block ON failed_events(chain_name, block_number);
+
+COMMENT ON TABLE failed_events IS '死信表 - 保存处理失败的原始事件日志，供自动重试和人工处理';
+COMMENT ON COLUMN failed_events.topics IS '原始 topics (JSONB 格式的十六进制字符串数组)';
+COMMENT ON COLUMN failed_events.data IS '原始 data (十六进制字符串)';
+COMMENT ON COLUMN failed_events.attempts IS '已尝试处理的次数 (包含首次处理)';
+COMMENT ON COLUMN failed_events.status IS '状态: pending(等待重试), exhausted(重试次数用尽), resolved(已处理), discarded(已丢弃)';
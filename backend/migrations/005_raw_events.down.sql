@@ -0,0 +1,5 @@
+-- ==========================================
+-- 回滚原始事件归档表
+-- ==========================================
+
+DROP TABLE IF EXISTS raw_events;
@@ -0,0 +1,30 @@
+-- ==========================================
+-- 原始事件归档表
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS raw_events (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    contract_address VARCHAR(42) NOT NULL,
+    event_name VARCHAR(64) NOT NULL,
+    block_number BIGINT NOT NULL,
+    block_hash VARCHAR(66) NOT NULL,
+    block_time TIMESTAMP NOT NULL,
+    tx_hash VARCHAR(66) NOT NULL,
+    tx_index INT NOT NULL,
+    log_index INT NOT NULL,
+    topics JSONB NOT NULL,
+    data TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_raw_events_log UNIQUE (chain_name, tx_hash, log_index)
+);
+
+-- 索引
+CREATE INDEX idx_raw_events_order ON raw_events(chain_name, block_number, log_index);
+CREATE INDEX idx_raw_events_event ON raw_events(chain_name, event_name);
+
+COMMENT ON TABLE raw_events IS '原始事件归档表 - 保存所有匹配的合约日志，可不依赖 RPC 重建余额';
+COMMENT ON COLUMN raw_events.event_name IS '事件名称 (Transfer, TokenMinted, TokenBurned)';
+COMMENT ON COLUMN raw_events.block_time IS '区块时间 (重放时无需再次查询节点)';
+COMMENT ON COLUMN raw_events.topics IS '原始 topics (JSONB 格式的十六进制字符串数组)';
+COMMENT ON COLUMN raw_events.data IS '原始 data (十六进制字符串)';
@@ -0,0 +1,11 @@
+-- ==========================================
+-- 回滚余额变动表日志序号
+-- ==========================================
+
+ALTER TABLE balance_changes DROP CONSTRAINT IF EXISTS uk_balance_changes_log_user;
+ALTER TABLE balance_changes
+    ADD CONSTRAINT uk_balance_changes_tx_user UNIQUE (chain_name, tx_hash, user_address);
+
+ALTER TABLE balance_changes
+    DROP COLUMN IF EXISTS contract_address,
+    DROP COLUMN IF EXISTS log_index;
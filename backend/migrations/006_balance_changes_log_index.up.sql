@@ -0,0 +1,15 @@
+-- ==========================================
+-- 余额变动表增加日志序号
+-- 同一交易可能包含多个合约的事件，唯一键需要精确到日志
+-- ==========================================
+
+ALTER TABLE balance_changes
+    ADD COLUMN IF NOT EXISTS log_index INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS contract_address VARCHAR(42) NOT NULL DEFAULT '';
+
+ALTER TABLE balance_changes DROP CONSTRAINT IF EXISTS uk_balance_changes_tx_user;
+ALTER TABLE balance_changes
+    ADD CONSTRAINT uk_balance_changes_log_user UNIQUE (chain_name, tx_hash, log_index, user_address);
+
+COMMENT ON COLUMN balance_changes.log_index IS '日志在区块中的序号 (历史数据为 0)';
+COMMENT ON COLUMN balance_changes.contract_address IS '产生该变动的合约地址 (历史数据为空)';
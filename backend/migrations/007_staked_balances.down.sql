@@ -0,0 +1,14 @@
+-- ==========================================
+-- 回滚质押余额
+-- ==========================================
+
+DELETE FROM balance_changes WHERE balance_type = 'staked';
+
+ALTER TABLE balance_changes DROP CONSTRAINT IF EXISTS ck_event_type;
+ALTER TABLE balance_changes
+    ADD CONSTRAINT ck_event_type CHECK (event_type IN ('transfer_in', 'transfer_out', 'mint', 'burn'));
+
+ALTER TABLE balance_changes DROP CONSTRAINT IF EXISTS ck_balance_type;
+ALTER TABLE balance_changes DROP COLUMN IF EXISTS balance_type;
+
+ALTER TABLE user_balances DROP COLUMN IF EXISTS staked_balance;
@@ -0,0 +1,22 @@
+-- ==========================================
+-- 质押余额
+-- 托管合约（质押/金库）的存取事件记入用户的质押余额，质押期间仍可获得积分
+-- ==========================================
+
+ALTER TABLE user_balances
+    ADD COLUMN IF NOT EXISTS staked_balance NUMERIC(78, 0) NOT NULL DEFAULT 0;
+
+ALTER TABLE balance_changes
+    ADD COLUMN IF NOT EXISTS balance_type VARCHAR(10) NOT NULL DEFAULT 'wallet';
+
+ALTER TABLE balance_changes DROP CONSTRAINT IF EXISTS ck_balance_type;
+ALTER TABLE balance_changes
+    ADD CONSTRAINT ck_balance_type CHECK (balance_type IN ('wallet', 'staked'));
+
+ALTER TABLE balance_changes DROP CONSTRAINT IF EXISTS ck_event_type;
+ALTER TABLE balance_changes
+    ADD CONSTRAINT ck_event_type CHECK (event_type IN ('transfer_in', 'transfer_out', 'mint', 'burn', 'stake', 'unstake'));
+
+COMMENT ON COLUMN user_balances.staked_balance IS '托管合约中的质押余额 (wei单位)';
+COMMENT ON COLUMN balance_changes.balance_type IS '余额类型: wallet(钱包余额), staked(质押余额)';
+COMMENT ON COLUMN balance_changes.event_type IS '事件类型: transfer_in(转入), transfer_out(转出), mint(铸造), burn(销毁), stake(质押), unstake(解除质押)';
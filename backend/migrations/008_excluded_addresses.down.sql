@@ -0,0 +1,6 @@
+-- ==========================================
+-- 回滚积分排除地址
+-- ==========================================
+
+DROP TABLE IF EXISTS excluded_address_audit;
+DROP TABLE IF EXISTS excluded_addresses;
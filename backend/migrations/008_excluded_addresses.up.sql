@@ -0,0 +1,41 @@
+-- ==========================================
+-- 积分排除地址
+-- 国库、团队多签、DEX 池、跨链桥等地址不参与积分计算和排行榜
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS excluded_addresses (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL DEFAULT '',
+    address VARCHAR(42) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_excluded_addresses_chain_address UNIQUE (chain_name, address)
+);
+
+-- 索引
+CREATE INDEX idx_excluded_addresses_address ON excluded_addresses(address);
+
+COMMENT ON TABLE excluded_addresses IS '积分排除地址表 - 这些地址不计算积分、不进入排行榜和导出';
+COMMENT ON COLUMN excluded_addresses.chain_name IS '链名称 (空字符串表示所有链)';
+COMMENT ON COLUMN excluded_addresses.created_by IS '添加人';
+
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS excluded_address_audit (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL DEFAULT '',
+    address VARCHAR(42) NOT NULL,
+    action VARCHAR(10) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    operator VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT ck_excluded_address_audit_action CHECK (action IN ('add', 'remove'))
+);
+
+-- 索引
+CREATE INDEX idx_excluded_address_audit_address ON excluded_address_audit(address, created_at);
+CREATE INDEX idx_excluded_address_audit_created ON excluded_address_audit(created_at);
+
+COMMENT ON TABLE excluded_address_audit IS '排除地址审计表 - 记录每次添加/移除排除地址的操作人和时间';
+COMMENT ON COLUMN excluded_address_audit.action IS '操作: add(添加), remove(移除)';
@@ -0,0 +1,14 @@
+-- ==========================================
+-- 回滚推荐奖励
+-- ==========================================
+
+DELETE FROM points_history WHERE calculation_type = 'referral';
+
+ALTER TABLE points_history DROP CONSTRAINT IF EXISTS ck_calculation_type;
+ALTER TABLE points_history
+    ADD CONSTRAINT ck_calculation_type CHECK (calculation_type IN ('normal', 'backfill'));
+
+ALTER TABLE points_history DROP COLUMN IF EXISTS source_address;
+
+DROP TABLE IF EXISTS referrals;
+DROP TABLE IF EXISTS referral_codes;
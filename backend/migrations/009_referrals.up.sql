@@ -0,0 +1,46 @@
+-- ==========================================
+-- 推荐奖励
+-- ==========================================
+
+-- 1. 推荐码表
+CREATE TABLE IF NOT EXISTS referral_codes (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL,
+    owner_address VARCHAR(42) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_referral_codes_code UNIQUE (code),
+    CONSTRAINT uk_referral_codes_owner UNIQUE (owner_address)
+);
+
+COMMENT ON TABLE referral_codes IS '推荐码表 - 每个地址一个推荐码';
+
+-- 2. 推荐关系表
+CREATE TABLE IF NOT EXISTS referrals (
+    id BIGSERIAL PRIMARY KEY,
+    referrer_address VARCHAR(42) NOT NULL,
+    referee_address VARCHAR(42) NOT NULL,
+    code VARCHAR(32) NOT NULL,
+    message TEXT NOT NULL,
+    signature VARCHAR(132) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_referrals_referee UNIQUE (referee_address),
+    CONSTRAINT ck_referrals_self CHECK (referrer_address <> referee_address)
+);
+
+-- 索引
+CREATE INDEX idx_referrals_referrer ON referrals(referrer_address);
+
+COMMENT ON TABLE referrals IS '推荐关系表 - 被推荐人通过 EIP-191 签名绑定推荐码，每个地址只能绑定一次';
+COMMENT ON COLUMN referrals.message IS '被推荐人签名的原文';
+COMMENT ON COLUMN referrals.signature IS '被推荐人的 personal_sign 签名';
+
+-- 3. 积分历史支持推荐奖励
+ALTER TABLE points_history
+    ADD COLUMN IF NOT EXISTS source_address VARCHAR(42);
+
+ALTER TABLE points_history DROP CONSTRAINT IF EXISTS ck_calculation_type;
+ALTER TABLE points_history
+    ADD CONSTRAINT ck_calculation_type CHECK (calculation_type IN ('normal', 'backfill', 'referral'));
+
+COMMENT ON COLUMN points_history.source_address IS '积分来源地址 (referral 类型为产生积分的被推荐人)';
+COMMENT ON COLUMN points_history.calculation_type IS '计算类型: normal(正常), backfill(回溯), referral(推荐奖励)';
@@ -0,0 +1,15 @@
+-- ==========================================
+-- 回滚手动积分调整
+-- ==========================================
+
+DELETE FROM points_history WHERE calculation_type = 'adjustment';
+
+DROP INDEX IF EXISTS idx_points_history_adjustment;
+
+ALTER TABLE points_history DROP CONSTRAINT IF EXISTS ck_calculation_type;
+ALTER TABLE points_history
+    ADD CONSTRAINT ck_calculation_type CHECK (calculation_type IN ('normal', 'backfill', 'referral'));
+
+ALTER TABLE points_history DROP COLUMN IF EXISTS adjustment_id;
+
+DROP TABLE IF EXISTS points_adjustments;
@@ -0,0 +1,41 @@
+-- ==========================================
+-- 手动积分调整
+-- 运营/客服发放或扣回积分（任务奖励、补偿、作弊扣回），保留完整审计记录
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS points_adjustments (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    user_address VARCHAR(42) NOT NULL,
+    amount NUMERIC(20, 10) NOT NULL,
+    reason TEXT NOT NULL,
+    operator VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'applied',
+    reverted_by VARCHAR(100),
+    revert_reason TEXT,
+    reverted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT ck_points_adjustments_amount CHECK (amount <> 0),
+    CONSTRAINT ck_points_adjustments_status CHECK (status IN ('applied', 'reverted'))
+);
+
+-- 索引
+CREATE INDEX idx_points_adjustments_user ON points_adjustments(chain_name, user_address, created_at);
+CREATE INDEX idx_points_adjustments_created ON points_adjustments(created_at);
+
+COMMENT ON TABLE points_adjustments IS '积分调整表 - 记录每次手动发放/扣回积分的操作人、原因和撤销情况';
+COMMENT ON COLUMN points_adjustments.amount IS '调整积分 (正数为发放，负数为扣回)';
+COMMENT ON COLUMN points_adjustments.status IS '状态: applied(已生效), reverted(已撤销)';
+
+-- 积分历史中记录调整及其撤销
+ALTER TABLE points_history
+    ADD COLUMN IF NOT EXISTS adjustment_id BIGINT;
+
+ALTER TABLE points_history DROP CONSTRAINT IF EXISTS ck_calculation_type;
+ALTER TABLE points_history
+    ADD CONSTRAINT ck_calculation_type CHECK (calculation_type IN ('normal', 'backfill', 'referral', 'adjustment'));
+
+CREATE INDEX idx_points_history_adjustment ON points_history(adjustment_id) WHERE adjustment_id IS NOT NULL;
+
+COMMENT ON COLUMN points_history.adjustment_id IS '关联的积分调整 ID (adjustment 类型，撤销时为负数积分)';
+COMMENT ON COLUMN points_history.calculation_type IS '计算类型: normal(正常), backfill(回溯), referral(推荐奖励), adjustment(手动调整)';
@@ -0,0 +1,10 @@
+-- ==========================================
+-- 回滚积分流水与兑换
+-- ==========================================
+
+DROP TABLE IF EXISTS points_redemptions;
+DROP TABLE IF EXISTS points_transactions;
+
+ALTER TABLE user_points DROP COLUMN IF EXISTS spent_points;
+
+COMMENT ON COLUMN user_points.total_points IS '累计积分总数';
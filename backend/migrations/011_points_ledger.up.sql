@@ -0,0 +1,76 @@
+-- ==========================================
+-- 积分流水与兑换
+-- user_points.total_points 保持为累计获得积分，可用积分 = total_points - spent_points
+-- ==========================================
+
+-- 1. 已消费积分
+ALTER TABLE user_points
+    ADD COLUMN IF NOT EXISTS spent_points NUMERIC(20, 10) NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN user_points.total_points IS '累计获得积分总数 (含推荐奖励和手动调整)';
+COMMENT ON COLUMN user_points.spent_points IS '已兑换消费的积分';
+
+-- ==========================================
+
+-- 2. 积分流水表
+CREATE TABLE IF NOT EXISTS points_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    user_address VARCHAR(42) NOT NULL,
+    tx_type VARCHAR(20) NOT NULL,
+    amount NUMERIC(20, 10) NOT NULL,
+    balance_after NUMERIC(20, 10) NOT NULL,
+    reference_id BIGINT,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT ck_points_transactions_type CHECK (tx_type IN ('earn', 'spend', 'adjust', 'expire'))
+);
+
+-- 索引
+CREATE INDEX idx_points_transactions_user ON points_transactions(chain_name, user_address, id);
+CREATE INDEX idx_points_transactions_type ON points_transactions(tx_type, created_at);
+
+COMMENT ON TABLE points_transactions IS '积分流水表 - 记录每一笔积分的获得、消费、调整和过期';
+COMMENT ON COLUMN points_transactions.tx_type IS '类型: earn(获得), spend(兑换消费), adjust(手动调整), expire(过期)';
+COMMENT ON COLUMN points_transactions.amount IS '变动积分 (正数为增加，负数为减少)';
+COMMENT ON COLUMN points_transactions.balance_after IS '流水写入后的可用积分';
+COMMENT ON COLUMN points_transactions.reference_id IS '关联记录 ID (adjust 为 points_adjustments.id, spend 为 points_redemptions.id)';
+
+-- 根据已有积分历史补录流水
+INSERT INTO points_transactions (chain_name, user_address, tx_type, amount, balance_after, reference_id, description, created_at)
+SELECT chain_name,
+       user_address,
+       CASE WHEN calculation_type = 'adjustment' THEN 'adjust' ELSE 'earn' END,
+       points_earned,
+       SUM(points_earned) OVER (PARTITION BY chain_name, user_address ORDER BY id),
+       adjustment_id,
+       calculation_type,
+       created_at
+FROM points_history
+WHERE points_earned <> 0
+ORDER BY id;
+
+-- ==========================================
+
+-- 3. 积分兑换表
+CREATE TABLE IF NOT EXISTS points_redemptions (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    user_address VARCHAR(42) NOT NULL,
+    amount NUMERIC(20, 10) NOT NULL,
+    perk VARCHAR(100) NOT NULL,
+    idempotency_key VARCHAR(100) NOT NULL,
+    message TEXT NOT NULL,
+    signature VARCHAR(132) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_points_redemptions_idempotency UNIQUE (chain_name, user_address, idempotency_key),
+    CONSTRAINT ck_points_redemptions_amount CHECK (amount > 0)
+);
+
+-- 索引
+CREATE INDEX idx_points_redemptions_user ON points_redemptions(chain_name, user_address, created_at);
+
+COMMENT ON TABLE points_redemptions IS '积分兑换表 - 用户签名授权的兑换记录，同一幂等键只会扣减一次';
+COMMENT ON COLUMN points_redemptions.idempotency_key IS '客户端提供的幂等键 (Idempotency-Key 请求头)';
+COMMENT ON COLUMN points_redemptions.message IS '用户签名的原文';
+COMMENT ON COLUMN points_redemptions.signature IS '用户的 personal_sign 签名';
@@ -0,0 +1,12 @@
+-- ==========================================
+-- 回滚积分过期
+-- ==========================================
+
+DROP INDEX IF EXISTS idx_points_history_expiry;
+DROP INDEX IF EXISTS idx_points_history_lots;
+
+ALTER TABLE points_history
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS remaining_points;
+
+ALTER TABLE user_points DROP COLUMN IF EXISTS expired_points;
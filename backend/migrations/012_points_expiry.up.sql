@@ -0,0 +1,52 @@
+-- ==========================================
+-- 积分过期（FIFO 批次）
+-- 每条 points_earned > 0 的积分历史是一个批次，消费、扣回和过期都按先进先出扣减批次剩余积分
+-- 可用积分 = total_points - spent_points - expired_points
+-- ==========================================
+
+-- 1. 已过期积分
+ALTER TABLE user_points
+    ADD COLUMN IF NOT EXISTS expired_points NUMERIC(20, 10) NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN user_points.expired_points IS '已过期的积分';
+
+-- 2. 积分批次剩余量和过期时间
+ALTER TABLE points_history
+    ADD COLUMN IF NOT EXISTS remaining_points NUMERIC(20, 10) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+
+CREATE INDEX idx_points_history_lots ON points_history(chain_name, user_address, calc_period_end, id) WHERE remaining_points > 0;
+CREATE INDEX idx_points_history_expiry ON points_history(chain_name, expires_at) WHERE remaining_points > 0;
+
+COMMENT ON COLUMN points_history.remaining_points IS '批次未消费、未过期的剩余积分';
+COMMENT ON COLUMN points_history.expires_at IS '批次过期时间 (NULL 表示不过期，如手动调整和启用过期前的积分)';
+
+-- 按已有消费和扣回先进先出初始化批次剩余积分（已有批次不设置过期时间）
+WITH deductions AS (
+    SELECT up.chain_name,
+           up.user_address,
+           up.spent_points + COALESCE((
+               SELECT SUM(-h.points_earned)
+               FROM points_history h
+               WHERE h.chain_name = up.chain_name
+                 AND h.user_address = up.user_address
+                 AND h.points_earned < 0
+           ), 0) AS deducted
+    FROM user_points up
+),
+lots AS (
+    SELECT h.id,
+           h.points_earned,
+           SUM(h.points_earned) OVER (
+               PARTITION BY h.chain_name, h.user_address
+               ORDER BY h.calc_period_end, h.id
+           ) AS cumulative,
+           COALESCE(d.deducted, 0) AS deducted
+    FROM points_history h
+    LEFT JOIN deductions d ON d.chain_name = h.chain_name AND d.user_address = h.user_address
+    WHERE h.points_earned > 0
+)
+UPDATE points_history p
+SET remaining_points = GREATEST(0, LEAST(l.points_earned, l.cumulative - l.deducted))
+FROM lots l
+WHERE p.id = l.id;
@@ -0,0 +1,5 @@
+-- ==========================================
+-- 回滚持有时长状态
+-- ==========================================
+
+DROP TABLE IF EXISTS holding_streaks;
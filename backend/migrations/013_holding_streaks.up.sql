@@ -0,0 +1,20 @@
+-- ==========================================
+-- 持有时长（连续持有）状态
+-- 从 balance_changes 推导：转出/销毁时重置或按剩余比例折算持有起点，质押/解押不影响
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS holding_streaks (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    user_address VARCHAR(42) NOT NULL,
+    holding_since TIMESTAMP,
+    held_balance NUMERIC(78, 0) NOT NULL DEFAULT 0,
+    as_of TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_holding_streaks_chain_address UNIQUE (chain_name, user_address)
+);
+
+COMMENT ON TABLE holding_streaks IS '连续持有状态表 - 积分计算时增量更新，用于计算持有时长倍数';
+COMMENT ON COLUMN holding_streaks.holding_since IS '连续持有起点 (NULL 表示当前未持有)';
+COMMENT ON COLUMN holding_streaks.held_balance IS 'as_of 时刻的持有量 (钱包余额 + 质押余额)';
+COMMENT ON COLUMN holding_streaks.as_of IS '状态对应的时间点，之后的余额变动尚未计入';
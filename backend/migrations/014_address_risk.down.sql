@@ -0,0 +1,8 @@
+-- ==========================================
+-- 回滚女巫/刷量检测
+-- ==========================================
+
+ALTER TABLE points_history DROP COLUMN IF EXISTS risk_multiplier;
+
+DROP TABLE IF EXISTS address_risk_audit;
+DROP TABLE IF EXISTS address_risk_scores;
@@ -0,0 +1,60 @@
+-- ==========================================
+-- 女巫/刷量检测
+-- 分析 balance_changes 中的循环转账、快进快出和同源资金集群，按地址记录风险分
+-- 被标记的地址在积分计算时降权或排除，管理员可以人工覆盖
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS address_risk_scores (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    address VARCHAR(42) NOT NULL,
+    score NUMERIC(5, 4) NOT NULL DEFAULT 0,
+    signals JSONB NOT NULL DEFAULT '[]',
+    funder_address VARCHAR(42),
+    override VARCHAR(10) NOT NULL DEFAULT 'none',
+    override_reason TEXT NOT NULL DEFAULT '',
+    override_by VARCHAR(100),
+    override_at TIMESTAMP,
+    analyzed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_address_risk_scores_chain_address UNIQUE (chain_name, address),
+    CONSTRAINT ck_address_risk_scores_score CHECK (score >= 0 AND score <= 1),
+    CONSTRAINT ck_address_risk_scores_override CHECK (override IN ('none', 'clear', 'flag', 'exclude'))
+);
+
+-- 索引
+CREATE INDEX idx_address_risk_scores_score ON address_risk_scores(chain_name, score DESC);
+CREATE INDEX idx_address_risk_scores_funder ON address_risk_scores(chain_name, funder_address) WHERE funder_address IS NOT NULL;
+
+COMMENT ON TABLE address_risk_scores IS '地址风险分表 - 检测任务写入风险分和命中的信号，管理员可覆盖处理结果';
+COMMENT ON COLUMN address_risk_scores.score IS '风险分 (0-1，多个信号按 1 - Π(1 - s) 合并)';
+COMMENT ON COLUMN address_risk_scores.signals IS '命中的信号: circular(循环转账), rapid(快进快出), cluster(同源资金集群)';
+COMMENT ON COLUMN address_risk_scores.funder_address IS '首笔转入的来源地址 (资金集群)';
+COMMENT ON COLUMN address_risk_scores.override IS '人工覆盖: none(按风险分), clear(不处理), flag(降权), exclude(排除)';
+COMMENT ON COLUMN address_risk_scores.analyzed_at IS '最近一次检测时间 (仅人工标记的地址为空)';
+
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS address_risk_audit (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    address VARCHAR(42) NOT NULL,
+    override VARCHAR(10) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    operator VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT ck_address_risk_audit_override CHECK (override IN ('none', 'clear', 'flag', 'exclude'))
+);
+
+-- 索引
+CREATE INDEX idx_address_risk_audit_address ON address_risk_audit(chain_name, address, created_at);
+CREATE INDEX idx_address_risk_audit_created ON address_risk_audit(created_at);
+
+COMMENT ON TABLE address_risk_audit IS '风险覆盖审计表 - 记录每次人工覆盖的操作人、原因和时间';
+
+-- 积分历史中记录风险降权
+ALTER TABLE points_history
+    ADD COLUMN IF NOT EXISTS risk_multiplier NUMERIC(5, 4) NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN points_history.risk_multiplier IS '风险降权倍数 (1 表示未降权，积分已乘以该倍数)';
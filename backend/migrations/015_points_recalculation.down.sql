@@ -0,0 +1,25 @@
+-- ==========================================
+-- 回滚积分全量重算
+-- 删除重算写入的记录并恢复被取代的记录，回滚后需要重新核对 user_points
+-- ==========================================
+
+DELETE FROM points_transactions WHERE tx_type = 'recalculate';
+
+ALTER TABLE points_transactions DROP CONSTRAINT IF EXISTS ck_points_transactions_type;
+ALTER TABLE points_transactions
+    ADD CONSTRAINT ck_points_transactions_type CHECK (tx_type IN ('earn', 'spend', 'adjust', 'expire'));
+
+DELETE FROM points_history WHERE recalculation_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_points_history_chain_period;
+
+ALTER TABLE points_history DROP CONSTRAINT IF EXISTS ck_calculation_type;
+ALTER TABLE points_history
+    ADD CONSTRAINT ck_calculation_type CHECK (calculation_type IN ('normal', 'backfill', 'referral', 'adjustment'));
+
+ALTER TABLE points_history
+    DROP COLUMN IF EXISTS superseded_by,
+    DROP COLUMN IF EXISTS recalculation_id;
+
+DROP TABLE IF EXISTS points_recalculation_diffs;
+DROP TABLE IF EXISTS points_recalculations;
@@ -0,0 +1,78 @@
+-- ==========================================
+-- 积分全量重算
+-- 重算按小时用新结果取代时间段内已有的计算积分（normal、backfill、referral），
+-- 旧记录保留并标记 superseded_by，随后按积分历史重建用户总积分和批次剩余量
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS points_recalculations (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    reason TEXT NOT NULL,
+    operator VARCHAR(100) NOT NULL,
+    periods_done INT NOT NULL DEFAULT 0,
+    users_changed INT NOT NULL DEFAULT 0,
+    points_before NUMERIC(30, 10) NOT NULL DEFAULT 0,
+    points_after NUMERIC(30, 10) NOT NULL DEFAULT 0,
+    error_message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    CONSTRAINT ck_points_recalculations_period CHECK (period_end > period_start),
+    CONSTRAINT ck_points_recalculations_status CHECK (status IN ('running', 'completed', 'failed'))
+);
+
+-- 索引
+CREATE INDEX idx_points_recalculations_chain ON points_recalculations(chain_name, created_at);
+
+COMMENT ON TABLE points_recalculations IS '积分重算表 - 记录每次重算的时间段、操作人、进度和汇总结果';
+COMMENT ON COLUMN points_recalculations.status IS '状态: running(执行中), completed(已完成), failed(失败)';
+COMMENT ON COLUMN points_recalculations.periods_done IS '已重算的小时数';
+COMMENT ON COLUMN points_recalculations.users_changed IS '总积分发生变化的用户数';
+COMMENT ON COLUMN points_recalculations.points_before IS '重算前时间段内的计算积分合计';
+COMMENT ON COLUMN points_recalculations.points_after IS '重算后时间段内的计算积分合计';
+
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS points_recalculation_diffs (
+    id BIGSERIAL PRIMARY KEY,
+    recalculation_id BIGINT NOT NULL,
+    user_address VARCHAR(42) NOT NULL,
+    period_points_before NUMERIC(20, 10) NOT NULL,
+    period_points_after NUMERIC(20, 10) NOT NULL,
+    total_before NUMERIC(20, 10) NOT NULL,
+    total_after NUMERIC(20, 10) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_points_recalculation_diffs_user UNIQUE (recalculation_id, user_address)
+);
+
+COMMENT ON TABLE points_recalculation_diffs IS '积分重算差异表 - 每个受影响用户重算前后的积分';
+COMMENT ON COLUMN points_recalculation_diffs.period_points_before IS '重算前时间段内的计算积分';
+COMMENT ON COLUMN points_recalculation_diffs.period_points_after IS '重算后时间段内的计算积分';
+COMMENT ON COLUMN points_recalculation_diffs.total_before IS '重算前的累计积分 (user_points.total_points)';
+COMMENT ON COLUMN points_recalculation_diffs.total_after IS '按积分历史重建后的累计积分';
+
+-- ==========================================
+
+-- 积分历史中记录重算
+ALTER TABLE points_history
+    ADD COLUMN IF NOT EXISTS recalculation_id BIGINT,
+    ADD COLUMN IF NOT EXISTS superseded_by BIGINT;
+
+ALTER TABLE points_history DROP CONSTRAINT IF EXISTS ck_calculation_type;
+ALTER TABLE points_history
+    ADD CONSTRAINT ck_calculation_type CHECK (calculation_type IN ('normal', 'backfill', 'referral', 'adjustment', 'recalculation'));
+
+CREATE INDEX idx_points_history_chain_period ON points_history(chain_name, calc_period_start) WHERE superseded_by IS NULL;
+
+COMMENT ON COLUMN points_history.recalculation_id IS '写入该记录的积分重算 ID';
+COMMENT ON COLUMN points_history.superseded_by IS '取代该记录的积分重算 ID (不为空表示已失效，不计入总积分)';
+COMMENT ON COLUMN points_history.calculation_type IS '计算类型: normal(正常), backfill(回溯), referral(推荐奖励), adjustment(手动调整), recalculation(重算)';
+
+-- 流水中记录重算导致的总积分变化
+ALTER TABLE points_transactions DROP CONSTRAINT IF EXISTS ck_points_transactions_type;
+ALTER TABLE points_transactions
+    ADD CONSTRAINT ck_points_transactions_type CHECK (tx_type IN ('earn', 'spend', 'adjust', 'expire', 'recalculate'));
+
+COMMENT ON COLUMN points_transactions.tx_type IS '类型: earn(获得), spend(兑换消费), adjust(手动调整), expire(过期), recalculate(重算)';
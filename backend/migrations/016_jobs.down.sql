@@ -0,0 +1,12 @@
+-- ==========================================
+-- 回滚后台任务
+-- ==========================================
+
+ALTER TABLE points_recalculation_diffs DROP COLUMN IF EXISTS rebuilt_at;
+
+UPDATE points_recalculations SET status = 'failed' WHERE status = 'cancelled';
+ALTER TABLE points_recalculations DROP CONSTRAINT IF EXISTS ck_points_recalculations_status;
+ALTER TABLE points_recalculations
+    ADD CONSTRAINT ck_points_recalculations_status CHECK (status IN ('running', 'completed', 'failed'));
+
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,55 @@
+-- ==========================================
+-- 后台任务
+-- 回溯和重算作为持久化任务执行，记录进度和错误，支持取消，服务重启后从最后完成的周期继续
+-- 同一条链同时只允许一个未结束的任务
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    job_type VARCHAR(20) NOT NULL,
+    chain_name VARCHAR(50) NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    progress_done INT NOT NULL DEFAULT 0,
+    progress_total INT NOT NULL DEFAULT 0,
+    cursor_time TIMESTAMP,
+    cancel_requested BOOLEAN NOT NULL DEFAULT FALSE,
+    error_message TEXT,
+    created_by VARCHAR(100) NOT NULL,
+    worker_id VARCHAR(100),
+    heartbeat_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT ck_jobs_type CHECK (job_type IN ('backfill', 'recalculation')),
+    CONSTRAINT ck_jobs_status CHECK (status IN ('pending', 'running', 'completed', 'failed', 'cancelled'))
+);
+
+-- 索引
+CREATE UNIQUE INDEX uk_jobs_active_chain ON jobs(chain_name) WHERE status IN ('pending', 'running');
+CREATE INDEX idx_jobs_chain ON jobs(chain_name, created_at);
+CREATE INDEX idx_jobs_status ON jobs(status, heartbeat_at) WHERE status IN ('pending', 'running');
+
+COMMENT ON TABLE jobs IS '后台任务表 - 回溯、重算等长时间任务的参数、进度和结果';
+COMMENT ON COLUMN jobs.job_type IS '任务类型: backfill(回溯), recalculation(重算)';
+COMMENT ON COLUMN jobs.params IS '任务参数 (JSONB): {start_time, end_time, reason, recalculation_id}';
+COMMENT ON COLUMN jobs.status IS '状态: pending(等待执行), running(执行中), completed(已完成), failed(失败), cancelled(已取消)';
+COMMENT ON COLUMN jobs.cursor_time IS '最后完成的周期结束时间';
+COMMENT ON COLUMN jobs.cancel_requested IS '已请求取消，执行中的任务在当前周期结束后停止';
+COMMENT ON COLUMN jobs.worker_id IS '执行任务的进程 (hostname:pid)';
+COMMENT ON COLUMN jobs.heartbeat_at IS '执行进程最近一次心跳，超时的任务由其他进程接管';
+
+-- ==========================================
+
+-- 重算支持取消和断点续算
+ALTER TABLE points_recalculations DROP CONSTRAINT IF EXISTS ck_points_recalculations_status;
+ALTER TABLE points_recalculations
+    ADD CONSTRAINT ck_points_recalculations_status CHECK (status IN ('running', 'completed', 'failed', 'cancelled'));
+
+COMMENT ON COLUMN points_recalculations.status IS '状态: running(执行中), completed(已完成), failed(失败), cancelled(已取消)';
+
+ALTER TABLE points_recalculation_diffs
+    ADD COLUMN IF NOT EXISTS rebuilt_at TIMESTAMP;
+
+COMMENT ON COLUMN points_recalculation_diffs.rebuilt_at IS '重建总积分的时间 (为空表示只记录了重算前的积分)';
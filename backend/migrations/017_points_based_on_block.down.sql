@@ -0,0 +1,5 @@
+-- ==========================================
+-- 回滚积分计算依据的区块高度
+-- ==========================================
+
+ALTER TABLE points_history DROP COLUMN IF EXISTS based_on_block;
@@ -0,0 +1,9 @@
+-- ==========================================
+-- 积分计算依据的区块高度
+-- 计算前要求监听器已索引到周期结束之后的区块，并记录当时已同步的区块高度
+-- ==========================================
+
+ALTER TABLE points_history
+    ADD COLUMN IF NOT EXISTS based_on_block BIGINT;
+
+COMMENT ON COLUMN points_history.based_on_block IS '计算时已同步（已确认）的区块高度，为空表示计算时未检查同步进度';
@@ -0,0 +1,5 @@
+-- ==========================================
+-- 回滚领导者选举
+-- ==========================================
+
+DROP TABLE IF EXISTS leader_leases;
@@ -0,0 +1,18 @@
+-- ==========================================
+-- 领导者选举
+-- 多实例部署时通过 Postgres advisory lock 选出领导者（锁由领导者的数据库会话持有），
+-- 本表只记录当前领导者用于健康检查展示
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS leader_leases (
+    name VARCHAR(100) PRIMARY KEY,
+    holder VARCHAR(200) NOT NULL,
+    acquired_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    renewed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE leader_leases IS '领导者记录表 - 各选举（如 scheduler）的当前领导者';
+COMMENT ON COLUMN leader_leases.name IS '选举名称';
+COMMENT ON COLUMN leader_leases.holder IS '领导者实例 (hostname:pid)';
+COMMENT ON COLUMN leader_leases.acquired_at IS '当选时间';
+COMMENT ON COLUMN leader_leases.renewed_at IS '最近一次续约时间，长时间未续约说明领导者已退出、等待其他实例接管';
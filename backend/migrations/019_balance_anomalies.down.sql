@@ -0,0 +1,5 @@
+-- ==========================================
+-- 回滚余额异常
+-- ==========================================
+
+DROP TABLE IF EXISTS balance_anomalies;
@@ -0,0 +1,46 @@
+-- ==========================================
+-- 余额异常
+-- 事件使余额变为负数通常说明漏处理了之前的事件，按配置的策略处理后记录在此供人工核查
+-- ==========================================
+
+CREATE TABLE IF NOT EXISTS balance_anomalies (
+    id BIGSERIAL PRIMARY KEY,
+    chain_name VARCHAR(50) NOT NULL,
+    user_address VARCHAR(42) NOT NULL,
+    tx_hash VARCHAR(66) NOT NULL,
+    log_index INT NOT NULL,
+    block_number BIGINT NOT NULL,
+    balance_type VARCHAR(10) NOT NULL,
+    balance_before NUMERIC(78, 0) NOT NULL,
+    amount_delta NUMERIC(78, 0) NOT NULL,
+    computed_balance NUMERIC(78, 0) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    chain_balance NUMERIC(78, 0),
+    balance_after NUMERIC(78, 0),
+    occurrences INT NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    resolved_by VARCHAR(100),
+    resolution_note TEXT,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT uk_balance_anomalies_log_user UNIQUE (chain_name, tx_hash, log_index, user_address, balance_type),
+    CONSTRAINT ck_balance_anomalies_action CHECK (action IN ('clamped', 'rejected', 'reconciled')),
+    CONSTRAINT ck_balance_anomalies_status CHECK (status IN ('open', 'resolved'))
+);
+
+-- 索引
+CREATE INDEX idx_balance_anomalies_status ON balance_anomalies(chain_name, status, id);
+CREATE INDEX idx_balance_anomalies_user ON balance_anomalies(chain_name, user_address);
+
+COMMENT ON TABLE balance_anomalies IS '余额异常表 - 事件导致余额为负时的处理记录';
+COMMENT ON COLUMN balance_anomalies.balance_before IS '处理该事件前的本地余额 (wei单位)';
+COMMENT ON COLUMN balance_anomalies.amount_delta IS '事件的余额变动 (wei单位)';
+COMMENT ON COLUMN balance_anomalies.computed_balance IS '本地余额加变动后的结果 (负数)';
+COMMENT ON COLUMN balance_anomalies.action IS '处理方式: clamped(记为0), rejected(拒绝并写入死信), reconciled(按链上 balanceOf 校正)';
+COMMENT ON COLUMN balance_anomalies.chain_balance IS '链上查询到的事件所在区块之前的余额 (reconciled)';
+COMMENT ON COLUMN balance_anomalies.balance_after IS '最终写入的余额 (rejected 为空)';
+COMMENT ON COLUMN balance_anomalies.occurrences IS '发生次数 (rejected 的事件每次重试都会累加)';
+COMMENT ON COLUMN balance_anomalies.status IS '状态: open(待核查), resolved(已核查)';
+COMMENT ON COLUMN balance_anomalies.resolved_by IS '核查人';
+COMMENT ON COLUMN balance_anomalies.resolution_note IS '核查说明';
@@ -0,0 +1,106 @@
+-- ==========================================
+-- 回滚时间列为 TIMESTAMP
+-- 按会话时区转换回本地时间，执行前将会话时区设置为服务运行的时区
+-- ==========================================
+
+ALTER TABLE user_balances
+    ALTER COLUMN last_update_time TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+
+ALTER TABLE balance_changes
+    ALTER COLUMN block_time TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE user_points
+    ALTER COLUMN last_calc_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+
+ALTER TABLE points_history
+    ALTER COLUMN calc_period_start TYPE TIMESTAMP,
+    ALTER COLUMN calc_period_end TYPE TIMESTAMP,
+    ALTER COLUMN expires_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE sync_state
+    ALTER COLUMN last_sync_at TYPE TIMESTAMP,
+    ALTER COLUMN last_error_at TYPE TIMESTAMP,
+    ALTER COLUMN last_success_at TYPE TIMESTAMP,
+    ALTER COLUMN last_synced_block_time TYPE TIMESTAMP,
+    ALTER COLUMN chain_head_time TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+
+ALTER TABLE failed_events
+    ALTER COLUMN next_retry_at TYPE TIMESTAMP,
+    ALTER COLUMN resolved_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+
+ALTER TABLE raw_events
+    ALTER COLUMN block_time TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE excluded_addresses
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE excluded_address_audit
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE referral_codes
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE referrals
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE points_adjustments
+    ALTER COLUMN reverted_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE points_transactions
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE points_redemptions
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE holding_streaks
+    ALTER COLUMN holding_since TYPE TIMESTAMP,
+    ALTER COLUMN as_of TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+
+ALTER TABLE address_risk_scores
+    ALTER COLUMN override_at TYPE TIMESTAMP,
+    ALTER COLUMN analyzed_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+
+ALTER TABLE address_risk_audit
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE points_recalculations
+    ALTER COLUMN period_start TYPE TIMESTAMP,
+    ALTER COLUMN period_end TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN completed_at TYPE TIMESTAMP;
+
+ALTER TABLE points_recalculation_diffs
+    ALTER COLUMN rebuilt_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE jobs
+    ALTER COLUMN cursor_time TYPE TIMESTAMP,
+    ALTER COLUMN heartbeat_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN started_at TYPE TIMESTAMP,
+    ALTER COLUMN finished_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+
+ALTER TABLE leader_leases
+    ALTER COLUMN acquired_at TYPE TIMESTAMP,
+    ALTER COLUMN renewed_at TYPE TIMESTAMP;
+
+ALTER TABLE balance_anomalies
+    ALTER COLUMN resolved_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
@@ -0,0 +1,111 @@
+-- ==========================================
+-- 时间列改为 TIMESTAMPTZ
+-- 原 TIMESTAMP 列保存的是服务所在时区的本地时间，跨时区部署和夏令时切换时周期边界会错位或重复
+-- 改为 TIMESTAMPTZ 后按绝对时间存储，服务连接统一使用 UTC
+--
+-- 已有数据按会话时区解释后转换：执行前将会话时区设置为服务原来运行的时区，例如
+--   SET TimeZone = 'Asia/Shanghai';
+-- 未设置时使用数据库服务器的默认时区
+-- ==========================================
+
+ALTER TABLE user_balances
+    ALTER COLUMN last_update_time TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE balance_changes
+    ALTER COLUMN block_time TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE user_points
+    ALTER COLUMN last_calc_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE points_history
+    ALTER COLUMN calc_period_start TYPE TIMESTAMPTZ,
+    ALTER COLUMN calc_period_end TYPE TIMESTAMPTZ,
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE sync_state
+    ALTER COLUMN last_sync_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN last_error_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN last_success_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN last_synced_block_time TYPE TIMESTAMPTZ,
+    ALTER COLUMN chain_head_time TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE failed_events
+    ALTER COLUMN next_retry_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN resolved_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE raw_events
+    ALTER COLUMN block_time TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE excluded_addresses
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE excluded_address_audit
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE referral_codes
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE referrals
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE points_adjustments
+    ALTER COLUMN reverted_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE points_transactions
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE points_redemptions
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE holding_streaks
+    ALTER COLUMN holding_since TYPE TIMESTAMPTZ,
+    ALTER COLUMN as_of TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE address_risk_scores
+    ALTER COLUMN override_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN analyzed_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE address_risk_audit
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE points_recalculations
+    ALTER COLUMN period_start TYPE TIMESTAMPTZ,
+    ALTER COLUMN period_end TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN completed_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE points_recalculation_diffs
+    ALTER COLUMN rebuilt_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE jobs
+    ALTER COLUMN cursor_time TYPE TIMESTAMPTZ,
+    ALTER COLUMN heartbeat_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN started_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN finished_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE leader_leases
+    ALTER COLUMN acquired_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN renewed_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE balance_anomalies
+    ALTER COLUMN resolved_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
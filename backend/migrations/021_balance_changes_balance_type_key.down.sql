@@ -0,0 +1,7 @@
+-- ==========================================
+-- 回滚余额变动唯一键
+-- ==========================================
+
+ALTER TABLE balance_changes DROP CONSTRAINT IF EXISTS uk_balance_changes_log_user_type;
+ALTER TABLE balance_changes
+    ADD CONSTRAINT uk_balance_changes_log_user UNIQUE (chain_name, tx_hash, log_index, user_address);
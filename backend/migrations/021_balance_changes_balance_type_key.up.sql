@@ -0,0 +1,9 @@
+-- ==========================================
+-- 余额变动唯一键增加余额类型
+-- 同一条日志可以同时变动同一用户的钱包余额和质押余额（例如托管合约的事件映射），
+-- 同一账户同一余额类型的变动在解析时已合并，唯一键需要区分余额类型
+-- ==========================================
+
+ALTER TABLE balance_changes DROP CONSTRAINT IF EXISTS uk_balance_changes_log_user;
+ALTER TABLE balance_changes
+    ADD CONSTRAINT uk_balance_changes_log_user_type UNIQUE (chain_name, tx_hash, log_index, user_address, balance_type);
@@ -0,0 +1,4 @@
+-- ==========================================
+-- 回滚持有状态重建
+-- 持有状态可以随时从余额变动历史重建，无需恢复
+-- ==========================================
@@ -0,0 +1,7 @@
+-- ==========================================
+-- 重建连续持有状态
+-- 持有余额改为按交易合并钱包和质押余额的变动后计算，存入托管合约不再中断持有；
+-- 旧的持有状态按原规则推导，清空后在下次计算或查询时从余额变动历史重建
+-- ==========================================
+
+TRUNCATE TABLE holding_streaks;
@@ -0,0 +1,6 @@
+-- ==========================================
+-- 回滚积分历史按周期去重
+-- ==========================================
+
+DROP INDEX IF EXISTS uk_points_history_referral_period;
+DROP INDEX IF EXISTS uk_points_history_user_period;
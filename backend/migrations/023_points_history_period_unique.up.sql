@@ -0,0 +1,15 @@
+-- ==========================================
+-- 积分历史按周期去重
+-- 定时计算、启动回溯和回溯任务可能在不同实例上同时计算同一周期，
+-- 同一用户同一周期只保留一条有效的计算积分（normal、backfill）和每个被推荐人一条推荐奖励，
+-- 重复写入时跳过，不再重复累加总积分
+-- 已有重复记录时建索引会失败，需要先对重复的时间段执行积分重算
+-- ==========================================
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_points_history_user_period
+    ON points_history(chain_name, user_address, calc_period_start)
+    WHERE calculation_type IN ('normal', 'backfill') AND superseded_by IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uk_points_history_referral_period
+    ON points_history(chain_name, user_address, calc_period_start, source_address)
+    WHERE calculation_type = 'referral' AND superseded_by IS NULL;